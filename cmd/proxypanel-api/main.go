@@ -21,10 +21,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/tools4net/ezfw/backend/internal/backup"
+	"github.com/tools4net/ezfw/backend/internal/jobs"
+	"github.com/tools4net/ezfw/backend/internal/notify"
+	"github.com/tools4net/ezfw/backend/internal/secrets"
+	"github.com/tools4net/ezfw/backend/internal/store"
 	"github.com/tools4net/ezfw/backend/internal/store/sqlite"
 	// "github.com/tools4net/ezfw/backend/internal/config" // Placeholder for config
 )
@@ -57,4 +68,71 @@ func main() {
 	}
 	defer dbStore.Close() // Ensure DB is closed when main exits
 
+	// Page size limits for list endpoints
+	pagination := store.DefaultPagination
+	pagination.DefaultLimit = envInt("PAGINATION_DEFAULT_LIMIT", pagination.DefaultLimit)
+	pagination.MaxLimit = envInt("PAGINATION_MAX_LIMIT", pagination.MaxLimit)
+	dbStore.SetPagination(pagination)
+	dbStore.SetBusyRetryBudget(time.Duration(envInt("SQLITE_BUSY_RETRY_MS", int(sqlite.DefaultBusyRetryBudget/time.Millisecond))) * time.Millisecond)
+
+	// Encrypt private keys and passwords at rest when a master key is set
+	sealer, err := secrets.NewSealerFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid data encryption key: %v", err)
+	}
+	if sealer != nil {
+		dbStore.SetSealer(sealer)
+		log.Printf("Encrypting sensitive config values with key %s", sealer.KeyID())
+	}
+
+	// Agents watching a config are told when it is updated
+	configNotifier := notify.NewConfigChangeNotifier()
+	appStore := notify.NewStore(dbStore, configNotifier)
+
+	// Maintenance jobs run until shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	jobRunner := jobs.NewRunner(appStore, nil)
+	if err := jobRunner.RegisterBuiltins(); err != nil {
+		log.Fatalf("Failed to register maintenance jobs: %v", err)
+	}
+
+	// Config backups to S3, when a bucket is configured
+	configBackup, err := backup.NewFromEnv(appStore)
+	switch {
+	case errors.Is(err, backup.ErrNotConfigured):
+		log.Printf("Config backups disabled: %v", err)
+	case err != nil:
+		log.Fatalf("Failed to configure config backups: %v", err)
+	default:
+		schedule, err := backup.ScheduleFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid backup schedule: %v", err)
+		}
+		if err := jobRunner.Register(configBackup.Job(schedule)); err != nil {
+			log.Fatalf("Failed to register backup job: %v", err)
+		}
+		log.Printf("Backing up configs on schedule %q", schedule)
+	}
+	jobRunner.Start(ctx)
+	defer jobRunner.Stop()
+
+	_ = appStore // handed to the API router once it is wired up
+
+	<-ctx.Done()
+	log.Printf("Shutting down")
+}
+
+// envInt reads a positive integer from the environment, falling back to def.
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Printf("Ignoring invalid %s=%q, using %d", key, v, def)
+		return def
+	}
+	return n
 }
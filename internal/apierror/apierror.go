@@ -0,0 +1,146 @@
+// Package apierror defines the error body returned by the API and the
+// catalogue of machine-readable codes clients can branch on.
+package apierror
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/tools4net/ezfw/backend/internal/backup"
+	"github.com/tools4net/ezfw/backend/internal/certs"
+	"github.com/tools4net/ezfw/backend/internal/configedit"
+	"github.com/tools4net/ezfw/backend/internal/generator"
+	"github.com/tools4net/ezfw/backend/internal/idempotency"
+	"github.com/tools4net/ezfw/backend/internal/promotion"
+	"github.com/tools4net/ezfw/backend/internal/reverse"
+	"github.com/tools4net/ezfw/backend/internal/schema"
+	"github.com/tools4net/ezfw/backend/internal/shareuri"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+	"github.com/tools4net/ezfw/backend/internal/xraybin"
+)
+
+// Code identifies a failure independently of its human-readable message.
+type Code string
+
+// Error codes. Every code a handler may return is listed here.
+const (
+	CodeNotFound               Code = "NOT_FOUND"
+	CodeConfigNotFound         Code = "CONFIG_NOT_FOUND"
+	CodeRuleSetNotFound        Code = "RULE_SET_NOT_FOUND"
+	CodeConfigValidationFailed Code = "CONFIG_VALIDATION_FAILED"
+	CodeUnresolvedReference    Code = "UNRESOLVED_REFERENCE"
+	CodeRuleIndexOutOfRange    Code = "RULE_INDEX_OUT_OF_RANGE"
+	CodeUnknownOutboundTag     Code = "UNKNOWN_OUTBOUND_TAG"
+	CodeDNSMigrationAmbiguous  Code = "DNS_MIGRATION_AMBIGUOUS"
+	CodeInvalidPromotionTarget Code = "INVALID_PROMOTION_TARGET"
+	CodeCertificateNotFound    Code = "CERTIFICATE_NOT_FOUND"
+	CodeXrayBinaryMissing      Code = "XRAY_BINARY_NOT_CONFIGURED"
+	CodeStoreBusy              Code = "STORE_BUSY"
+	CodeNameConflict           Code = "NAME_CONFLICT"
+	CodeUnknownSection         Code = "UNKNOWN_SECTION"
+	CodeUnknownServiceType     Code = "UNKNOWN_SERVICE_TYPE"
+	CodeInvalidSort            Code = "INVALID_SORT"
+	CodeTooManyIDs             Code = "TOO_MANY_IDS"
+	CodeManagedSection         Code = "MANAGED_SECTION"
+	CodeInvalidRuleOrder       Code = "INVALID_RULE_ORDER"
+	CodeBackupNotConfigured    Code = "BACKUP_NOT_CONFIGURED"
+	CodeUnsupportedScheme      Code = "UNSUPPORTED_SCHEME"
+	CodeInvalidURI             Code = "INVALID_URI"
+	CodeOutboundNotFound       Code = "OUTBOUND_NOT_FOUND"
+	CodeURINotEncodable        Code = "URI_NOT_ENCODABLE"
+	CodeInvalidReversePair     Code = "INVALID_REVERSE_PAIR"
+	CodeReverseDomainInUse     Code = "REVERSE_DOMAIN_IN_USE"
+	CodeIdempotencyInProgress  Code = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeInternal               Code = "INTERNAL_ERROR"
+)
+
+// Error is the wire format of every API error response.
+type Error struct {
+	Status  int                          `json:"status" example:"404"`
+	Code    Code                         `json:"code" example:"CONFIG_NOT_FOUND"`
+	Message string                       `json:"message"`
+	Details []validation.ValidationError `json:"details,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// New returns an Error with the given status, code and message.
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Validation returns a CONFIG_VALIDATION_FAILED error listing errs.
+func Validation(errs []validation.ValidationError) *Error {
+	return &Error{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    CodeConfigValidationFailed,
+		Message: "configuration failed validation",
+		Details: errs,
+	}
+}
+
+// catalogue maps sentinel errors from the domain packages to their code.
+var catalogue = []struct {
+	target error
+	status int
+	code   Code
+}{
+	{sql.ErrNoRows, http.StatusNotFound, CodeNotFound},
+	{generator.ErrUnresolvedReference, http.StatusUnprocessableEntity, CodeUnresolvedReference},
+	{configedit.ErrRuleIndexOutOfRange, http.StatusNotFound, CodeRuleIndexOutOfRange},
+	{configedit.ErrUnknownOutboundTag, http.StatusUnprocessableEntity, CodeUnknownOutboundTag},
+	{configedit.ErrAmbiguousDNSMigration, http.StatusConflict, CodeDNSMigrationAmbiguous},
+	{promotion.ErrInvalidTarget, http.StatusBadRequest, CodeInvalidPromotionTarget},
+	{reverse.ErrInvalidPair, http.StatusBadRequest, CodeInvalidReversePair},
+	{reverse.ErrDomainInUse, http.StatusConflict, CodeReverseDomainInUse},
+	{idempotency.ErrInProgress, http.StatusConflict, CodeIdempotencyInProgress},
+	{certs.ErrNoCertificate, http.StatusNotFound, CodeCertificateNotFound},
+	{xraybin.ErrNotConfigured, http.StatusNotImplemented, CodeXrayBinaryMissing},
+	{backup.ErrNotConfigured, http.StatusNotImplemented, CodeBackupNotConfigured},
+	{store.ErrConflict, http.StatusConflict, CodeNameConflict},
+	{schema.ErrUnknownType, http.StatusNotFound, CodeUnknownServiceType},
+	{shareuri.ErrUnsupportedScheme, http.StatusBadRequest, CodeUnsupportedScheme},
+	{shareuri.ErrInvalidURI, http.StatusBadRequest, CodeInvalidURI},
+	{shareuri.ErrOutboundNotFound, http.StatusNotFound, CodeOutboundNotFound},
+	{shareuri.ErrNotEncodable, http.StatusBadRequest, CodeURINotEncodable},
+	{configedit.ErrInvalidRuleOrder, http.StatusBadRequest, CodeInvalidRuleOrder},
+	{configedit.ErrManagedSection, http.StatusConflict, CodeManagedSection},
+	{store.ErrTooManyIDs, http.StatusBadRequest, CodeTooManyIDs},
+	{store.ErrInvalidSort, http.StatusBadRequest, CodeInvalidSort},
+	{store.ErrUnknownSection, http.StatusBadRequest, CodeUnknownSection},
+	{store.ErrBusy, http.StatusServiceUnavailable, CodeStoreBusy}, // sent with Retry-After
+}
+
+// FromError converts err into an Error. An *Error is returned as-is, a
+// validation.ValidationError becomes a CONFIG_VALIDATION_FAILED error, known
+// sentinel errors get their catalogue code and anything else is reported as
+// INTERNAL_ERROR. notFound, if non-empty, replaces the generic NOT_FOUND code
+// so handlers can name the missing resource.
+func FromError(err error, notFound Code) *Error {
+	if err == nil {
+		return nil
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var validationErr validation.ValidationError
+	if errors.As(err, &validationErr) {
+		return Validation([]validation.ValidationError{validationErr})
+	}
+	for _, entry := range catalogue {
+		if errors.Is(err, entry.target) {
+			code := entry.code
+			if code == CodeNotFound && notFound != "" {
+				code = notFound
+			}
+			return New(entry.status, code, err.Error())
+		}
+	}
+	return New(http.StatusInternalServerError, CodeInternal, err.Error())
+}
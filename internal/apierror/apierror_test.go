@@ -0,0 +1,135 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/backup"
+	"github.com/tools4net/ezfw/backend/internal/configedit"
+	"github.com/tools4net/ezfw/backend/internal/idempotency"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/promotion"
+	"github.com/tools4net/ezfw/backend/internal/reverse"
+	"github.com/tools4net/ezfw/backend/internal/schema"
+	"github.com/tools4net/ezfw/backend/internal/shareuri"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+)
+
+func TestFromError_StoreNotFound(t *testing.T) {
+	st := sqlitetest.New(t)
+
+	_, err := st.GetXrayConfig(context.Background(), "missing")
+	require.Error(t, err)
+
+	apiErr := FromError(err, CodeConfigNotFound)
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Equal(t, CodeConfigNotFound, apiErr.Code)
+	assert.Equal(t, CodeNotFound, FromError(err, "").Code)
+
+	_, err = st.GetRuleSet(context.Background(), "missing")
+	assert.Equal(t, CodeRuleSetNotFound, FromError(err, CodeRuleSetNotFound).Code)
+}
+
+func TestFromError_Catalogue(t *testing.T) {
+	err := configedit.DeleteDNSRule(&models.SingBoxConfig{}, 3)
+	assert.Equal(t, CodeRuleIndexOutOfRange, FromError(err, "").Code)
+
+	wrapped := fmt.Errorf("promote: %w", promotion.ErrInvalidTarget)
+	apiErr := FromError(wrapped, "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeInvalidPromotionTarget, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("update: %w", store.ErrBusy), "")
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+	assert.Equal(t, CodeStoreBusy, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("list: %w", store.ErrInvalidSort), "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeInvalidSort, apiErr.Code)
+
+	apiErr = FromError(backup.ErrNotConfigured, "")
+	assert.Equal(t, http.StatusNotImplemented, apiErr.Status)
+	assert.Equal(t, CodeBackupNotConfigured, apiErr.Code)
+
+	_, err = shareuri.ParseProxyURI("hysteria2://secret@example.com:443")
+	apiErr = FromError(err, "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeUnsupportedScheme, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("import: %w", shareuri.ErrInvalidURI), "")
+	assert.Equal(t, CodeInvalidURI, apiErr.Code)
+
+	_, err = shareuri.BuildProxyURIForTag(&models.XrayConfig{}, "proxy")
+	apiErr = FromError(err, "")
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Equal(t, CodeOutboundNotFound, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("share: %w", shareuri.ErrNotEncodable), "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeURINotEncodable, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("pair: %w", reverse.ErrDomainInUse), "")
+	assert.Equal(t, http.StatusConflict, apiErr.Status)
+	assert.Equal(t, CodeReverseDomainInUse, apiErr.Code)
+
+	apiErr = FromError(idempotency.ErrInProgress, "")
+	assert.Equal(t, http.StatusConflict, apiErr.Status)
+	assert.Equal(t, CodeIdempotencyInProgress, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("pair: %w", reverse.ErrInvalidPair), "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeInvalidReversePair, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("reorder: %w", configedit.ErrInvalidRuleOrder), "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeInvalidRuleOrder, apiErr.Code)
+
+	apiErr = FromError(&configedit.ManagedSectionError{Path: "api", Owner: configedit.XrayAPIOwner}, "")
+	assert.Equal(t, http.StatusConflict, apiErr.Status)
+	assert.Equal(t, CodeManagedSection, apiErr.Code)
+	assert.Contains(t, apiErr.Message, configedit.XrayAPIOwner)
+
+	apiErr = FromError(fmt.Errorf("batch: %w", store.ErrTooManyIDs), "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeTooManyIDs, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("list: %w", store.ErrUnknownSection), "")
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, CodeUnknownSection, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("schema: %w", schema.ErrUnknownType), "")
+	assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	assert.Equal(t, CodeUnknownServiceType, apiErr.Code)
+
+	apiErr = FromError(fmt.Errorf("disk on fire"), CodeConfigNotFound)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.Status)
+	assert.Equal(t, CodeInternal, apiErr.Code)
+
+	assert.Nil(t, FromError(nil, ""))
+}
+
+func TestValidation_WireFormat(t *testing.T) {
+	verr := validation.ValidationError{Field: "inbounds[0].port", Message: "port out of range", Value: "70000"}
+	apiErr := FromError(fmt.Errorf("save: %w", verr), "")
+	assert.Equal(t, CodeConfigValidationFailed, apiErr.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.Status)
+
+	body, err := json.Marshal(apiErr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"status": 422,
+		"code": "CONFIG_VALIDATION_FAILED",
+		"message": "configuration failed validation",
+		"details": [{"field": "inbounds[0].port", "error": "port out of range", "value": "70000"}]
+	}`, string(body))
+
+	// An *Error passes through unchanged
+	assert.Same(t, apiErr, FromError(fmt.Errorf("handler: %w", apiErr), ""))
+}
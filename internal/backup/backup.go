@@ -0,0 +1,187 @@
+// Package backup uploads snapshots of every stored configuration to
+// S3-compatible object storage.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/jobs"
+	"github.com/tools4net/ezfw/backend/internal/secrets"
+	"github.com/tools4net/ezfw/backend/internal/snapshot"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// Environment variables configuring backups. EnvEndpoint points at a
+// MinIO-style server and defaults to AWS; EnvSchedule is a daily or hourly
+// cron spec in UTC (see jobs.ParseCron) and defaults to DefaultSchedule.
+const (
+	EnvBucket   = "BACKUP_S3_BUCKET"
+	EnvPrefix   = "BACKUP_S3_PREFIX"
+	EnvEndpoint = "BACKUP_S3_ENDPOINT"
+	EnvSchedule = "BACKUP_SCHEDULE"
+)
+
+// DefaultSchedule runs the backup job daily at 02:00 UTC unless EnvSchedule
+// says otherwise.
+const DefaultSchedule = "0 2 * * *"
+
+// JobName is the name the backup job is registered under, for RunNow.
+const JobName = "config-backup"
+
+// ErrNotConfigured is returned by NewFromEnv when EnvBucket is unset. API
+// handlers map it to 501 Not Implemented.
+var ErrNotConfigured = errors.New(EnvBucket + " is not set")
+
+// Object describes one stored backup.
+type Object struct {
+	Key          string    `json:"key" example:"ezfw/ezfw-backup-20260101T020000Z.zip"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// ObjectStore is the subset of an object storage API backups need.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body []byte) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// ConfigBackup writes snapshot archives of a store to an ObjectStore.
+type ConfigBackup struct {
+	st      store.Store
+	objects ObjectStore
+	prefix  string
+	sealer  *secrets.Sealer
+	now     func() time.Time
+}
+
+// NewConfigBackup returns a ConfigBackup storing archives under prefix.
+func NewConfigBackup(st store.Store, objects ObjectStore, prefix string) *ConfigBackup {
+	return &ConfigBackup{
+		st:      st,
+		objects: objects,
+		prefix:  strings.Trim(prefix, "/"),
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// NewFromEnv builds a ConfigBackup backed by S3 from the BACKUP_S3_*
+// variables and the usual AWS credential variables.
+func NewFromEnv(st store.Store) (*ConfigBackup, error) {
+	bucket := os.Getenv(EnvBucket)
+	if bucket == "" {
+		return nil, ErrNotConfigured
+	}
+	objects, err := NewS3Store(S3Options{
+		Endpoint:        os.Getenv(EnvEndpoint),
+		Region:          os.Getenv("AWS_REGION"),
+		Bucket:          bucket,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sealer, err := secrets.NewSealerFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	b := NewConfigBackup(st, objects, os.Getenv(EnvPrefix))
+	b.SetSealer(sealer)
+	return b, nil
+}
+
+// ScheduleFromEnv parses EnvSchedule, falling back to DefaultSchedule. A
+// spec jobs.ParseCron cannot represent is an error, not silently replaced.
+func ScheduleFromEnv() (*jobs.Cron, error) {
+	spec := os.Getenv(EnvSchedule)
+	if spec == "" {
+		spec = DefaultSchedule
+	}
+	schedule, err := jobs.ParseCron(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvSchedule, err)
+	}
+	return schedule, nil
+}
+
+// SetSealer makes Run encrypt archives with the master key. Snapshots hold
+// decrypted config values, so a store that seals its secrets should not
+// have them uploaded in the clear.
+func (b *ConfigBackup) SetSealer(sealer *secrets.Sealer) {
+	b.sealer = sealer
+}
+
+// OpenArchive returns the zip archive inside a backup uploaded by Run. Backups
+// made without a sealer are returned unchanged.
+func OpenArchive(sealer *secrets.Sealer, body []byte) ([]byte, error) {
+	if !bytes.HasPrefix(body, []byte(secrets.Prefix)) {
+		return body, nil
+	}
+	if sealer == nil {
+		return nil, fmt.Errorf("backup is encrypted but no %s is configured", secrets.EnvMasterKey)
+	}
+	archive, err := sealer.Decrypt(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt backup: %w", err)
+	}
+	return []byte(archive), nil
+}
+
+// Run exports every config with snapshot.Export and uploads the archive
+// under a timestamped key. With a sealer the archive is encrypted and the
+// key ends in .zip.enc; OpenArchive reverses this.
+func (b *ConfigBackup) Run(ctx context.Context) (*Object, error) {
+	var buf bytes.Buffer
+	if err := snapshot.Export(ctx, b.st, &buf); err != nil {
+		return nil, fmt.Errorf("export configs: %w", err)
+	}
+	body, ext := buf.Bytes(), ".zip"
+	if b.sealer != nil {
+		encrypted, err := b.sealer.Encrypt(buf.String())
+		if err != nil {
+			return nil, fmt.Errorf("encrypt backup: %w", err)
+		}
+		body, ext = []byte(encrypted), ".zip.enc"
+	}
+	now := b.now()
+	key := path.Join(b.prefix, "ezfw-backup-"+now.Format("20060102T150405Z")+ext)
+	if err := b.objects.Put(ctx, key, body); err != nil {
+		return nil, fmt.Errorf("upload backup %s: %w", key, err)
+	}
+	return &Object{Key: key, Size: int64(len(body)), LastModified: now}, nil
+}
+
+// List returns the stored backups, newest first.
+func (b *ConfigBackup) List(ctx context.Context) ([]Object, error) {
+	prefix := b.prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+	objects, err := b.objects.List(ctx, prefix+"ezfw-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("list backups: %w", err)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key > objects[j].Key })
+	return objects, nil
+}
+
+// Job runs the backup on schedule. Manual triggers go through
+// jobs.Runner.RunNow(ctx, JobName).
+func (b *ConfigBackup) Job(schedule jobs.Schedule) jobs.Job {
+	return jobs.Job{
+		Name:     JobName,
+		Schedule: schedule,
+		Run: func(ctx context.Context, _ store.Store) error {
+			_, err := b.Run(ctx)
+			return err
+		},
+	}
+}
@@ -0,0 +1,151 @@
+// Package certs inspects TLS certificates embedded in stored configurations
+// so the panel can surface their expiry dates.
+package certs
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// NoteNotInline is set on entries that only reference a certificate file on
+// the node, which the panel cannot read.
+const NoteNotInline = "not inline: certificate is loaded from a file on the node"
+
+// ErrNoCertificate is returned when PEM input contains no CERTIFICATE block.
+var ErrNoCertificate = errors.New("no PEM certificate found")
+
+// CertInfo describes one certificate, or a file reference when Note is set.
+type CertInfo struct {
+	Location  string    `json:"location,omitempty" example:"inbounds[0].streamSettings.tlsSettings.certificates[0]"`
+	Subject   string    `json:"subject,omitempty" example:"CN=example.com"`
+	Issuer    string    `json:"issuer,omitempty" example:"CN=R3,O=Let's Encrypt,C=US"`
+	DNSNames  []string  `json:"dns_names,omitempty"`
+	NotBefore time.Time `json:"not_before,omitzero"`
+	NotAfter  time.Time `json:"not_after,omitzero"`
+	File      string    `json:"file,omitempty"`
+	Note      string    `json:"note,omitempty"`
+}
+
+// ParseCertificateInfo parses every CERTIFICATE block in a PEM chain given as
+// lines, the way Xray and sing-box store inline certificates. Non-certificate
+// blocks (e.g. keys pasted alongside) are skipped.
+func ParseCertificateInfo(lines []string) ([]CertInfo, error) {
+	rest := []byte(strings.Join(lines, "\n"))
+	var infos []CertInfo
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate %d: %w", len(infos), err)
+		}
+		infos = append(infos, CertInfo{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			DNSNames:  cert.DNSNames,
+			NotBefore: cert.NotBefore.UTC(),
+			NotAfter:  cert.NotAfter.UTC(),
+		})
+	}
+	if len(infos) == 0 {
+		return nil, ErrNoCertificate
+	}
+	return infos, nil
+}
+
+// XrayConfigCertificates collects the certificates of every inbound and
+// outbound TLS/XTLS settings block in config.
+func XrayConfigCertificates(config *models.XrayConfig) ([]CertInfo, error) {
+	var infos []CertInfo
+	var err error
+	for i, in := range config.Inbounds {
+		if infos, err = appendStreamCertificates(infos, fmt.Sprintf("inbounds[%d]", i), in.StreamSettings); err != nil {
+			return nil, err
+		}
+	}
+	for i, out := range config.Outbounds {
+		if infos, err = appendStreamCertificates(infos, fmt.Sprintf("outbounds[%d]", i), out.StreamSettings); err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+func appendStreamCertificates(infos []CertInfo, prefix string, stream *models.StreamSettingsObject) ([]CertInfo, error) {
+	if stream == nil {
+		return infos, nil
+	}
+	var err error
+	if stream.TLSSettings != nil {
+		infos, err = appendXrayCertificates(infos, prefix+".streamSettings.tlsSettings", stream.TLSSettings.Certificates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if stream.XTLSSettings != nil {
+		infos, err = appendXrayCertificates(infos, prefix+".streamSettings.xtlsSettings", stream.XTLSSettings.Certificates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+func appendXrayCertificates(infos []CertInfo, prefix string, certs []models.Certificate) ([]CertInfo, error) {
+	for i, c := range certs {
+		parsed, err := certificateInfo(fmt.Sprintf("%s.certificates[%d]", prefix, i), c.Certificate, c.CertificateFile)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, parsed...)
+	}
+	return infos, nil
+}
+
+// SingBoxConfigCertificates collects the certificates listed in config's
+// top-level certificate section.
+func SingBoxConfigCertificates(config *models.SingBoxConfig) ([]CertInfo, error) {
+	var infos []CertInfo
+	for i, c := range config.Certificate {
+		if c == nil {
+			continue
+		}
+		parsed, err := certificateInfo(fmt.Sprintf("certificate[%d]", i), c.Certificate, c.CertificatePath)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, parsed...)
+	}
+	return infos, nil
+}
+
+// certificateInfo parses an inline chain, or describes a file-only reference.
+func certificateInfo(location string, inline []string, file *string) ([]CertInfo, error) {
+	if len(inline) == 0 {
+		info := CertInfo{Location: location, Note: NoteNotInline}
+		if file != nil {
+			info.File = *file
+		}
+		return []CertInfo{info}, nil
+	}
+	parsed, err := ParseCertificateInfo(inline)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	for i := range parsed {
+		parsed[i].Location = location
+	}
+	return parsed, nil
+}
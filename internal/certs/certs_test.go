@@ -0,0 +1,80 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// selfSignedPEM returns a self-signed certificate for cn as PEM lines.
+func selfSignedPEM(t *testing.T, cn string, notAfter time.Time) []string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return strings.Split(strings.TrimSpace(string(encoded)), "\n")
+}
+
+func TestParseCertificateInfo(t *testing.T) {
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	lines := selfSignedPEM(t, "example.com", notAfter)
+
+	infos, err := ParseCertificateInfo(lines)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "CN=example.com", infos[0].Subject)
+	assert.Equal(t, "CN=example.com", infos[0].Issuer, "self-signed")
+	assert.Equal(t, []string{"example.com"}, infos[0].DNSNames)
+	assert.True(t, notAfter.Equal(infos[0].NotAfter))
+
+	_, err = ParseCertificateInfo([]string{"not a pem"})
+	assert.ErrorIs(t, err, ErrNoCertificate)
+}
+
+func TestXrayConfigCertificates(t *testing.T) {
+	notAfter := time.Date(2031, 6, 1, 0, 0, 0, 0, time.UTC)
+	file := "/etc/xray/cert.pem"
+	config := &models.XrayConfig{Inbounds: []models.InboundObject{
+		{Protocol: "vless"},
+		{Protocol: "trojan", StreamSettings: &models.StreamSettingsObject{TLSSettings: &models.TLSSettings{
+			Certificates: []models.Certificate{
+				{Certificate: selfSignedPEM(t, "a.example.com", notAfter)},
+				{CertificateFile: &file},
+			},
+		}}},
+	}}
+
+	infos, err := XrayConfigCertificates(config)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "inbounds[1].streamSettings.tlsSettings.certificates[0]", infos[0].Location)
+	assert.True(t, notAfter.Equal(infos[0].NotAfter))
+	assert.Equal(t, NoteNotInline, infos[1].Note)
+	assert.Equal(t, file, infos[1].File)
+
+	encoded, err := json.Marshal(infos[1])
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "not_before", "file references have no validity period")
+	assert.NotContains(t, string(encoded), "not_after")
+}
@@ -0,0 +1,113 @@
+package configedit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// ErrManagedSection is returned when an edit changes a section owned by a
+// panel feature.
+var ErrManagedSection = errors.New("section is managed by a panel feature")
+
+// ManagedSectionError reports which managed section an edit touched.
+type ManagedSectionError struct {
+	Path  string
+	Owner string
+}
+
+func (e *ManagedSectionError) Error() string {
+	return fmt.Sprintf("%s: %s is owned by %s, use its endpoints to change it", ErrManagedSection, e.Path, e.Owner)
+}
+
+func (e *ManagedSectionError) Unwrap() error { return ErrManagedSection }
+
+// MarkManaged records owner as the owner of paths. Paths already marked are
+// reassigned rather than duplicated.
+func MarkManaged(config *models.XrayConfig, owner string, paths ...string) {
+	for _, path := range paths {
+		found := false
+		for i := range config.ManagedSections {
+			if config.ManagedSections[i].Path == path {
+				config.ManagedSections[i].Owner = owner
+				found = true
+			}
+		}
+		if !found {
+			config.ManagedSections = append(config.ManagedSections, models.ManagedSection{Path: path, Owner: owner})
+		}
+	}
+}
+
+// UnmarkManaged drops every managed section owned by owner.
+func UnmarkManaged(config *models.XrayConfig, owner string) {
+	kept := config.ManagedSections[:0]
+	for _, section := range config.ManagedSections {
+		if section.Owner != owner {
+			kept = append(kept, section)
+		}
+	}
+	config.ManagedSections = kept
+	if len(kept) == 0 {
+		config.ManagedSections = nil
+	}
+}
+
+// ProtectManagedSections prepares a raw update of current. The managed
+// section list cannot be edited that way, so updated always inherits the one
+// from current. Unless override is set, an update that changes anything under
+// a managed path is rejected with a *ManagedSectionError.
+func ProtectManagedSections(current, updated *models.XrayConfig, override bool) error {
+	updated.ManagedSections = current.ManagedSections
+	if override || len(current.ManagedSections) == 0 {
+		return nil
+	}
+	before, err := models.DeployableDocument(current)
+	if err != nil {
+		return err
+	}
+	after, err := models.DeployableDocument(updated)
+	if err != nil {
+		return err
+	}
+	for _, section := range current.ManagedSections {
+		if !reflect.DeepEqual(lookupPath(before, section.Path), lookupPath(after, section.Path)) {
+			return &ManagedSectionError{Path: section.Path, Owner: section.Owner}
+		}
+	}
+	return nil
+}
+
+// lookupPath resolves a ManagedSection path in a generic JSON document,
+// returning nil when any segment is absent.
+func lookupPath(doc interface{}, path string) interface{} {
+	for _, segment := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			doc = node[segment]
+		case []interface{}:
+			doc = arrayElement(node, segment)
+		default:
+			return nil
+		}
+	}
+	return doc
+}
+
+// arrayElement selects the element tagged segment, falling back to treating
+// segment as an index.
+func arrayElement(list []interface{}, segment string) interface{} {
+	for _, elem := range list {
+		if m, ok := elem.(map[string]interface{}); ok && m["tag"] == segment {
+			return elem
+		}
+	}
+	if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(list) {
+		return list[i]
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package configedit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func managedClientsConfig() *models.XrayConfig {
+	config := &models.XrayConfig{
+		Inbounds: []models.InboundObject{{
+			Tag: "vless-in", Protocol: "vless", Port: 443,
+			Settings: map[string]interface{}{
+				"clients":    []interface{}{map[string]interface{}{"id": "a", "email": "a@example.com"}},
+				"decryption": "none",
+			},
+		}},
+	}
+	MarkManaged(config, "clients", "inbounds.vless-in.settings.clients")
+	return config
+}
+
+func TestProtectManagedSections_RawEditOfManagedClients(t *testing.T) {
+	current := managedClientsConfig()
+
+	updated := managedClientsConfig()
+	updated.ManagedSections = nil // a raw PUT cannot drop the annotation
+	updated.Inbounds[0].Settings["clients"] = []interface{}{}
+	err := ProtectManagedSections(current, updated, false)
+	var managedErr *ManagedSectionError
+	require.True(t, errors.As(err, &managedErr))
+	assert.ErrorIs(t, err, ErrManagedSection)
+	assert.Equal(t, "inbounds.vless-in.settings.clients", managedErr.Path)
+	assert.Equal(t, "clients", managedErr.Owner)
+
+	// Override lets the edit through and keeps the annotation
+	require.NoError(t, ProtectManagedSections(current, updated, true))
+	assert.Equal(t, current.ManagedSections, updated.ManagedSections)
+
+	// Edits outside the managed path are fine
+	updated = managedClientsConfig()
+	updated.ManagedSections = nil
+	updated.Inbounds[0].Port = 8443
+	updated.Inbounds[0].Settings["decryption"] = "other"
+	require.NoError(t, ProtectManagedSections(current, updated, false))
+	assert.Equal(t, current.ManagedSections, updated.ManagedSections)
+}
+
+func TestProtectManagedSections_XrayAPI(t *testing.T) {
+	current := &models.XrayConfig{}
+	require.NoError(t, EnableXrayAPI(current, XrayAPIOptions{Port: 10085, Services: []string{"StatsService"}}))
+	assert.ElementsMatch(t, []models.ManagedSection{
+		{Path: "api", Owner: XrayAPIOwner},
+		{Path: "inbounds.api", Owner: XrayAPIOwner},
+	}, current.ManagedSections)
+
+	updated := &models.XrayConfig{}
+	require.NoError(t, EnableXrayAPI(updated, XrayAPIOptions{Port: 10086, Services: []string{"StatsService"}}))
+	err := ProtectManagedSections(current, updated, false)
+	var managedErr *ManagedSectionError
+	require.True(t, errors.As(err, &managedErr))
+	assert.Equal(t, "inbounds.api", managedErr.Path)
+
+	// Removing a managed section is an edit too
+	err = ProtectManagedSections(current, &models.XrayConfig{}, false)
+	assert.ErrorIs(t, err, ErrManagedSection)
+}
+
+func TestUnmarkManaged(t *testing.T) {
+	config := &models.XrayConfig{}
+	MarkManaged(config, "clients", "inbounds.a.settings.clients")
+	MarkManaged(config, XrayAPIOwner, "api")
+	MarkManaged(config, XrayAPIOwner, "api")
+	require.Len(t, config.ManagedSections, 2)
+
+	UnmarkManaged(config, XrayAPIOwner)
+	assert.Equal(t, []models.ManagedSection{{Path: "inbounds.a.settings.clients", Owner: "clients"}}, config.ManagedSections)
+	UnmarkManaged(config, "clients")
+	assert.Nil(t, config.ManagedSections)
+}
@@ -0,0 +1,220 @@
+// Package configedit contains in-place transformations of stored Xray and
+// SingBox configurations. Functions here only mutate the models they are
+// given; loading and persisting is left to the caller.
+package configedit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// ErrAmbiguousDNSMigration is returned when at least one legacy DNS server
+// cannot be translated to a typed server without guessing. Nothing is
+// modified in that case.
+var ErrAmbiguousDNSMigration = errors.New("legacy dns servers cannot be migrated unambiguously")
+
+// DNSServerMigration records what happened to a single DNS server.
+type DNSServerMigration struct {
+	Index   int    `json:"index"`
+	Tag     string `json:"tag,omitempty"`
+	Address string `json:"address,omitempty"` // Legacy address before migration
+	Type    string `json:"type,omitempty"`    // Typed server after migration
+	Reason  string `json:"reason,omitempty"`  // Why the server was not migrated
+}
+
+// DNSMigrationReport summarises a legacy DNS server migration.
+type DNSMigrationReport struct {
+	Migrated  []DNSServerMigration `json:"migrated"`
+	Unchanged []DNSServerMigration `json:"unchanged"` // Servers that were already typed
+	Ambiguous []DNSServerMigration `json:"ambiguous"` // Servers that block the migration
+}
+
+// MigrateLegacyDNSServers rewrites legacy address-based SingBox DNS servers
+// into the typed form introduced in sing-box 1.12 (type, server, server_port,
+// tls/http/dhcp blocks). Tags, detours and the other dial fields are kept.
+//
+// The migration is all-or-nothing: if any server is ambiguous the report
+// lists it, ErrAmbiguousDNSMigration is returned and dns is left untouched.
+func MigrateLegacyDNSServers(dns *models.SingBoxDNSConfig) (*DNSMigrationReport, error) {
+	report := &DNSMigrationReport{
+		Migrated:  []DNSServerMigration{},
+		Unchanged: []DNSServerMigration{},
+		Ambiguous: []DNSServerMigration{},
+	}
+	if dns == nil {
+		return report, nil
+	}
+
+	migrated := make(map[int]*models.SingBoxDNSServer)
+	for i, server := range dns.Servers {
+		if server == nil {
+			continue
+		}
+		entry := DNSServerMigration{Index: i, Tag: derefString(server.Tag)}
+		if server.Address == nil {
+			if server.Type != nil {
+				entry.Type = *server.Type
+			}
+			report.Unchanged = append(report.Unchanged, entry)
+			continue
+		}
+		entry.Address = *server.Address
+
+		typed, err := migrateLegacyDNSServer(server)
+		if err != nil {
+			entry.Reason = err.Error()
+			report.Ambiguous = append(report.Ambiguous, entry)
+			continue
+		}
+		entry.Type = *typed.Type
+		migrated[i] = typed
+		report.Migrated = append(report.Migrated, entry)
+	}
+
+	if len(report.Ambiguous) > 0 {
+		return report, ErrAmbiguousDNSMigration
+	}
+	for i, typed := range migrated {
+		dns.Servers[i] = typed
+	}
+	return report, nil
+}
+
+// migrateLegacyDNSServer returns a typed copy of a legacy server.
+func migrateLegacyDNSServer(legacy *models.SingBoxDNSServer) (*models.SingBoxDNSServer, error) {
+	if legacy.Strategy != nil {
+		return nil, fmt.Errorf("per-server strategy %q has no typed equivalent; move it to a DNS rule", *legacy.Strategy)
+	}
+
+	typed := *legacy // Keeps Tag, dial fields (detour etc.) and ClientSubnet
+	typed.Type = nil
+	typed.Address = nil
+	typed.AddressResolver = nil
+	typed.AddressStrategy = nil
+
+	if legacy.AddressResolver != nil {
+		resolver := map[string]interface{}{"server": *legacy.AddressResolver}
+		if legacy.AddressStrategy != nil {
+			resolver["strategy"] = *legacy.AddressStrategy
+		}
+		typed.DomainResolver = resolver
+	} else if legacy.AddressStrategy != nil {
+		return nil, fmt.Errorf("address_strategy %q is set without an address_resolver", *legacy.AddressStrategy)
+	}
+
+	address := strings.TrimSpace(*legacy.Address)
+	switch address {
+	case "":
+		return nil, fmt.Errorf("address is empty")
+	case "local":
+		return nil, fmt.Errorf("legacy local resolved through the system; choose a local or dhcp server by hand, the typed local server resolves differently")
+	case "fakeip":
+		typed.Type = stringPtr("fakeip")
+		return &typed, nil
+	}
+
+	if !strings.Contains(address, "://") {
+		if err := setServerAddress(&typed, address); err != nil {
+			return nil, err
+		}
+		typed.Type = stringPtr("udp")
+		return &typed, nil
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("unparsable address: %w", err)
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		typed.Type = stringPtr(u.Scheme)
+	case "tls", "quic":
+		typed.Type = stringPtr(u.Scheme)
+		setTLSServerName(&typed, u.Hostname())
+	case "https", "h3":
+		if u.Scheme == "https" {
+			typed.Type = stringPtr("https")
+		} else {
+			typed.Type = stringPtr("http3")
+		}
+		setTLSServerName(&typed, u.Hostname())
+		if u.Path != "" && u.Path != "/dns-query" {
+			httpSettings := models.SingBoxDNSHTTPSettings{}
+			if typed.HTTP != nil {
+				httpSettings = *typed.HTTP
+			}
+			httpSettings.Path = stringPtr(u.Path)
+			typed.HTTP = &httpSettings
+		}
+	case "dhcp":
+		typed.Type = stringPtr("dhcp")
+		if iface := u.Host; iface != "" && iface != "auto" {
+			typed.DHCP = &models.SingBoxDNSDhcpServerSettings{Interface: stringPtr(iface)}
+		}
+		return &typed, nil
+	case "rcode":
+		return nil, fmt.Errorf("rcode servers were replaced by the predefined DNS rule action")
+	default:
+		return nil, fmt.Errorf("unsupported address scheme %q", u.Scheme)
+	}
+
+	if err := setServerAddress(&typed, u.Host); err != nil {
+		return nil, err
+	}
+	return &typed, nil
+}
+
+// setServerAddress fills Server and, when present, ServerPort from a
+// "host", "host:port" or "[v6]:port" string.
+func setServerAddress(server *models.SingBoxDNSServer, hostport string) error {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		// No port: plain host name, IPv4 or bare IPv6 address
+		host = strings.Trim(hostport, "[]")
+		port = ""
+	}
+	if host == "" {
+		return fmt.Errorf("address %q has no host", hostport)
+	}
+	server.Server = stringPtr(host)
+	if port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("address %q has an invalid port", hostport)
+		}
+		server.ServerPort = &p
+	}
+	return nil
+}
+
+// setTLSServerName adds a tls block carrying the SNI for domain servers.
+// IP literal servers keep sing-box's default behaviour.
+func setTLSServerName(server *models.SingBoxDNSServer, host string) {
+	if host == "" || net.ParseIP(host) != nil {
+		return
+	}
+	if server.TLS == nil {
+		server.TLS = &models.SingBoxDNSTLSSettings{}
+	} else {
+		tls := *server.TLS // Do not modify the legacy server's block
+		server.TLS = &tls
+	}
+	if server.TLS.ServerName == nil {
+		server.TLS.ServerName = stringPtr(host)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
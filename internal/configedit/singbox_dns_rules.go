@@ -0,0 +1,68 @@
+package configedit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// ErrRuleIndexOutOfRange is returned when a rule index does not exist.
+var ErrRuleIndexOutOfRange = errors.New("rule index out of range")
+
+// IndexedDNSRule pairs a DNS rule with its position in dns.rules.
+type IndexedDNSRule struct {
+	Index int                    `json:"index"`
+	Rule  *models.SingBoxDNSRule `json:"rule"`
+}
+
+// ListDNSRules returns the DNS rules of config together with their indices.
+func ListDNSRules(config *models.SingBoxConfig) []IndexedDNSRule {
+	rules := []IndexedDNSRule{}
+	if config.DNS == nil {
+		return rules
+	}
+	for i, rule := range config.DNS.Rules {
+		rules = append(rules, IndexedDNSRule{Index: i, Rule: rule})
+	}
+	return rules
+}
+
+// AppendDNSRule adds rule to the end of dns.rules, creating the DNS section
+// if needed, and returns the new rule's index.
+func AppendDNSRule(config *models.SingBoxConfig, rule *models.SingBoxDNSRule) int {
+	if config.DNS == nil {
+		config.DNS = &models.SingBoxDNSConfig{}
+	}
+	config.DNS.Rules = append(config.DNS.Rules, rule)
+	return len(config.DNS.Rules) - 1
+}
+
+// ReplaceDNSRule overwrites the DNS rule at index.
+func ReplaceDNSRule(config *models.SingBoxConfig, index int, rule *models.SingBoxDNSRule) error {
+	if err := checkDNSRuleIndex(config, index); err != nil {
+		return err
+	}
+	config.DNS.Rules[index] = rule
+	return nil
+}
+
+// DeleteDNSRule removes the DNS rule at index; later rules move up by one.
+func DeleteDNSRule(config *models.SingBoxConfig, index int) error {
+	if err := checkDNSRuleIndex(config, index); err != nil {
+		return err
+	}
+	config.DNS.Rules = append(config.DNS.Rules[:index], config.DNS.Rules[index+1:]...)
+	return nil
+}
+
+func checkDNSRuleIndex(config *models.SingBoxConfig, index int) error {
+	count := 0
+	if config.DNS != nil {
+		count = len(config.DNS.Rules)
+	}
+	if index < 0 || index >= count {
+		return fmt.Errorf("%w: %d (config has %d dns rules)", ErrRuleIndexOutOfRange, index, count)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package configedit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestDNSRuleEditing(t *testing.T) {
+	config := &models.SingBoxConfig{}
+	assert.Empty(t, ListDNSRules(config))
+
+	assert.Equal(t, 0, AppendDNSRule(config, &models.SingBoxDNSRule{Domain: []string{"a.com"}}))
+	assert.Equal(t, 1, AppendDNSRule(config, &models.SingBoxDNSRule{Domain: []string{"b.com"}}))
+	assert.Equal(t, 2, AppendDNSRule(config, &models.SingBoxDNSRule{Domain: []string{"c.com"}}))
+
+	require.NoError(t, ReplaceDNSRule(config, 1, &models.SingBoxDNSRule{Domain: []string{"B.com"}}))
+	assert.Equal(t, []string{"B.com"}, config.DNS.Rules[1].Domain)
+
+	require.NoError(t, DeleteDNSRule(config, 0))
+	rules := ListDNSRules(config)
+	require.Len(t, rules, 2)
+	assert.Equal(t, 0, rules[0].Index)
+	assert.Equal(t, []string{"B.com"}, rules[0].Rule.Domain)
+	assert.Equal(t, []string{"c.com"}, rules[1].Rule.Domain)
+}
+
+func TestDNSRuleEditing_OutOfRange(t *testing.T) {
+	config := &models.SingBoxConfig{}
+	assert.ErrorIs(t, ReplaceDNSRule(config, 0, &models.SingBoxDNSRule{}), ErrRuleIndexOutOfRange)
+
+	AppendDNSRule(config, &models.SingBoxDNSRule{})
+	assert.ErrorIs(t, DeleteDNSRule(config, 1), ErrRuleIndexOutOfRange)
+	assert.ErrorIs(t, DeleteDNSRule(config, -1), ErrRuleIndexOutOfRange)
+	assert.Len(t, config.DNS.Rules, 1, "failed edits must not modify the config")
+}
@@ -0,0 +1,120 @@
+package configedit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestMigrateLegacyDNSServers_AddressMatrix(t *testing.T) {
+	tests := []struct {
+		address    string
+		wantType   string
+		wantServer string
+		wantPort   int
+		wantSNI    string
+		wantPath   string
+		wantIface  string
+	}{
+		{address: "8.8.8.8", wantType: "udp", wantServer: "8.8.8.8"},
+		{address: "8.8.8.8:5353", wantType: "udp", wantServer: "8.8.8.8", wantPort: 5353},
+		{address: "2001:4860:4860::8888", wantType: "udp", wantServer: "2001:4860:4860::8888"},
+		{address: "udp://[2606:4700::1111]:53", wantType: "udp", wantServer: "2606:4700::1111", wantPort: 53},
+		{address: "tcp://1.1.1.1", wantType: "tcp", wantServer: "1.1.1.1"},
+		{address: "tls://dns.google", wantType: "tls", wantServer: "dns.google", wantSNI: "dns.google"},
+		{address: "tls://1.1.1.1:853", wantType: "tls", wantServer: "1.1.1.1", wantPort: 853},
+		{address: "https://1.1.1.1/dns-query", wantType: "https", wantServer: "1.1.1.1"},
+		{address: "https://dns.example.com/custom", wantType: "https", wantServer: "dns.example.com", wantSNI: "dns.example.com", wantPath: "/custom"},
+		{address: "h3://dns.google/dns-query", wantType: "http3", wantServer: "dns.google", wantSNI: "dns.google"},
+		{address: "quic://dns.adguard.com", wantType: "quic", wantServer: "dns.adguard.com", wantSNI: "dns.adguard.com"},
+		{address: "dhcp://auto", wantType: "dhcp"},
+		{address: "dhcp://en0", wantType: "dhcp", wantIface: "en0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.address, func(t *testing.T) {
+			dns := &models.SingBoxDNSConfig{Servers: []*models.SingBoxDNSServer{{
+				Tag:               stringPtr("remote"),
+				Address:           stringPtr(tc.address),
+				SingBoxDialFields: models.SingBoxDialFields{Detour: stringPtr("proxy")},
+			}}}
+
+			report, err := MigrateLegacyDNSServers(dns)
+			require.NoError(t, err)
+			require.Len(t, report.Migrated, 1)
+			assert.Equal(t, tc.wantType, report.Migrated[0].Type)
+
+			server := dns.Servers[0]
+			require.NotNil(t, server.Type)
+			assert.Equal(t, tc.wantType, *server.Type)
+			assert.Nil(t, server.Address, "legacy address must be cleared")
+			assert.Equal(t, "remote", *server.Tag, "tag must be preserved")
+			assert.Equal(t, "proxy", *server.Detour, "detour must be preserved")
+
+			if tc.wantServer != "" {
+				require.NotNil(t, server.Server)
+				assert.Equal(t, tc.wantServer, *server.Server)
+			} else {
+				assert.Nil(t, server.Server)
+			}
+			if tc.wantPort != 0 {
+				require.NotNil(t, server.ServerPort)
+				assert.Equal(t, tc.wantPort, *server.ServerPort)
+			} else {
+				assert.Nil(t, server.ServerPort)
+			}
+			if tc.wantSNI != "" {
+				require.NotNil(t, server.TLS)
+				assert.Equal(t, tc.wantSNI, *server.TLS.ServerName)
+			} else {
+				assert.Nil(t, server.TLS)
+			}
+			if tc.wantPath != "" {
+				require.NotNil(t, server.HTTP)
+				assert.Equal(t, tc.wantPath, *server.HTTP.Path)
+			}
+			if tc.wantIface != "" {
+				require.NotNil(t, server.DHCP)
+				assert.Equal(t, tc.wantIface, *server.DHCP.Interface)
+			}
+		})
+	}
+}
+
+func TestMigrateLegacyDNSServers_AddressResolver(t *testing.T) {
+	dns := &models.SingBoxDNSConfig{Servers: []*models.SingBoxDNSServer{
+		{Tag: stringPtr("bootstrap"), Address: stringPtr("223.5.5.5")},
+		{Tag: stringPtr("doh"), Address: stringPtr("https://dns.google/dns-query"), AddressResolver: stringPtr("bootstrap"), AddressStrategy: stringPtr("ipv4_only")},
+	}}
+
+	_, err := MigrateLegacyDNSServers(dns)
+	require.NoError(t, err)
+	assert.Nil(t, dns.Servers[1].AddressResolver)
+	assert.Nil(t, dns.Servers[1].AddressStrategy)
+	assert.Equal(t, map[string]interface{}{"server": "bootstrap", "strategy": "ipv4_only"}, dns.Servers[1].DomainResolver)
+}
+
+func TestMigrateLegacyDNSServers_RefusesAmbiguous(t *testing.T) {
+	dns := &models.SingBoxDNSConfig{Servers: []*models.SingBoxDNSServer{
+		{Tag: stringPtr("google"), Address: stringPtr("8.8.8.8")},
+		{Tag: stringPtr("block"), Address: stringPtr("rcode://refused")},
+		{Tag: stringPtr("typed"), Type: stringPtr("udp"), Server: stringPtr("1.1.1.1")},
+		{Tag: stringPtr("system"), Address: stringPtr("local")},
+	}}
+
+	report, err := MigrateLegacyDNSServers(dns)
+	require.ErrorIs(t, err, ErrAmbiguousDNSMigration)
+	require.Len(t, report.Ambiguous, 2)
+	assert.Equal(t, "block", report.Ambiguous[0].Tag)
+	assert.NotEmpty(t, report.Ambiguous[0].Reason)
+	assert.Equal(t, "system", report.Ambiguous[1].Tag, "legacy local is not migrated without asking")
+	assert.Contains(t, report.Ambiguous[1].Reason, "by hand")
+	require.Len(t, report.Unchanged, 1)
+	assert.Equal(t, "typed", report.Unchanged[0].Tag)
+
+	// Nothing may be rewritten when the migration is refused
+	assert.Equal(t, "8.8.8.8", *dns.Servers[0].Address)
+	assert.Nil(t, dns.Servers[0].Type)
+}
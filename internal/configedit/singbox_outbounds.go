@@ -0,0 +1,45 @@
+package configedit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// ErrUnknownOutboundTag is returned when a requested order names an outbound
+// the config does not have.
+var ErrUnknownOutboundTag = errors.New("unknown outbound tag")
+
+// SortSingBoxOutbounds reorders config.Outbounds so the outbounds named in
+// tags come first, in that order. Outbounds not listed follow in their
+// original order. Unknown or repeated tags leave config unchanged.
+func SortSingBoxOutbounds(config *models.SingBoxConfig, tags []string) error {
+	byTag := make(map[string]*models.SingBoxOutbound, len(config.Outbounds))
+	for _, out := range config.Outbounds {
+		if out != nil {
+			byTag[out.Tag] = out
+		}
+	}
+
+	placed := make(map[string]bool, len(tags))
+	sorted := make([]*models.SingBoxOutbound, 0, len(config.Outbounds))
+	for _, tag := range tags {
+		out, ok := byTag[tag]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownOutboundTag, tag)
+		}
+		if placed[tag] {
+			return fmt.Errorf("outbound tag %q is listed more than once", tag)
+		}
+		placed[tag] = true
+		sorted = append(sorted, out)
+	}
+	for _, out := range config.Outbounds {
+		if out == nil || !placed[out.Tag] {
+			sorted = append(sorted, out)
+		}
+	}
+	config.Outbounds = sorted
+	return nil
+}
@@ -0,0 +1,48 @@
+package configedit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func outboundConfig(tags ...string) *models.SingBoxConfig {
+	config := &models.SingBoxConfig{}
+	for _, tag := range tags {
+		config.Outbounds = append(config.Outbounds, &models.SingBoxOutbound{Type: "direct", Tag: tag})
+	}
+	return config
+}
+
+func outboundTags(config *models.SingBoxConfig) []string {
+	tags := make([]string, 0, len(config.Outbounds))
+	for _, out := range config.Outbounds {
+		tags = append(tags, out.Tag)
+	}
+	return tags
+}
+
+func TestSortSingBoxOutbounds_Full(t *testing.T) {
+	config := outboundConfig("block", "proxy", "direct")
+	require.NoError(t, SortSingBoxOutbounds(config, []string{"direct", "proxy", "block"}))
+	assert.Equal(t, []string{"direct", "proxy", "block"}, outboundTags(config))
+}
+
+func TestSortSingBoxOutbounds_Partial(t *testing.T) {
+	config := outboundConfig("a", "b", "c", "d", "e")
+	require.NoError(t, SortSingBoxOutbounds(config, []string{"d", "b"}))
+	assert.Equal(t, []string{"d", "b", "a", "c", "e"}, outboundTags(config), "unlisted outbounds keep their order")
+}
+
+func TestSortSingBoxOutbounds_Rejected(t *testing.T) {
+	config := outboundConfig("direct", "proxy")
+
+	err := SortSingBoxOutbounds(config, []string{"proxy", "missing"})
+	assert.ErrorIs(t, err, ErrUnknownOutboundTag)
+	assert.Equal(t, []string{"direct", "proxy"}, outboundTags(config), "config is unchanged on error")
+
+	assert.Error(t, SortSingBoxOutbounds(config, []string{"proxy", "proxy"}))
+	assert.Equal(t, []string{"direct", "proxy"}, outboundTags(config))
+}
@@ -0,0 +1,172 @@
+package configedit
+
+import (
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+)
+
+// XrayAPITag is the tag EnableXrayAPI gives the API object, its inbound and
+// the routing rule between them.
+const XrayAPITag = "api"
+
+// XrayAPIOwner is the managed section owner recorded by EnableXrayAPI.
+const XrayAPIOwner = "xray-api"
+
+// XrayAPIServices lists the gRPC services EnableXrayAPI accepts.
+var XrayAPIServices = []string{"HandlerService", "StatsService", "LoggerService", "RoutingService", "ReflectionService"}
+
+// XrayAPIOptions selects where the Xray gRPC API listens and what it serves.
+type XrayAPIOptions struct {
+	Listen   string   `json:"listen" example:"127.0.0.1"` // Defaults to 127.0.0.1
+	Port     int      `json:"port" example:"10085"`
+	Services []string `json:"services" example:"HandlerService,StatsService"`
+}
+
+// EnableXrayAPI wires up the Xray gRPC API: the api object, a dokodemo-door
+// inbound tagged "api", a routing rule sending that inbound to the API and,
+// when StatsService is requested, the stats object and system policy
+// counters. Applying it again replaces the previous settings instead of
+// adding a second copy. The api object and inbound are marked as managed
+// sections. Invalid options leave config unchanged and are reported as a
+// validation.ValidationError.
+func EnableXrayAPI(config *models.XrayConfig, opts XrayAPIOptions) error {
+	if err := validation.ValidatePort(opts.Port, "port"); err != nil {
+		return err
+	}
+	if len(opts.Services) == 0 {
+		return validation.ValidationError{Field: "services", Message: "at least one service is required"}
+	}
+	stats := false
+	for _, service := range opts.Services {
+		if !containsString(XrayAPIServices, service) {
+			return validation.ValidationError{
+				Field:   "services",
+				Message: "unknown service, expected one of " + strings.Join(XrayAPIServices, ", "),
+				Value:   service,
+			}
+		}
+		stats = stats || service == "StatsService"
+	}
+	listen := opts.Listen
+	if listen == "" {
+		listen = "127.0.0.1"
+	}
+
+	tag := XrayAPITag
+	config.API = &models.APIObject{Tag: &tag, Services: append([]string(nil), opts.Services...)}
+
+	inbound := models.InboundObject{
+		Tag:      XrayAPITag,
+		Listen:   listen,
+		Port:     opts.Port,
+		Protocol: "dokodemo-door",
+		Settings: map[string]interface{}{"address": "127.0.0.1"},
+	}
+	if i := xrayInboundIndex(config, XrayAPITag); i >= 0 {
+		config.Inbounds[i] = inbound
+	} else {
+		config.Inbounds = append(config.Inbounds, inbound)
+	}
+
+	if config.Routing == nil {
+		config.Routing = &models.RoutingObject{}
+	}
+	if xrayAPIRuleIndex(config.Routing.Rules) < 0 {
+		// First, so no catch-all rule can swallow API traffic
+		rule := models.RoutingRule{Type: stringPtr("field"), InboundTag: []string{XrayAPITag}, OutboundTag: stringPtr(XrayAPITag)}
+		config.Routing.Rules = append([]models.RoutingRule{rule}, config.Routing.Rules...)
+	}
+
+	if stats {
+		config.Stats = &models.StatsObject{}
+		if config.Policy == nil {
+			config.Policy = &models.PolicyObject{}
+		}
+		if config.Policy.System == nil {
+			config.Policy.System = &models.SystemPolicy{}
+		}
+		on := true
+		sys := config.Policy.System
+		sys.StatsInboundUplink, sys.StatsInboundDownlink = &on, &on
+		sys.StatsOutboundUplink, sys.StatsOutboundDownlink = &on, &on
+	}
+	MarkManaged(config, XrayAPIOwner, "api", "inbounds."+XrayAPITag)
+	return nil
+}
+
+// DisableXrayAPI removes everything EnableXrayAPI adds: the api object, the
+// api inbound, routing rules targeting the API, the stats object and the
+// system policy stats counters. Policy and routing objects left empty are
+// removed too, as are the managed sections EnableXrayAPI recorded. Disabling
+// a config without the API is a no-op.
+func DisableXrayAPI(config *models.XrayConfig) {
+	tag := XrayAPITag
+	if config.API != nil && config.API.Tag != nil {
+		tag = *config.API.Tag
+	}
+	config.API = nil
+	UnmarkManaged(config, XrayAPIOwner)
+
+	if i := xrayInboundIndex(config, tag); i >= 0 {
+		config.Inbounds = append(config.Inbounds[:i], config.Inbounds[i+1:]...)
+	}
+	if config.Routing != nil {
+		kept := config.Routing.Rules[:0]
+		for _, rule := range config.Routing.Rules {
+			if rule.OutboundTag == nil || *rule.OutboundTag != tag {
+				kept = append(kept, rule)
+			}
+		}
+		config.Routing.Rules = kept
+		if len(kept) == 0 && len(config.Routing.Balancers) == 0 &&
+			config.Routing.DomainStrategy == nil && config.Routing.DomainMatcher == nil {
+			config.Routing = nil
+		}
+	}
+
+	config.Stats = nil
+	if config.Policy != nil && config.Policy.System != nil {
+		sys := config.Policy.System
+		sys.StatsInboundUplink, sys.StatsInboundDownlink = nil, nil
+		sys.StatsOutboundUplink, sys.StatsOutboundDownlink = nil, nil
+		if *sys == (models.SystemPolicy{}) {
+			config.Policy.System = nil
+		}
+		if config.Policy.System == nil && len(config.Policy.Levels) == 0 {
+			config.Policy = nil
+		}
+	}
+}
+
+// xrayInboundIndex returns the index of the inbound tagged tag, or -1.
+func xrayInboundIndex(config *models.XrayConfig, tag string) int {
+	for i, in := range config.Inbounds {
+		if in.Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// xrayAPIRuleIndex returns the index of the rule routing the api inbound to
+// the API, or -1.
+func xrayAPIRuleIndex(rules []models.RoutingRule) int {
+	for i, rule := range rules {
+		if rule.OutboundTag != nil && *rule.OutboundTag == XrayAPITag &&
+			len(rule.InboundTag) == 1 && rule.InboundTag[0] == XrayAPITag {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
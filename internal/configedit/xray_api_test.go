@@ -0,0 +1,88 @@
+package configedit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+)
+
+func TestEnableXrayAPI_Idempotent(t *testing.T) {
+	config := &models.XrayConfig{
+		Inbounds: []models.InboundObject{{Tag: "vless-in", Protocol: "vless", Port: 443}},
+		Routing:  &models.RoutingObject{Rules: []models.RoutingRule{{Network: stringPtr("tcp,udp"), OutboundTag: stringPtr("direct")}}},
+	}
+	opts := XrayAPIOptions{Port: 10085, Services: []string{"HandlerService", "StatsService"}}
+
+	require.NoError(t, EnableXrayAPI(config, opts))
+	first, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, EnableXrayAPI(config, opts))
+	second, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(first), string(second), "enabling twice must not duplicate anything")
+
+	assert.Equal(t, []string{"HandlerService", "StatsService"}, config.API.Services)
+	require.Len(t, config.Inbounds, 2)
+	assert.Equal(t, models.InboundObject{
+		Tag: "api", Listen: "127.0.0.1", Port: 10085, Protocol: "dokodemo-door",
+		Settings: map[string]interface{}{"address": "127.0.0.1"},
+	}, config.Inbounds[1])
+	require.Len(t, config.Routing.Rules, 2)
+	assert.Equal(t, []string{"api"}, config.Routing.Rules[0].InboundTag, "the API rule precedes catch-all rules")
+	assert.NotNil(t, config.Stats)
+	assert.True(t, *config.Policy.System.StatsOutboundDownlink)
+
+	// Re-enabling with new options updates in place
+	require.NoError(t, EnableXrayAPI(config, XrayAPIOptions{Listen: "0.0.0.0", Port: 10086, Services: []string{"LoggerService"}}))
+	require.Len(t, config.Inbounds, 2)
+	assert.Equal(t, 10086, config.Inbounds[1].Port)
+	assert.Equal(t, []string{"LoggerService"}, config.API.Services)
+}
+
+func TestEnableXrayAPI_InvalidOptions(t *testing.T) {
+	config := &models.XrayConfig{}
+
+	err := EnableXrayAPI(config, XrayAPIOptions{Port: 70000, Services: []string{"StatsService"}})
+	var verr validation.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, "port", verr.Field)
+
+	err = EnableXrayAPI(config, XrayAPIOptions{Port: 10085, Services: []string{"StatService"}})
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, "StatService", verr.Value)
+
+	assert.Equal(t, &models.XrayConfig{}, config, "a rejected request changes nothing")
+}
+
+func TestDisableXrayAPI(t *testing.T) {
+	on := true
+	original := &models.XrayConfig{
+		Inbounds: []models.InboundObject{{Tag: "vless-in", Protocol: "vless", Port: 443}},
+		Routing:  &models.RoutingObject{Rules: []models.RoutingRule{{OutboundTag: stringPtr("direct")}}},
+		Policy:   &models.PolicyObject{System: &models.SystemPolicy{OverrideAccessLogPort: &on}},
+	}
+	config := &models.XrayConfig{
+		Inbounds: []models.InboundObject{{Tag: "vless-in", Protocol: "vless", Port: 443}},
+		Routing:  &models.RoutingObject{Rules: []models.RoutingRule{{OutboundTag: stringPtr("direct")}}},
+		Policy:   &models.PolicyObject{System: &models.SystemPolicy{OverrideAccessLogPort: &on}},
+	}
+	require.NoError(t, EnableXrayAPI(config, XrayAPIOptions{Port: 10085, Services: []string{"StatsService"}}))
+
+	DisableXrayAPI(config)
+	assert.Equal(t, original, config, "unrelated settings survive")
+
+	DisableXrayAPI(config)
+	assert.Equal(t, original, config)
+
+	bare := &models.XrayConfig{}
+	require.NoError(t, EnableXrayAPI(bare, XrayAPIOptions{Port: 10085, Services: []string{"StatsService"}}))
+	DisableXrayAPI(bare)
+	assert.Empty(t, bare.Inbounds)
+	assert.Nil(t, bare.Routing, "routing left empty is removed")
+	assert.Nil(t, bare.Policy, "policy left empty is removed")
+	assert.Nil(t, bare.Stats)
+}
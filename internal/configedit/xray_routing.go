@@ -0,0 +1,82 @@
+package configedit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// ErrInvalidRuleOrder is returned when a reorder request is malformed.
+var ErrInvalidRuleOrder = errors.New("invalid rule order")
+
+// XrayRuleReorder moves routing rules either by swapping two positions or,
+// when Order is set, by listing every current index in its new position.
+type XrayRuleReorder struct {
+	FromIndex *int  `json:"from_index,omitempty" example:"2"`
+	ToIndex   *int  `json:"to_index,omitempty" example:"0"`
+	Order     []int `json:"order,omitempty" example:"2,0,1"`
+}
+
+// ReorderXrayRoutingRules applies req to the routing rules of config. Swap
+// indices outside the rule list yield ErrRuleIndexOutOfRange; an order that is
+// not a permutation of the existing indices, or a request mixing or missing
+// both forms, yields ErrInvalidRuleOrder. Config is unchanged on error.
+func ReorderXrayRoutingRules(config *models.XrayConfig, req XrayRuleReorder) error {
+	var rules []models.RoutingRule
+	if config.Routing != nil {
+		rules = config.Routing.Rules
+	}
+
+	if req.Order != nil {
+		if req.FromIndex != nil || req.ToIndex != nil {
+			return fmt.Errorf("%w: use either order or from_index/to_index", ErrInvalidRuleOrder)
+		}
+		if len(req.Order) != len(rules) {
+			return fmt.Errorf("%w: order has %d entries, config has %d routing rules", ErrInvalidRuleOrder, len(req.Order), len(rules))
+		}
+		seen := make([]bool, len(rules))
+		for _, i := range req.Order {
+			if i < 0 || i >= len(rules) || seen[i] {
+				return fmt.Errorf("%w: order must list each index from 0 to %d exactly once", ErrInvalidRuleOrder, len(rules)-1)
+			}
+			seen[i] = true
+		}
+		reordered := make([]models.RoutingRule, len(rules))
+		for pos, i := range req.Order {
+			reordered[pos] = rules[i]
+		}
+		copy(rules, reordered)
+		return nil
+	}
+
+	if req.FromIndex == nil || req.ToIndex == nil {
+		return fmt.Errorf("%w: from_index and to_index are both required", ErrInvalidRuleOrder)
+	}
+	from, to := *req.FromIndex, *req.ToIndex
+	for _, index := range []int{from, to} {
+		if index < 0 || index >= len(rules) {
+			return fmt.Errorf("%w: %d (config has %d routing rules)", ErrRuleIndexOutOfRange, index, len(rules))
+		}
+	}
+	rules[from], rules[to] = rules[to], rules[from]
+	return nil
+}
+
+// ToggleXrayRoutingRule flips the enabled flag of the routing rule at index
+// and returns the new state. A rule without the flag counts as enabled, so
+// the first toggle disables it.
+func ToggleXrayRoutingRule(config *models.XrayConfig, index int) (bool, error) {
+	count := 0
+	if config.Routing != nil {
+		count = len(config.Routing.Rules)
+	}
+	if index < 0 || index >= count {
+		return false, fmt.Errorf("%w: %d (config has %d routing rules)", ErrRuleIndexOutOfRange, index, count)
+	}
+
+	rule := &config.Routing.Rules[index]
+	enabled := rule.Enabled != nil && !*rule.Enabled // New state is the inverse of the current one
+	rule.Enabled = &enabled
+	return enabled, nil
+}
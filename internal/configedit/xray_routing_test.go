@@ -0,0 +1,73 @@
+package configedit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestToggleXrayRoutingRule(t *testing.T) {
+	config := &models.XrayConfig{Routing: &models.RoutingObject{Rules: []models.RoutingRule{{OutboundTag: stringPtr("direct")}}}}
+
+	enabled, err := ToggleXrayRoutingRule(config, 0)
+	require.NoError(t, err)
+	assert.False(t, enabled, "nil means enabled, so the first toggle disables")
+	assert.False(t, *config.Routing.Rules[0].Enabled)
+
+	enabled, err = ToggleXrayRoutingRule(config, 0)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	_, err = ToggleXrayRoutingRule(config, 1)
+	assert.ErrorIs(t, err, ErrRuleIndexOutOfRange)
+	_, err = ToggleXrayRoutingRule(&models.XrayConfig{}, 0)
+	assert.ErrorIs(t, err, ErrRuleIndexOutOfRange)
+}
+
+func TestReorderXrayRoutingRules(t *testing.T) {
+	newConfig := func() *models.XrayConfig {
+		return &models.XrayConfig{Routing: &models.RoutingObject{Rules: []models.RoutingRule{
+			{OutboundTag: stringPtr("a")}, {OutboundTag: stringPtr("b")}, {OutboundTag: stringPtr("c")},
+		}}}
+	}
+	tags := func(config *models.XrayConfig) []string {
+		var out []string
+		for _, rule := range config.Routing.Rules {
+			out = append(out, *rule.OutboundTag)
+		}
+		return out
+	}
+	idx := func(i int) *int { return &i }
+
+	t.Run("swap", func(t *testing.T) {
+		config := newConfig()
+		require.NoError(t, ReorderXrayRoutingRules(config, XrayRuleReorder{FromIndex: idx(2), ToIndex: idx(0)}))
+		assert.Equal(t, []string{"c", "b", "a"}, tags(config))
+	})
+
+	t.Run("full reorder", func(t *testing.T) {
+		config := newConfig()
+		require.NoError(t, ReorderXrayRoutingRules(config, XrayRuleReorder{Order: []int{2, 0, 1}}))
+		assert.Equal(t, []string{"c", "a", "b"}, tags(config))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		config := newConfig()
+		err := ReorderXrayRoutingRules(config, XrayRuleReorder{FromIndex: idx(0), ToIndex: idx(3)})
+		assert.ErrorIs(t, err, ErrRuleIndexOutOfRange)
+		err = ReorderXrayRoutingRules(config, XrayRuleReorder{FromIndex: idx(-1), ToIndex: idx(0)})
+		assert.ErrorIs(t, err, ErrRuleIndexOutOfRange)
+		err = ReorderXrayRoutingRules(config, XrayRuleReorder{FromIndex: idx(0)})
+		assert.ErrorIs(t, err, ErrInvalidRuleOrder)
+
+		for _, order := range [][]int{{0, 1}, {0, 1, 1}, {0, 1, 3}, {}} {
+			err = ReorderXrayRoutingRules(config, XrayRuleReorder{Order: order})
+			assert.ErrorIs(t, err, ErrInvalidRuleOrder, "order %v", order)
+		}
+		err = ReorderXrayRoutingRules(config, XrayRuleReorder{Order: []int{1, 0, 2}, FromIndex: idx(0), ToIndex: idx(1)})
+		assert.ErrorIs(t, err, ErrInvalidRuleOrder)
+		assert.Equal(t, []string{"a", "b", "c"}, tags(config), "rejected requests leave rules untouched")
+	})
+}
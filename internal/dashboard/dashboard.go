@@ -0,0 +1,92 @@
+// Package dashboard aggregates the figures shown on the panel's landing page
+// so the UI can fetch them in a single request.
+package dashboard
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// Timeout bounds the whole summary, including every fanned-out query.
+const Timeout = 2 * time.Second
+
+// RecentLimit is the number of entries in DashboardSummary.RecentConfigs.
+const RecentLimit = 10
+
+// RecentConfigEntry is a recently updated config of any type.
+type RecentConfigEntry struct {
+	Type      string    `json:"type" example:"xray"`
+	ID        string    `json:"id" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"`
+	Name      string    `json:"name" example:"edge"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DashboardSummary is the body of GET /api/v2/dashboard/summary.
+type DashboardSummary struct {
+	ConfigCounts  map[string]int      `json:"config_counts"`
+	RecentConfigs []RecentConfigEntry `json:"recent_configs"`
+}
+
+// Summary runs the dashboard queries concurrently and aggregates them. It
+// fails with the first query error, or with context.DeadlineExceeded once
+// Timeout has passed.
+func Summary(ctx context.Context, st store.Store) (*DashboardSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var (
+		xrayCount, singBoxCount int
+		xrays                   []*models.XrayConfig
+		singBoxes               []*models.SingBoxConfig
+	)
+	queries := []func() error{
+		func() (err error) { xrayCount, err = st.CountXrayConfigs(ctx); return },
+		func() (err error) { singBoxCount, err = st.CountSingBoxConfigs(ctx); return },
+		func() (err error) { xrays, err = st.ListXrayConfigs(ctx, RecentLimit, 0, store.Sort{}); return },
+		func() (err error) { singBoxes, err = st.ListSingBoxConfigs(ctx, RecentLimit, 0, store.Sort{}); return },
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := query(); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel() // Abandon the remaining queries
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	recent := make([]RecentConfigEntry, 0, len(xrays)+len(singBoxes))
+	for _, c := range xrays {
+		recent = append(recent, RecentConfigEntry{Type: models.ConfigTypeXray, ID: c.ID, Name: c.Name, UpdatedAt: c.UpdatedAt})
+	}
+	for _, c := range singBoxes {
+		recent = append(recent, RecentConfigEntry{Type: models.ConfigTypeSingBox, ID: c.ID, Name: c.Name, UpdatedAt: c.UpdatedAt})
+	}
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].UpdatedAt.After(recent[j].UpdatedAt) })
+	if len(recent) > RecentLimit {
+		recent = recent[:RecentLimit]
+	}
+
+	return &DashboardSummary{
+		ConfigCounts:  map[string]int{models.ConfigTypeXray: xrayCount, models.ConfigTypeSingBox: singBoxCount},
+		RecentConfigs: recent,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+
+	for _, name := range []string{"x1", "x2", "x3"} {
+		require.NoError(t, st.CreateXrayConfig(ctx, &models.XrayConfig{Name: name}))
+		time.Sleep(2 * time.Millisecond) // Distinct updated_at values
+	}
+	latest := &models.SingBoxConfig{Name: "sb"}
+	require.NoError(t, st.CreateSingBoxConfig(ctx, latest))
+
+	summary, err := Summary(ctx, st)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{models.ConfigTypeXray: 3, models.ConfigTypeSingBox: 1}, summary.ConfigCounts)
+	require.Len(t, summary.RecentConfigs, 4)
+	assert.Equal(t, RecentConfigEntry{Type: models.ConfigTypeSingBox, ID: latest.ID, Name: "sb", UpdatedAt: latest.UpdatedAt}, summary.RecentConfigs[0])
+	assert.Equal(t, "x3", summary.RecentConfigs[1].Name)
+	assert.Equal(t, "x1", summary.RecentConfigs[3].Name)
+}
+
+// failingStore fails CountSingBoxConfigs and otherwise delegates.
+type failingStore struct {
+	store.Store
+}
+
+var errCount = errors.New("count failed")
+
+func (failingStore) CountSingBoxConfigs(context.Context) (int, error) { return 0, errCount }
+
+func TestSummary_QueryError(t *testing.T) {
+	_, err := Summary(context.Background(), failingStore{sqlitetest.New(t)})
+	assert.ErrorIs(t, err, errCount)
+}
@@ -0,0 +1,23 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/version"
+)
+
+// XrayModelVersionWarning returns a warning when config was authored for an
+// Xray version older than the one named by XRAY_CURRENT_VERSION, and "" when
+// either version is unknown or the config is current.
+func XrayModelVersionWarning(config *models.XrayConfig) string {
+	current := os.Getenv(version.EnvXrayCurrentVersion)
+	if config == nil || config.ModelVersion == "" || current == "" {
+		return ""
+	}
+	if version.AtLeast(config.ModelVersion, current) {
+		return ""
+	}
+	return fmt.Sprintf("config was authored for xray %s, older than the current %s", config.ModelVersion, current)
+}
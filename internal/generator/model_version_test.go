@@ -0,0 +1,21 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/version"
+)
+
+func TestXrayModelVersionWarning(t *testing.T) {
+	t.Setenv(version.EnvXrayCurrentVersion, "1.8.4")
+
+	assert.Contains(t, XrayModelVersionWarning(&models.XrayConfig{ModelVersion: "1.8.0"}), "1.8.0")
+	assert.Empty(t, XrayModelVersionWarning(&models.XrayConfig{ModelVersion: "1.8.4"}))
+	assert.Empty(t, XrayModelVersionWarning(&models.XrayConfig{ModelVersion: "v1.9.0"}))
+	assert.Empty(t, XrayModelVersionWarning(&models.XrayConfig{}), "unknown model version")
+
+	t.Setenv(version.EnvXrayCurrentVersion, "")
+	assert.Empty(t, XrayModelVersionWarning(&models.XrayConfig{ModelVersion: "1.0.0"}), "unknown current version")
+}
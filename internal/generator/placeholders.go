@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// placeholderPattern matches ${KEY} references in string values.
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// GenerateInput carries the per-request inputs of config generation.
+type GenerateInput struct {
+	// Substitutions resolves ${KEY} placeholders in string values, so
+	// secrets can be kept out of stored configs.
+	Substitutions map[string]string `json:"substitutions,omitempty"`
+}
+
+// Generated is a rendered config together with the placeholders that had no
+// substitution and were left in place.
+type Generated struct {
+	Document   []byte   `json:"document"`
+	Unresolved []string `json:"unresolved_placeholders"`
+}
+
+// GenerateXray renders config like RenderXray and then resolves placeholders
+// from in.Substitutions. Config itself is not modified.
+func GenerateXray(config *models.XrayConfig, in GenerateInput) (*Generated, error) {
+	doc, err := xrayDocument(config)
+	if err != nil {
+		return nil, err
+	}
+	return generate(doc, in)
+}
+
+// GenerateSingBox is the SingBox counterpart of GenerateXray.
+func GenerateSingBox(config *models.SingBoxConfig, in GenerateInput) (*Generated, error) {
+	doc, err := singBoxDocument(config)
+	if err != nil {
+		return nil, err
+	}
+	return generate(doc, in)
+}
+
+func generate(doc map[string]interface{}, in GenerateInput) (*Generated, error) {
+	unresolved := resolvePlaceholders(doc, in.Substitutions)
+	out, err := marshalDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Generated{Document: out, Unresolved: unresolved}, nil
+}
+
+// resolvePlaceholders replaces ${KEY} in every string value of doc, in place,
+// with subs[KEY]. Placeholders without a substitution are left intact and
+// their keys are returned, sorted and without duplicates.
+func resolvePlaceholders(doc map[string]interface{}, subs map[string]string) []string {
+	missing := make(map[string]bool)
+	var resolve func(v interface{}) interface{}
+	resolve = func(v interface{}) interface{} {
+		switch node := v.(type) {
+		case string:
+			return placeholderPattern.ReplaceAllStringFunc(node, func(match string) string {
+				key := placeholderPattern.FindStringSubmatch(match)[1]
+				if value, ok := subs[key]; ok {
+					return value
+				}
+				missing[key] = true
+				return match
+			})
+		case map[string]interface{}:
+			for k, child := range node {
+				node[k] = resolve(child)
+			}
+		case []interface{}:
+			for i, child := range node {
+				node[i] = resolve(child)
+			}
+		}
+		return v
+	}
+	resolve(doc)
+
+	unresolved := make([]string, 0, len(missing))
+	for key := range missing {
+		unresolved = append(unresolved, key)
+	}
+	sort.Strings(unresolved)
+	return unresolved
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestGenerateXray_ResolvesPlaceholders(t *testing.T) {
+	config := &models.XrayConfig{
+		Inbounds: []models.InboundObject{{
+			Tag: "vless-in", Protocol: "vless", Port: 443,
+			Settings: map[string]interface{}{
+				"clients": []interface{}{map[string]interface{}{"id": "${CLIENT_UUID}", "email": "${USER}@${DOMAIN}"}},
+			},
+		}},
+	}
+
+	got, err := GenerateXray(config, GenerateInput{Substitutions: map[string]string{
+		"CLIENT_UUID": "5f3c1d7e-0000-4000-8000-000000000001",
+		"USER":        "alice",
+	}})
+	require.NoError(t, err)
+
+	var doc struct {
+		Inbounds []struct {
+			Port     int `json:"port"`
+			Settings struct {
+				Clients []map[string]string `json:"clients"`
+			} `json:"settings"`
+		} `json:"inbounds"`
+	}
+	require.NoError(t, json.Unmarshal(got.Document, &doc))
+	client := doc.Inbounds[0].Settings.Clients[0]
+	assert.Equal(t, "5f3c1d7e-0000-4000-8000-000000000001", client["id"])
+	assert.Equal(t, "alice@${DOMAIN}", client["email"], "unknown placeholders are left intact")
+	assert.Equal(t, 443, doc.Inbounds[0].Port)
+	assert.Equal(t, []string{"DOMAIN"}, got.Unresolved)
+
+	assert.Equal(t, "${CLIENT_UUID}", config.Inbounds[0].Settings["clients"].([]interface{})[0].(map[string]interface{})["id"],
+		"the stored config is not modified")
+}
+
+func TestGenerateSingBox_ReportsUnresolved(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Outbounds: []*models.SingBoxOutbound{{Type: "trojan", Tag: "out", Settings: map[string]interface{}{"password": "${PASS}"}}},
+		Route:     &models.SingBoxRouteConfig{Final: strPtr("${FINAL}")},
+	}
+	got, err := GenerateSingBox(config, GenerateInput{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FINAL", "PASS"}, got.Unresolved)
+	assert.Contains(t, string(got.Document), "${PASS}")
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// RenderXray returns the indented JSON document handed to the xray binary for
+// config, without panel metadata such as ID, name and timestamps. Routing
+// rules with enabled explicitly set to false are left out; config itself is
+// not modified.
+func RenderXray(config *models.XrayConfig) ([]byte, error) {
+	doc, err := xrayDocument(config)
+	if err != nil {
+		return nil, err
+	}
+	return marshalDocument(doc)
+}
+
+// xrayDocument builds the generic document RenderXray marshals.
+func xrayDocument(config *models.XrayConfig) (map[string]interface{}, error) {
+	if config == nil {
+		return nil, fmt.Errorf("cannot render nil xray config")
+	}
+	return models.DeployableDocument(config)
+}
+
+// RenderSingBox is the SingBox counterpart of RenderXray.
+func RenderSingBox(config *models.SingBoxConfig) ([]byte, error) {
+	doc, err := singBoxDocument(config)
+	if err != nil {
+		return nil, err
+	}
+	return marshalDocument(doc)
+}
+
+// singBoxDocument builds the generic document RenderSingBox marshals.
+func singBoxDocument(config *models.SingBoxConfig) (map[string]interface{}, error) {
+	if config == nil {
+		return nil, fmt.Errorf("cannot render nil singbox config")
+	}
+	return models.DeployableDocument(config)
+}
+
+func marshalDocument(doc map[string]interface{}) ([]byte, error) {
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rendered config: %w", err)
+	}
+	return out, nil
+}
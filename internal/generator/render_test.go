@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestRenderXray_SkipsDisabledRoutingRules(t *testing.T) {
+	disabled := false
+	config := &models.XrayConfig{
+		Name: "edge",
+		Routing: &models.RoutingObject{Rules: []models.RoutingRule{
+			{OutboundTag: strPtr("block"), Domain: []string{"ads.example"}, Enabled: &disabled},
+			{OutboundTag: strPtr("direct"), Domain: []string{"lan.example"}},
+		}},
+	}
+
+	out, err := RenderXray(config)
+	require.NoError(t, err)
+
+	var doc struct {
+		Name    string `json:"name"`
+		Routing struct {
+			Rules []map[string]interface{} `json:"rules"`
+		} `json:"routing"`
+	}
+	require.NoError(t, json.Unmarshal(out, &doc))
+	assert.Empty(t, doc.Name, "panel metadata must not be rendered")
+	require.Len(t, doc.Routing.Rules, 1)
+	assert.Equal(t, "direct", doc.Routing.Rules[0]["outboundTag"])
+
+	require.Len(t, config.Routing.Rules, 2, "the stored config keeps disabled rules")
+}
@@ -0,0 +1,55 @@
+// Package generator turns stored configurations into the documents emitted
+// to proxy nodes, resolving references to panel-managed resources.
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+)
+
+// ErrUnresolvedReference is returned when a config references a resource the
+// generator cannot find.
+var ErrUnresolvedReference = errors.New("unresolved reference")
+
+// ExpandSingBoxRuleSets appends a route.rule_set entry for every managed
+// rule-set referenced by config's route or DNS rules that is not already
+// defined inline. Config is modified in place, so callers emitting a stored
+// config should pass a copy. Unknown tags are reported together and leave
+// config untouched.
+func ExpandSingBoxRuleSets(config *models.SingBoxConfig, managed []*models.SingBoxRuleSet) error {
+	if errs := validation.SingBoxRuleSetReferenceErrors(config, managed); len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnresolvedReference, joinValidationErrors(errs))
+	}
+
+	byTag := make(map[string]*models.SingBoxRuleSet, len(managed))
+	for _, rs := range managed {
+		byTag[rs.Tag] = rs
+	}
+	defined := validation.InlineRuleSetTags(config)
+
+	for _, ref := range validation.SingBoxRuleSetReferences(config) {
+		if defined[ref.Tag] {
+			continue
+		}
+		if config.Route == nil {
+			config.Route = &models.SingBoxRouteConfig{}
+		}
+		config.Route.RuleSet = append(config.Route.RuleSet, byTag[ref.Tag].RouteRuleSet())
+		defined[ref.Tag] = true
+	}
+	return nil
+}
+
+func joinValidationErrors(errs []validation.ValidationError) string {
+	msg := ""
+	for i, e := range errs {
+		if i > 0 {
+			msg += "; "
+		}
+		msg += e.Error()
+	}
+	return msg
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestExpandSingBoxRuleSets(t *testing.T) {
+	managed := []*models.SingBoxRuleSet{
+		{Tag: "geosite-cn", Type: models.RuleSetTypeRemote, Format: models.RuleSetFormatBinary, URL: "https://example.com/geosite-cn.srs", DownloadDetour: "direct"},
+		{Tag: "ads", Type: models.RuleSetTypeLocal, Format: models.RuleSetFormatSource, Path: "/etc/sing-box/ads.json"},
+		{Tag: "unused", Type: models.RuleSetTypeRemote, Format: models.RuleSetFormatBinary, URL: "https://example.com/unused.srs"},
+	}
+	config := &models.SingBoxConfig{
+		Route: &models.SingBoxRouteConfig{
+			Rules: []*models.SingBoxRouteRule{
+				{RuleSet: []string{"geosite-cn"}, Outbound: strPtr("direct")},
+				{Mode: strPtr("or"), Rules: []*models.SingBoxRouteRule{{RuleSet: []string{"private"}}}},
+			},
+			RuleSet: []map[string]interface{}{{"tag": "private", "type": "local", "format": "binary", "path": "private.srs"}},
+		},
+		DNS: &models.SingBoxDNSConfig{Rules: []*models.SingBoxDNSRule{{RuleSet: []string{"ads", "geosite-cn"}}}},
+	}
+
+	require.NoError(t, ExpandSingBoxRuleSets(config, managed))
+	require.Len(t, config.Route.RuleSet, 3, "inline entry plus each referenced managed rule-set once")
+	assert.Equal(t, "private", config.Route.RuleSet[0]["tag"])
+	assert.Equal(t, map[string]interface{}{
+		"tag": "geosite-cn", "type": "remote", "format": "binary",
+		"url": "https://example.com/geosite-cn.srs", "download_detour": "direct",
+	}, config.Route.RuleSet[1])
+	assert.Equal(t, "/etc/sing-box/ads.json", config.Route.RuleSet[2]["path"])
+}
+
+func TestExpandSingBoxRuleSets_UnknownTag(t *testing.T) {
+	config := &models.SingBoxConfig{
+		DNS: &models.SingBoxDNSConfig{Rules: []*models.SingBoxDNSRule{{RuleSet: []string{"missing"}}}},
+	}
+
+	err := ExpandSingBoxRuleSets(config, nil)
+	require.ErrorIs(t, err, ErrUnresolvedReference)
+	assert.Contains(t, err.Error(), "dns.rules[0].rule_set[0]")
+	assert.Nil(t, config.Route, "config must be untouched on error")
+}
@@ -0,0 +1,144 @@
+// Package graph derives the dependency graph of a configuration: which
+// inbounds route to which outbounds, and which outbounds are chained
+// through others. It only reads the model and is used both for rendering
+// diagrams and for detecting detour cycles.
+package graph
+
+// Node kinds.
+const (
+	KindInbound   = "inbound"
+	KindOutbound  = "outbound"
+	KindBalancer  = "balancer"
+	KindDNSServer = "dns_server"
+	KindEndpoint  = "endpoint"
+	KindBridge    = "bridge"
+	KindPortal    = "portal"
+)
+
+// Edge kinds. RoutesTo edges come from routing rules, balancer selectors and
+// selector outbounds; DetoursVia from sing-box detours and Xray
+// sockopt.dialerProxy; ProxiedThrough from Xray proxySettings.
+const (
+	EdgeRoutesTo       = "routes-to"
+	EdgeDetoursVia     = "detours-via"
+	EdgeProxiedThrough = "proxied-through"
+)
+
+// Node is one tagged element of a config. ID is Kind and Tag joined by a
+// colon, since Xray keeps separate tag namespaces per kind.
+type Node struct {
+	ID   string `json:"id" example:"outbound:proxy"`
+	Kind string `json:"kind" example:"outbound"`
+	Tag  string `json:"tag" example:"proxy"`
+	Path string `json:"path" example:"outbounds[1]"`
+}
+
+// Edge is a dependency from one node on another.
+type Edge struct {
+	From string `json:"from" example:"inbound:socks-in"`
+	To   string `json:"to" example:"outbound:proxy"`
+	Kind string `json:"kind" example:"routes-to"`
+}
+
+// Graph is an adjacency list of a config. Each entry of Cycles lists the IDs
+// along a loop, starting and ending at the same node.
+type Graph struct {
+	Nodes  []Node     `json:"nodes"`
+	Edges  []Edge     `json:"edges"`
+	Cycles [][]string `json:"cycles"`
+}
+
+// builder accumulates nodes and edges, ignoring duplicates and edges to tags
+// that do not exist (unresolved references are reported elsewhere).
+type builder struct {
+	g     *Graph
+	nodes map[string]bool
+	edges map[Edge]bool
+}
+
+func newBuilder() *builder {
+	return &builder{
+		g:     &Graph{Nodes: []Node{}, Edges: []Edge{}, Cycles: [][]string{}},
+		nodes: make(map[string]bool),
+		edges: make(map[Edge]bool),
+	}
+}
+
+func nodeID(kind, tag string) string {
+	return kind + ":" + tag
+}
+
+func (b *builder) addNode(kind, tag, path string) {
+	id := nodeID(kind, tag)
+	if tag == "" || b.nodes[id] {
+		return
+	}
+	b.nodes[id] = true
+	b.g.Nodes = append(b.g.Nodes, Node{ID: id, Kind: kind, Tag: tag, Path: path})
+}
+
+// has reports whether a node exists and returns its ID.
+func (b *builder) has(kind, tag string) (string, bool) {
+	id := nodeID(kind, tag)
+	return id, b.nodes[id]
+}
+
+func (b *builder) addEdge(from, to, kind string) {
+	e := Edge{From: from, To: to, Kind: kind}
+	if b.edges[e] {
+		return
+	}
+	b.edges[e] = true
+	b.g.Edges = append(b.g.Edges, e)
+}
+
+// finish detects cycles and returns the graph.
+func (b *builder) finish() *Graph {
+	b.g.Cycles = findCycles(b.g)
+	return b.g
+}
+
+// findCycles returns one cycle per back edge found by a depth-first search
+// visiting nodes in config order, so results are deterministic.
+func findCycles(g *Graph) [][]string {
+	adjacent := make(map[string][]string)
+	for _, e := range g.Edges {
+		adjacent[e.From] = append(adjacent[e.From], e.To)
+	}
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+	cycles := [][]string{}
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = onStack
+		stack = append(stack, id)
+		for _, next := range adjacent[id] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case onStack:
+				start := len(stack) - 1
+				for stack[start] != next {
+					start--
+				}
+				cycle := append([]string{}, stack[start:]...)
+				cycles = append(cycles, append(cycle, next))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+	for _, n := range g.Nodes {
+		if state[n.ID] == unvisited {
+			visit(n.ID)
+		}
+	}
+	return cycles
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestBuildXray_ChainedProxy(t *testing.T) {
+	config := &models.XrayConfig{
+		Inbounds: []models.InboundObject{{Tag: "socks-in"}, {Tag: "api-in"}},
+		Outbounds: []models.OutboundObject{
+			{Tag: strPtr("exit"), ProxySettings: &models.ProxySettings{Tag: strPtr("relay")}},
+			{Tag: strPtr("relay"), StreamSettings: &models.StreamSettingsObject{
+				SocketSettings: &models.SocketOptions{DialerProxy: strPtr("entry")},
+			}},
+			{Tag: strPtr("entry")},
+			{Tag: strPtr("api")},
+		},
+		Routing: &models.RoutingObject{
+			Rules: []models.RoutingRule{{InboundTag: []string{"api-in"}, OutboundTag: strPtr("api")}},
+		},
+	}
+
+	g := BuildXray(config)
+	assert.Len(t, g.Nodes, 6)
+	assert.Equal(t, Node{ID: "outbound:relay", Kind: KindOutbound, Tag: "relay", Path: "outbounds[1]"}, g.Nodes[3])
+	assert.Equal(t, []Edge{
+		{From: "outbound:exit", To: "outbound:relay", Kind: EdgeProxiedThrough},
+		{From: "outbound:relay", To: "outbound:entry", Kind: EdgeDetoursVia},
+		{From: "inbound:api-in", To: "outbound:api", Kind: EdgeRoutesTo},
+		{From: "inbound:socks-in", To: "outbound:exit", Kind: EdgeRoutesTo},
+		{From: "inbound:api-in", To: "outbound:exit", Kind: EdgeRoutesTo},
+	}, g.Edges)
+	assert.Empty(t, g.Cycles)
+}
+
+func TestBuildXray_CycleThroughBalancer(t *testing.T) {
+	config := &models.XrayConfig{
+		Outbounds: []models.OutboundObject{
+			{Tag: strPtr("hop-a"), ProxySettings: &models.ProxySettings{Tag: strPtr("hop-b")}},
+			{Tag: strPtr("hop-b"), ProxySettings: &models.ProxySettings{Tag: strPtr("hop-a")}},
+			{Tag: strPtr("self"), ProxySettings: &models.ProxySettings{Tag: strPtr("self")}},
+		},
+		Routing: &models.RoutingObject{
+			Balancers: []models.Balancer{{Tag: strPtr("pool"), Selector: []string{"hop-"}}},
+		},
+	}
+
+	g := BuildXray(config)
+	assert.Equal(t, [][]string{
+		{"outbound:hop-a", "outbound:hop-b", "outbound:hop-a"},
+		{"outbound:self", "outbound:self"},
+	}, g.Cycles)
+	assert.Contains(t, g.Edges, Edge{From: "balancer:pool", To: "outbound:hop-b", Kind: EdgeRoutesTo})
+}
+
+func TestBuildSingBox_DetourCycle(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Inbounds: []*models.SingBoxInbound{{Type: "mixed", Tag: "mixed-in"}},
+		Outbounds: []*models.SingBoxOutbound{
+			{Type: "selector", Tag: "select", Settings: map[string]interface{}{"outbounds": []interface{}{"proxy", "direct"}}},
+			{Type: "vless", Tag: "proxy", Settings: map[string]interface{}{"detour": "wg"}},
+			{Type: "direct", Tag: "direct"},
+		},
+		Endpoints: []map[string]interface{}{{"type": "wireguard", "tag": "wg", "detour": "select"}},
+		DNS: &models.SingBoxDNSConfig{Servers: []*models.SingBoxDNSServer{{
+			Tag: strPtr("remote"), SingBoxDialFields: models.SingBoxDialFields{Detour: strPtr("proxy")},
+		}}},
+		Route: &models.SingBoxRouteConfig{
+			Rules: []*models.SingBoxRouteRule{{Inbound: "mixed-in", Outbound: strPtr("direct")}},
+			Final: strPtr("select"),
+		},
+	}
+
+	g := BuildSingBox(config)
+	require.Len(t, g.Nodes, 6)
+	assert.Contains(t, g.Edges, Edge{From: "dns_server:remote", To: "outbound:proxy", Kind: EdgeDetoursVia})
+	assert.Contains(t, g.Edges, Edge{From: "inbound:mixed-in", To: "outbound:direct", Kind: EdgeRoutesTo})
+	assert.Contains(t, g.Edges, Edge{From: "inbound:mixed-in", To: "outbound:select", Kind: EdgeRoutesTo})
+	assert.Equal(t, [][]string{
+		{"outbound:select", "outbound:proxy", "endpoint:wg", "outbound:select"},
+	}, g.Cycles)
+}
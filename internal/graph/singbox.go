@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// singBoxGroupTypes are the outbound types that pick among other outbounds
+// listed in their "outbounds" setting.
+var singBoxGroupTypes = map[string]bool{"selector": true, "urltest": true}
+
+// BuildSingBox returns the dependency graph of a sing-box config. Traffic
+// no rule matches goes to route.final, or the first outbound without it.
+func BuildSingBox(config *models.SingBoxConfig) *Graph {
+	b := newBuilder()
+	var inbounds []string
+	for i, in := range config.Inbounds {
+		if in == nil {
+			continue
+		}
+		b.addNode(KindInbound, in.Tag, fmt.Sprintf("inbounds[%d]", i))
+		if id, ok := b.has(KindInbound, in.Tag); ok {
+			inbounds = append(inbounds, id)
+		}
+	}
+	for i, out := range config.Outbounds {
+		if out != nil {
+			b.addNode(KindOutbound, out.Tag, fmt.Sprintf("outbounds[%d]", i))
+		}
+	}
+	for i, endpoint := range config.Endpoints {
+		if tag, _ := endpoint["tag"].(string); tag != "" {
+			b.addNode(KindEndpoint, tag, fmt.Sprintf("endpoints[%d]", i))
+		}
+	}
+	if config.DNS != nil {
+		for i, server := range config.DNS.Servers {
+			if server != nil && server.Tag != nil {
+				b.addNode(KindDNSServer, *server.Tag, fmt.Sprintf("dns.servers[%d]", i))
+			}
+		}
+	}
+
+	for _, out := range config.Outbounds {
+		if out == nil || out.Tag == "" {
+			continue
+		}
+		from := nodeID(KindOutbound, out.Tag)
+		if detour, _ := out.Settings["detour"].(string); detour != "" {
+			b.addSingBoxEdge(from, detour, EdgeDetoursVia)
+		}
+		if singBoxGroupTypes[out.Type] {
+			members, _ := out.Settings["outbounds"].([]interface{})
+			for _, m := range members {
+				if tag, _ := m.(string); tag != "" {
+					b.addSingBoxEdge(from, tag, EdgeRoutesTo)
+				}
+			}
+		}
+	}
+	for _, endpoint := range config.Endpoints {
+		tag, _ := endpoint["tag"].(string)
+		if detour, _ := endpoint["detour"].(string); tag != "" && detour != "" {
+			b.addSingBoxEdge(nodeID(KindEndpoint, tag), detour, EdgeDetoursVia)
+		}
+	}
+	if config.DNS != nil {
+		for _, server := range config.DNS.Servers {
+			if server != nil && server.Tag != nil && server.Detour != nil {
+				b.addSingBoxEdge(nodeID(KindDNSServer, *server.Tag), *server.Detour, EdgeDetoursVia)
+			}
+		}
+	}
+
+	if config.Route != nil {
+		for _, rule := range config.Route.Rules {
+			if rule == nil {
+				continue
+			}
+			target := rule.Outbound
+			if target == nil {
+				target = rule.Balancer
+			}
+			if target == nil {
+				continue
+			}
+			for _, from := range singBoxRuleSources(b, rule.Inbound, inbounds) {
+				b.addSingBoxEdge(from, *target, EdgeRoutesTo)
+			}
+		}
+	}
+	final := ""
+	if config.Route != nil && config.Route.Final != nil {
+		final = *config.Route.Final
+	} else if len(config.Outbounds) > 0 && config.Outbounds[0] != nil {
+		final = config.Outbounds[0].Tag
+	}
+	if final != "" {
+		for _, from := range inbounds {
+			b.addSingBoxEdge(from, final, EdgeRoutesTo)
+		}
+	}
+	return b.finish()
+}
+
+// addSingBoxEdge adds an edge to tag, which may name an outbound or an
+// endpoint since sing-box routes to both.
+func (b *builder) addSingBoxEdge(from, tag, kind string) {
+	for _, k := range []string{KindOutbound, KindEndpoint} {
+		if to, ok := b.has(k, tag); ok {
+			b.addEdge(from, to, kind)
+			return
+		}
+	}
+}
+
+// singBoxRuleSources returns the inbounds named by a rule's inbound field,
+// a string or a list of strings, or every inbound when it is unset.
+func singBoxRuleSources(b *builder, inbound interface{}, inbounds []string) []string {
+	var tags []string
+	switch v := inbound.(type) {
+	case string:
+		tags = []string{v}
+	case []string:
+		tags = v
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	default:
+		return inbounds
+	}
+	var sources []string
+	for _, tag := range tags {
+		if id, ok := b.has(KindInbound, tag); ok {
+			sources = append(sources, id)
+		}
+	}
+	return sources
+}
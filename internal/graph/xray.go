@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// BuildXray returns the dependency graph of an Xray config. Traffic no rule
+// matches goes to the first outbound, so every inbound routes to it.
+func BuildXray(config *models.XrayConfig) *Graph {
+	b := newBuilder()
+	var inbounds []string // Node IDs traffic can enter from
+	for i, in := range config.Inbounds {
+		b.addNode(KindInbound, in.Tag, fmt.Sprintf("inbounds[%d]", i))
+		if id, ok := b.has(KindInbound, in.Tag); ok {
+			inbounds = append(inbounds, id)
+		}
+	}
+	var outboundTags []string
+	for i, out := range config.Outbounds {
+		if out.Tag != nil {
+			b.addNode(KindOutbound, *out.Tag, fmt.Sprintf("outbounds[%d]", i))
+			outboundTags = append(outboundTags, *out.Tag)
+		}
+	}
+	if config.DNS != nil && config.DNS.Tag != nil {
+		b.addNode(KindDNSServer, *config.DNS.Tag, "dns")
+	}
+	if config.Reverse != nil {
+		for i, bridge := range config.Reverse.Bridges {
+			if bridge.Tag != nil {
+				b.addNode(KindBridge, *bridge.Tag, fmt.Sprintf("reverse.bridges[%d]", i))
+			}
+		}
+		for i, portal := range config.Reverse.Portals {
+			if portal.Tag != nil {
+				b.addNode(KindPortal, *portal.Tag, fmt.Sprintf("reverse.portals[%d]", i))
+			}
+		}
+	}
+	if config.Routing != nil {
+		for i, balancer := range config.Routing.Balancers {
+			if balancer.Tag != nil {
+				b.addNode(KindBalancer, *balancer.Tag, fmt.Sprintf("routing.balancers[%d]", i))
+			}
+		}
+	}
+
+	// Outbound chaining
+	for _, out := range config.Outbounds {
+		if out.Tag == nil {
+			continue
+		}
+		from := nodeID(KindOutbound, *out.Tag)
+		if out.ProxySettings != nil && out.ProxySettings.Tag != nil {
+			if to, ok := b.has(KindOutbound, *out.ProxySettings.Tag); ok {
+				b.addEdge(from, to, EdgeProxiedThrough)
+			}
+		}
+		if out.StreamSettings != nil && out.StreamSettings.SocketSettings != nil && out.StreamSettings.SocketSettings.DialerProxy != nil {
+			if to, ok := b.has(KindOutbound, *out.StreamSettings.SocketSettings.DialerProxy); ok {
+				b.addEdge(from, to, EdgeDetoursVia)
+			}
+		}
+	}
+
+	if config.Routing != nil {
+		// Balancer selectors match outbound tags by prefix
+		for _, balancer := range config.Routing.Balancers {
+			if balancer.Tag == nil {
+				continue
+			}
+			from := nodeID(KindBalancer, *balancer.Tag)
+			for _, tag := range outboundTags {
+				for _, prefix := range balancer.Selector {
+					if strings.HasPrefix(tag, prefix) {
+						b.addEdge(from, nodeID(KindOutbound, tag), EdgeRoutesTo)
+						break
+					}
+				}
+			}
+		}
+		for _, rule := range config.Routing.Rules {
+			if rule.Enabled != nil && !*rule.Enabled {
+				continue
+			}
+			var target string
+			var ok bool
+			switch {
+			case rule.BalancerTag != nil:
+				target, ok = b.has(KindBalancer, *rule.BalancerTag)
+			case rule.OutboundTag != nil:
+				if target, ok = b.has(KindOutbound, *rule.OutboundTag); !ok {
+					target, ok = b.has(KindPortal, *rule.OutboundTag)
+				}
+			}
+			if !ok {
+				continue
+			}
+			for _, from := range xrayRuleSources(b, rule, inbounds) {
+				b.addEdge(from, target, EdgeRoutesTo)
+			}
+		}
+	}
+
+	if len(outboundTags) > 0 && config.Outbounds[0].Tag != nil {
+		for _, from := range inbounds {
+			b.addEdge(from, nodeID(KindOutbound, *config.Outbounds[0].Tag), EdgeRoutesTo)
+		}
+	}
+	return b.finish()
+}
+
+// xrayRuleSources returns the nodes whose traffic a rule applies to: those
+// named in inboundTag, which may be inbounds, bridges or the DNS module, or
+// every inbound when the rule does not filter by inbound.
+func xrayRuleSources(b *builder, rule models.RoutingRule, inbounds []string) []string {
+	if len(rule.InboundTag) == 0 {
+		return inbounds
+	}
+	var sources []string
+	for _, tag := range rule.InboundTag {
+		for _, kind := range []string{KindInbound, KindBridge, KindDNSServer} {
+			if id, ok := b.has(kind, tag); ok {
+				sources = append(sources, id)
+				break
+			}
+		}
+	}
+	return sources
+}
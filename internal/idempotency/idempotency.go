@@ -0,0 +1,153 @@
+// Package idempotency replays the response of a create request when a client
+// retries it with the same X-Idempotency-Key, so a retry after a dropped
+// response does not create a duplicate.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// Header is the request header carrying the client's idempotency key.
+const Header = "X-Idempotency-Key"
+
+// TTL is how long a cached response is replayed.
+const TTL = 24 * time.Hour
+
+// PendingTTL is how long a key stays reserved by a request that never
+// completes, e.g. because the server stopped while creating the config.
+const PendingTTL = time.Minute
+
+// ErrInProgress is returned for a key whose first request is still running.
+// API handlers map it to 409 Conflict; the client retries later and gets the
+// replayed response.
+var ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+
+// Operations that support idempotency keys.
+const (
+	OperationCreateXrayConfig    = "create_xray_config"
+	OperationCreateSingBoxConfig = "create_singbox_config"
+)
+
+// now is replaced in tests.
+var now = func() time.Time { return time.Now().UTC() }
+
+// HashKey returns the stored form of a client key for operation. Keys are
+// never stored verbatim.
+func HashKey(operation, key string) string {
+	sum := sha256.Sum256([]byte(operation + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Do runs create and caches its JSON-encoded result under key, unless an
+// unexpired response for the same operation and key is already cached, in
+// which case that response is returned with replayed set. The key is
+// reserved before create runs, so a concurrent request with the same key
+// gets ErrInProgress instead of creating a duplicate. An empty key runs
+// create without caching. Failed creates are not cached and release the key.
+// If the result cannot be cached it is returned together with the error.
+func Do(ctx context.Context, st store.Store, operation, key string, create func(ctx context.Context) (interface{}, error)) (response json.RawMessage, replayed bool, err error) {
+	if key == "" {
+		result, err := create(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		response, err := marshalResponse(operation, result)
+		return response, false, err
+	}
+
+	keyHash := HashKey(operation, key)
+	reserved := now()
+	ok, err := st.ReserveIdempotencyKey(ctx, &models.IdempotencyRecord{
+		KeyHash:   keyHash,
+		Operation: operation,
+		CreatedAt: reserved,
+		ExpiresAt: reserved.Add(PendingTTL),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		record, err := st.GetIdempotencyRecord(ctx, keyHash)
+		switch {
+		case err == nil && len(record.Response) > 0:
+			return record.Response, true, nil
+		case err == nil || errors.Is(err, sql.ErrNoRows):
+			return nil, false, ErrInProgress
+		default:
+			return nil, false, err
+		}
+	}
+
+	result, err := create(ctx)
+	if err == nil {
+		response, err = marshalResponse(operation, result)
+	}
+	if err != nil {
+		if releaseErr := st.ReleaseIdempotencyKey(ctx, keyHash); releaseErr != nil {
+			return nil, false, errors.Join(err, releaseErr)
+		}
+		return nil, false, err
+	}
+
+	created := now()
+	record := &models.IdempotencyRecord{
+		KeyHash:   keyHash,
+		Operation: operation,
+		Response:  response,
+		CreatedAt: created,
+		ExpiresAt: created.Add(TTL),
+	}
+	if err := st.SaveIdempotencyRecord(ctx, record); err != nil {
+		return response, false, err
+	}
+	return response, false, nil
+}
+
+func marshalResponse(operation string, result interface{}) (json.RawMessage, error) {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s response: %w", operation, err)
+	}
+	return response, nil
+}
+
+// CreateXrayConfig creates config through Do. On a replay config is left
+// untouched and the cached config is returned instead.
+func CreateXrayConfig(ctx context.Context, st store.Store, key string, config *models.XrayConfig) (*models.XrayConfig, bool, error) {
+	response, replayed, err := Do(ctx, st, OperationCreateXrayConfig, key, func(ctx context.Context) (interface{}, error) {
+		return config, st.CreateXrayConfig(ctx, config)
+	})
+	if err != nil || !replayed {
+		return config, replayed, err
+	}
+	cached := &models.XrayConfig{}
+	if err := json.Unmarshal(response, cached); err != nil {
+		return nil, true, fmt.Errorf("unmarshal cached xray config: %w", err)
+	}
+	return cached, true, nil
+}
+
+// CreateSingBoxConfig is the SingBox counterpart of CreateXrayConfig.
+func CreateSingBoxConfig(ctx context.Context, st store.Store, key string, config *models.SingBoxConfig) (*models.SingBoxConfig, bool, error) {
+	response, replayed, err := Do(ctx, st, OperationCreateSingBoxConfig, key, func(ctx context.Context) (interface{}, error) {
+		return config, st.CreateSingBoxConfig(ctx, config)
+	})
+	if err != nil || !replayed {
+		return config, replayed, err
+	}
+	cached := &models.SingBoxConfig{}
+	if err := json.Unmarshal(response, cached); err != nil {
+		return nil, true, fmt.Errorf("unmarshal cached singbox config: %w", err)
+	}
+	return cached, true, nil
+}
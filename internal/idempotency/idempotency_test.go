@@ -0,0 +1,136 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func TestCreateSingBoxConfig_DoubleSubmit(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+
+	first, replayed, err := CreateSingBoxConfig(ctx, st, "retry-1", &models.SingBoxConfig{Name: "client"})
+	require.NoError(t, err)
+	assert.False(t, replayed)
+
+	second, replayed, err := CreateSingBoxConfig(ctx, st, "retry-1", &models.SingBoxConfig{Name: "client"})
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, first.ID, second.ID)
+
+	configs, err := st.ListSingBoxConfigs(ctx, 10, 0, store.Sort{})
+	require.NoError(t, err)
+	assert.Len(t, configs, 1, "retry must not create a duplicate")
+
+	// A different key creates a new config
+	_, replayed, err = CreateSingBoxConfig(ctx, st, "retry-2", &models.SingBoxConfig{Name: "client"})
+	require.NoError(t, err)
+	assert.False(t, replayed)
+}
+
+func TestCreateXrayConfig_KeyScopedToOperation(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+
+	_, _, err := CreateSingBoxConfig(ctx, st, "shared", &models.SingBoxConfig{Name: "sb"})
+	require.NoError(t, err)
+	xray, replayed, err := CreateXrayConfig(ctx, st, "shared", &models.XrayConfig{Name: "xray"})
+	require.NoError(t, err)
+	assert.False(t, replayed)
+
+	again, replayed, err := CreateXrayConfig(ctx, st, "shared", &models.XrayConfig{Name: "xray"})
+	require.NoError(t, err, "a replay must not hit the name conflict")
+	assert.True(t, replayed)
+	assert.Equal(t, xray.ID, again.ID)
+}
+
+func TestDo_ExpiryAndFailures(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	calls := 0
+	create := func(ctx context.Context) (interface{}, error) {
+		calls++
+		return map[string]int{"call": calls}, nil
+	}
+
+	// No key: never cached
+	_, _, err := Do(ctx, st, "op", "", create)
+	require.NoError(t, err)
+	_, replayed, err := Do(ctx, st, "op", "", create)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 2, calls)
+
+	// Failures are not cached
+	_, _, err = Do(ctx, st, "op", "k", func(ctx context.Context) (interface{}, error) {
+		return nil, assert.AnError
+	})
+	require.ErrorIs(t, err, assert.AnError)
+	resp, replayed, err := Do(ctx, st, "op", "k", create)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.JSONEq(t, `{"call": 3}`, string(resp))
+
+	// Expired keys run create again
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Now().UTC().Add(TTL + time.Minute) }
+	resp, replayed, err = Do(ctx, st, "op", "k", create)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.JSONEq(t, `{"call": 4}`, string(resp))
+}
+
+func TestDo_ConcurrentDuplicates(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	create := func(ctx context.Context) (interface{}, error) {
+		calls.Add(1)
+		<-release // Hold the key while the duplicates arrive
+		return map[string]string{"id": "created"}, nil
+	}
+
+	const n = 8
+	errs := make([]error, n)
+	var started, done sync.WaitGroup
+	started.Add(n)
+	done.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			_, _, errs[i] = Do(ctx, st, "op", "same-key", create)
+		}(i)
+	}
+	started.Wait()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, 5*time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond) // Let the duplicates reach the store
+	close(release)
+	done.Wait()
+
+	assert.EqualValues(t, 1, calls.Load(), "only one request may run create")
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInProgress)
+	}
+	assert.GreaterOrEqual(t, succeeded, 1)
+
+	resp, replayed, err := Do(ctx, st, "op", "same-key", create)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.JSONEq(t, `{"id":"created"}`, string(resp))
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// DefaultJobRunRetention is how long PruneJobRuns keeps run history.
+const DefaultJobRunRetention = 30 * 24 * time.Hour
+
+// PruneIdempotencyKeys deletes expired idempotency records every hour.
+func PruneIdempotencyKeys(clock Clock) Job {
+	return Job{
+		Name:     "prune-idempotency-keys",
+		Interval: time.Hour,
+		Run: func(ctx context.Context, st store.Store) error {
+			_, err := st.DeleteExpiredIdempotencyRecords(ctx, clock.Now())
+			return err
+		},
+	}
+}
+
+// PruneJobRuns deletes job run history older than retention once a day.
+func PruneJobRuns(clock Clock, retention time.Duration) Job {
+	return Job{
+		Name:     "prune-job-runs",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context, st store.Store) error {
+			_, err := st.DeleteJobRunsBefore(ctx, clock.Now().Add(-retention))
+			return err
+		},
+	}
+}
+
+// RotateDataKeys re-encrypts sensitive config values sealed with a retired
+// master key once a day, so previous keys can eventually be dropped.
+func RotateDataKeys() Job {
+	return Job{
+		Name:     "rotate-data-keys",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context, st store.Store) error {
+			_, err := st.RotateDataKeys(ctx)
+			return err
+		},
+	}
+}
+
+// RegisterBuiltins registers the maintenance jobs every deployment runs.
+func (r *Runner) RegisterBuiltins() error {
+	for _, job := range []Job{
+		PruneIdempotencyKeys(r.clock),
+		PruneJobRuns(r.clock, DefaultJobRunRetention),
+		RotateDataKeys(),
+	} {
+		if err := r.Register(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a Schedule for the simple cron specs maintenance jobs need:
+// "M H * * *" runs daily at H:M and "M * * * *" hourly at minute M, both in
+// UTC. ParseCron rejects anything else rather than approximating it.
+type Cron struct {
+	spec   string
+	minute int
+	hour   int // -1 for hourly specs
+}
+
+// ParseCron parses a daily or hourly cron spec.
+func ParseCron(spec string) (*Cron, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 || fields[2] != "*" || fields[3] != "*" || fields[4] != "*" {
+		return nil, fmt.Errorf(`unsupported cron spec %q: only "M H * * *" and "M * * * *" are supported`, spec)
+	}
+	minute, err := strconv.Atoi(fields[0])
+	if err != nil || minute < 0 || minute > 59 {
+		return nil, fmt.Errorf("cron spec %q: invalid minute %q", spec, fields[0])
+	}
+	hour := -1
+	if fields[1] != "*" {
+		hour, err = strconv.Atoi(fields[1])
+		if err != nil || hour < 0 || hour > 23 {
+			return nil, fmt.Errorf("cron spec %q: invalid hour %q", spec, fields[1])
+		}
+	}
+	return &Cron{spec: strings.Join(fields, " "), minute: minute, hour: hour}, nil
+}
+
+// Next returns the first run time strictly after after.
+func (c *Cron) Next(after time.Time) time.Time {
+	after = after.UTC()
+	if c.hour < 0 {
+		next := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), c.minute, 0, 0, time.UTC)
+		if !next.After(after) {
+			next = next.Add(time.Hour)
+		}
+		return next
+	}
+	next := time.Date(after.Year(), after.Month(), after.Day(), c.hour, c.minute, 0, 0, time.UTC)
+	if !next.After(after) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// String returns the spec, for Status.
+func (c *Cron) String() string {
+	return c.spec
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func TestParseCron(t *testing.T) {
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC) }
+
+	daily, err := ParseCron("0 2 * * *")
+	require.NoError(t, err)
+	assert.Equal(t, at(1, 2, 0), daily.Next(at(1, 1, 59)))
+	assert.Equal(t, at(2, 2, 0), daily.Next(at(1, 2, 0)), "a run time is never returned twice")
+	assert.Equal(t, "0 2 * * *", daily.String())
+
+	hourly, err := ParseCron("15 * * * *")
+	require.NoError(t, err)
+	assert.Equal(t, at(1, 3, 15), hourly.Next(at(1, 2, 30)))
+
+	for _, spec := range []string{"", "*/5 * * * *", "0 2 * * 1", "60 2 * * *", "0 24 * * *", "@daily"} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRunner_CronSchedule(t *testing.T) {
+	st := sqlitetest.New(t)
+	clock := newFakeClock()
+	runner := NewRunner(st, clock)
+
+	daily, err := ParseCron("30 0 * * *")
+	require.NoError(t, err)
+	ran := make(chan time.Time, 1)
+	require.NoError(t, runner.Register(Job{Name: "nightly", Schedule: daily, Run: func(ctx context.Context, _ store.Store) error {
+		ran <- clock.Now()
+		return nil
+	}}))
+	assert.Equal(t, "30 0 * * *", runner.Statuses()[0].Schedule)
+	runner.Start(context.Background())
+	defer runner.Stop()
+
+	clock.waitForTimers(t, 1)
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC), <-ran)
+}
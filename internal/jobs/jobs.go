@@ -0,0 +1,222 @@
+// Package jobs runs background maintenance jobs on a schedule or on demand
+// and records every run in the store.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+var (
+	// ErrUnknownJob is returned by RunNow for a name that was never registered.
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrAlreadyRunning is returned by RunNow while the job is running.
+	ErrAlreadyRunning = errors.New("job is already running")
+)
+
+// Run triggers.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Clock is the time source of a Runner. Tests substitute a fake.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now().UTC() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Schedule picks the run times of a job that runs at fixed times of day
+// rather than at a fixed interval.
+type Schedule interface {
+	Next(after time.Time) time.Time
+	String() string
+}
+
+// Job is a unit of background work.
+type Job struct {
+	Name     string
+	Interval time.Duration // 0 runs the job on demand only
+	Schedule Schedule      // Overrides Interval when set
+	Run      func(ctx context.Context, st store.Store) error
+}
+
+// Status describes a registered job.
+type Status struct {
+	Name     string         `json:"name" example:"prune-idempotency-keys"`
+	Interval string         `json:"interval,omitempty" example:"1h0m0s"`
+	Schedule string         `json:"schedule,omitempty" example:"0 2 * * *"`
+	Running  bool           `json:"running"`
+	LastRun  *models.JobRun `json:"last_run,omitempty"`
+}
+
+type entry struct {
+	job     Job
+	running atomic.Bool
+	lastRun *models.JobRun // guarded by Runner.mu
+}
+
+// Runner owns the registered jobs. Register jobs before calling Start; a job
+// never overlaps with itself, whether triggered by its schedule or RunNow.
+type Runner struct {
+	st    store.Store
+	clock Clock
+
+	mu      sync.Mutex
+	jobs    map[string]*entry
+	order   []string
+	started bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRunner returns a Runner recording runs in st. A nil clock uses the
+// system clock.
+func NewRunner(st store.Store, clock Clock) *Runner {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &Runner{st: st, clock: clock, jobs: make(map[string]*entry)}
+}
+
+// Register adds job to the runner.
+func (r *Runner) Register(job Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case job.Name == "" || job.Run == nil:
+		return fmt.Errorf("job needs a name and a run function")
+	case r.jobs[job.Name] != nil:
+		return fmt.Errorf("job %q is already registered", job.Name)
+	case r.started:
+		return fmt.Errorf("cannot register job %q after Start", job.Name)
+	}
+	r.jobs[job.Name] = &entry{job: job}
+	r.order = append(r.order, job.Name)
+	return nil
+}
+
+// Start schedules every job with an interval or schedule. Jobs stop when ctx is done or
+// Stop is called.
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return
+	}
+	r.started = true
+	ctx, r.cancel = context.WithCancel(ctx)
+	for _, name := range r.order {
+		e := r.jobs[name]
+		if e.job.Interval <= 0 && e.job.Schedule == nil {
+			continue
+		}
+		r.wg.Add(1)
+		go r.schedule(ctx, e)
+	}
+}
+
+// Stop cancels the scheduled jobs and waits for running ones to return.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *Runner) schedule(ctx context.Context, e *entry) {
+	defer r.wg.Done()
+	for {
+		wait := e.job.Interval
+		if e.job.Schedule != nil {
+			now := r.clock.Now()
+			wait = e.job.Schedule.Next(now).Sub(now)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(wait):
+		}
+		// A manual run in progress makes this tick a no-op
+		if _, err := r.execute(ctx, e, TriggerSchedule); err != nil && !errors.Is(err, ErrAlreadyRunning) {
+			log.Printf("job %s: %v", e.job.Name, err)
+		}
+	}
+}
+
+// RunNow runs the named job in the caller's goroutine and returns the
+// recorded run. A failing job is reported in the run's outcome, not as an
+// error.
+func (r *Runner) RunNow(ctx context.Context, name string) (*models.JobRun, error) {
+	r.mu.Lock()
+	e := r.jobs[name]
+	r.mu.Unlock()
+	if e == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	return r.execute(ctx, e, TriggerManual)
+}
+
+func (r *Runner) execute(ctx context.Context, e *entry, trigger string) (*models.JobRun, error) {
+	if !e.running.CompareAndSwap(false, true) {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyRunning, e.job.Name)
+	}
+	defer e.running.Store(false)
+
+	started := r.clock.Now()
+	err := e.job.Run(ctx, r.st)
+	run := &models.JobRun{
+		Job:        e.job.Name,
+		Trigger:    trigger,
+		StartedAt:  started,
+		DurationMS: r.clock.Now().Sub(started).Milliseconds(),
+		Outcome:    models.JobOutcomeSuccess,
+	}
+	if err != nil {
+		run.Outcome = models.JobOutcomeFailure
+		run.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	e.lastRun = run
+	r.mu.Unlock()
+	// The run is recorded even when it was stopped by shutdown
+	if err := r.st.CreateJobRun(context.WithoutCancel(ctx), run); err != nil {
+		return run, fmt.Errorf("record %s run: %w", e.job.Name, err)
+	}
+	return run, nil
+}
+
+// Statuses returns the registered jobs in registration order.
+func (r *Runner) Statuses() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]Status, 0, len(r.order))
+	for _, name := range r.order {
+		e := r.jobs[name]
+		s := Status{Name: name, Running: e.running.Load(), LastRun: e.lastRun}
+		if e.job.Schedule != nil {
+			s.Schedule = e.job.Schedule.String()
+		} else if e.job.Interval > 0 {
+			s.Interval = e.job.Interval.String()
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
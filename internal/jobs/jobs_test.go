@@ -0,0 +1,196 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires every timer that is due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.at.After(c.now) {
+			w.ch <- c.now
+			continue
+		}
+		pending = append(pending, w)
+	}
+	c.waiters = pending
+}
+
+// waitForTimers blocks until n timers are pending.
+func (c *fakeClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.waiters) >= n
+	}, time.Second, time.Millisecond)
+}
+
+func TestRunner_ScheduledRun(t *testing.T) {
+	st := sqlitetest.New(t)
+	clock := newFakeClock()
+	runner := NewRunner(st, clock)
+
+	ran := make(chan struct{}, 1)
+	require.NoError(t, runner.Register(Job{Name: "tick", Interval: time.Minute, Run: func(ctx context.Context, st store.Store) error {
+		ran <- struct{}{}
+		return nil
+	}}))
+	runner.Start(context.Background())
+	defer runner.Stop()
+
+	clock.waitForTimers(t, 1)
+	clock.Advance(30 * time.Second)
+	select {
+	case <-ran:
+		t.Fatal("job ran before its interval elapsed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(30 * time.Second)
+	<-ran
+
+	// The run is recorded once the next tick is scheduled
+	clock.waitForTimers(t, 1)
+	runs, err := st.ListJobRuns(context.Background(), "tick", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, TriggerSchedule, runs[0].Trigger)
+	assert.Equal(t, models.JobOutcomeSuccess, runs[0].Outcome)
+	assert.True(t, clock.Now().Equal(runs[0].StartedAt))
+}
+
+func TestRunner_NoOverlapAndCleanStop(t *testing.T) {
+	st := sqlitetest.New(t)
+	clock := newFakeClock()
+	runner := NewRunner(st, clock)
+
+	started := make(chan struct{})
+	require.NoError(t, runner.Register(Job{Name: "slow", Interval: time.Minute, Run: func(ctx context.Context, st store.Store) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+	runner.Start(context.Background())
+
+	clock.waitForTimers(t, 1)
+	clock.Advance(time.Minute)
+	<-started
+
+	_, err := runner.RunNow(context.Background(), "slow")
+	assert.ErrorIs(t, err, ErrAlreadyRunning)
+	assert.True(t, runner.Statuses()[0].Running)
+
+	runner.Stop() // returns only after the running job has returned
+
+	status := runner.Statuses()[0]
+	assert.False(t, status.Running)
+	require.NotNil(t, status.LastRun)
+	assert.Equal(t, models.JobOutcomeFailure, status.LastRun.Outcome)
+	assert.Equal(t, context.Canceled.Error(), status.LastRun.Error)
+
+	runs, err := st.ListJobRuns(context.Background(), "slow", 10)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1, "a run stopped by shutdown is still recorded")
+}
+
+func TestRunner_RunNowAndRegistration(t *testing.T) {
+	st := sqlitetest.New(t)
+	runner := NewRunner(st, newFakeClock())
+
+	require.NoError(t, runner.Register(Job{Name: "once", Run: func(ctx context.Context, st store.Store) error {
+		return assert.AnError
+	}}))
+	assert.Error(t, runner.Register(Job{Name: "once", Run: func(ctx context.Context, st store.Store) error { return nil }}))
+	assert.Error(t, runner.Register(Job{Name: "no-func"}))
+
+	run, err := runner.RunNow(context.Background(), "once")
+	require.NoError(t, err)
+	assert.Equal(t, TriggerManual, run.Trigger)
+	assert.Equal(t, models.JobOutcomeFailure, run.Outcome)
+	assert.Equal(t, assert.AnError.Error(), run.Error)
+
+	_, err = runner.RunNow(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrUnknownJob)
+
+	runner.Start(context.Background())
+	defer runner.Stop()
+	assert.Error(t, runner.Register(Job{Name: "late", Run: func(ctx context.Context, st store.Store) error { return nil }}))
+}
+
+func TestBuiltins(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	clock := newFakeClock()
+	runner := NewRunner(st, clock)
+	require.NoError(t, runner.RegisterBuiltins())
+
+	require.NoError(t, st.SaveIdempotencyRecord(ctx, &models.IdempotencyRecord{
+		KeyHash: "old", Operation: "op", Response: json.RawMessage(`{}`), ExpiresAt: clock.Now().Add(-time.Minute),
+	}))
+	require.NoError(t, st.SaveIdempotencyRecord(ctx, &models.IdempotencyRecord{
+		KeyHash: "new", Operation: "op", Response: json.RawMessage(`{}`), ExpiresAt: clock.Now().Add(time.Hour),
+	}))
+	require.NoError(t, st.CreateJobRun(ctx, &models.JobRun{
+		Job: "ancient", Trigger: TriggerSchedule, StartedAt: clock.Now().Add(-DefaultJobRunRetention - time.Hour), Outcome: models.JobOutcomeSuccess,
+	}))
+
+	run, err := runner.RunNow(ctx, "prune-idempotency-keys")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobOutcomeSuccess, run.Outcome)
+	_, err = st.GetIdempotencyRecord(ctx, "old")
+	assert.Error(t, err)
+	_, err = st.GetIdempotencyRecord(ctx, "new")
+	assert.NoError(t, err)
+
+	_, err = runner.RunNow(ctx, "prune-job-runs")
+	require.NoError(t, err)
+	runs, err := st.ListJobRuns(ctx, "", 10)
+	require.NoError(t, err)
+	for _, r := range runs {
+		assert.NotEqual(t, "ancient", r.Job)
+	}
+	assert.Len(t, runs, 2, "the two manual runs are kept")
+}
@@ -0,0 +1,76 @@
+// Package linter reports advisory best-practice findings for stored Xray and
+// SingBox configurations. Unlike package validation, findings never block
+// saving a config.
+package linter
+
+import "sort"
+
+// Finding severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is a single lint result. Path is a JSON-path style reference such
+// as "inbounds[0].sniffing".
+type Finding struct {
+	Rule     string `json:"rule" example:"XRAY-TLS-INSECURE"`
+	Severity string `json:"severity" example:"warning"`
+	Path     string `json:"path" example:"outbounds[1].streamSettings.tlsSettings.allowInsecure"`
+	Message  string `json:"message"`
+}
+
+// Report groups findings by whether they should be treated as errors.
+type Report struct {
+	Errors   []Finding `json:"errors"`
+	Warnings []Finding `json:"warnings"`
+}
+
+// rule is one lint check over a config of type T.
+type rule[T any] struct {
+	id       string
+	severity string
+	check    func(config T) []Finding // Findings need only Path and Message
+}
+
+// run evaluates rules against config, skipping the rule IDs in any of the
+// suppress lists.
+func run[T any](rules []rule[T], config T, suppress ...[]string) *Report {
+	skip := make(map[string]bool)
+	for _, ids := range suppress {
+		for _, id := range ids {
+			skip[id] = true
+		}
+	}
+
+	report := &Report{Errors: []Finding{}, Warnings: []Finding{}}
+	for _, r := range rules {
+		if skip[r.id] {
+			continue
+		}
+		for _, f := range r.check(config) {
+			f.Rule = r.id
+			f.Severity = r.severity
+			if f.Severity == SeverityError {
+				report.Errors = append(report.Errors, f)
+			} else {
+				report.Warnings = append(report.Warnings, f)
+			}
+		}
+	}
+	return report
+}
+
+// RuleIDs returns the IDs of every registered rule, sorted.
+func RuleIDs() []string {
+	var ids []string
+	for _, r := range xrayRules {
+		ids = append(ids, r.id)
+	}
+	for _, r := range singBoxRules {
+		ids = append(ids, r.id)
+	}
+	sort.Strings(ids)
+	return ids
+}
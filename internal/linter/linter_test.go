@@ -0,0 +1,165 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+func boolPtr(b bool) *bool    { return &b }
+
+func ruleIDs(findings []Finding) []string {
+	ids := make([]string, 0, len(findings))
+	for _, f := range findings {
+		ids = append(ids, f.Rule)
+	}
+	return ids
+}
+
+func TestLintXray(t *testing.T) {
+	config := &models.XrayConfig{
+		Stats: &models.StatsObject{},
+		Inbounds: []models.InboundObject{{
+			Protocol: "vless",
+			Sniffing: &models.SniffingObject{Enabled: boolPtr(true)},
+			StreamSettings: &models.StreamSettingsObject{TLSSettings: &models.TLSSettings{
+				RealitySettings: &models.RealitySettingsObject{Dest: strPtr("example.com:443")},
+			}},
+		}},
+		Outbounds: []models.OutboundObject{
+			{Protocol: strPtr("freedom"), Settings: map[string]interface{}{}},
+			{
+				Protocol: strPtr("vless"),
+				Mux:      &models.MuxObject{Enabled: boolPtr(true)},
+				Settings: map[string]interface{}{"vnext": []interface{}{
+					map[string]interface{}{"users": []interface{}{map[string]interface{}{"flow": "xtls-rprx-vision"}}},
+				}},
+				StreamSettings: &models.StreamSettingsObject{TLSSettings: &models.TLSSettings{AllowInsecure: boolPtr(true)}},
+			},
+		},
+	}
+
+	report := LintXray(config)
+	assert.Empty(t, report.Errors, "lint findings are advisory")
+	assert.ElementsMatch(t, []string{
+		"XRAY-LOG-MISSING", "XRAY-TLS-INSECURE", "XRAY-REALITY-NO-MIN-CLIENT",
+		"XRAY-MUX-VISION", "XRAY-FREEDOM-NO-DOMAIN-STRATEGY", "XRAY-STATS-NO-POLICY",
+	}, ruleIDs(report.Warnings))
+
+	for _, f := range report.Warnings {
+		if f.Rule == "XRAY-TLS-INSECURE" {
+			assert.Equal(t, "outbounds[1].streamSettings.tlsSettings.allowInsecure", f.Path)
+			assert.Equal(t, SeverityWarning, f.Severity)
+		}
+	}
+
+	report = LintXray(config, "XRAY-LOG-MISSING", "XRAY-STATS-NO-POLICY")
+	assert.NotContains(t, ruleIDs(report.Warnings), "XRAY-LOG-MISSING")
+	assert.NotContains(t, ruleIDs(report.Warnings), "XRAY-STATS-NO-POLICY")
+}
+
+func TestLintXray_SniffingAndStatsPolicy(t *testing.T) {
+	config := &models.XrayConfig{
+		Log:      &models.LogObject{},
+		Stats:    &models.StatsObject{},
+		Policy:   &models.PolicyObject{System: &models.SystemPolicy{StatsInboundUplink: boolPtr(true)}},
+		Inbounds: []models.InboundObject{{Protocol: "socks"}},
+	}
+
+	report := LintXray(config)
+	require.Len(t, report.Warnings, 1)
+	assert.Equal(t, "XRAY-SNIFFING-DISABLED", report.Warnings[0].Rule)
+	assert.Equal(t, "inbounds[0].sniffing", report.Warnings[0].Path)
+}
+
+func TestLintXray_APINotWired(t *testing.T) {
+	config := &models.XrayConfig{
+		Log: &models.LogObject{},
+		API: &models.APIObject{Tag: strPtr("api"), Services: []string{"StatsService"}},
+	}
+
+	report := LintXray(config)
+	require.Len(t, report.Warnings, 2)
+	assert.Equal(t, []string{"XRAY-API-NOT-WIRED", "XRAY-API-NOT-WIRED"}, ruleIDs(report.Warnings))
+	assert.Equal(t, "api.tag", report.Warnings[0].Path)
+	assert.Equal(t, "routing.rules", report.Warnings[1].Path)
+
+	config.Inbounds = []models.InboundObject{{Tag: "api", Protocol: "dokodemo-door", Sniffing: &models.SniffingObject{Enabled: boolPtr(true)}}}
+	config.Routing = &models.RoutingObject{
+		DomainMatcher: strPtr("mph"),
+		Rules:         []models.RoutingRule{{InboundTag: []string{"api"}, OutboundTag: strPtr("api")}},
+	}
+	assert.Empty(t, LintXray(config).Warnings)
+}
+
+func TestLintXray_DomainMatcherLinear(t *testing.T) {
+	config := &models.XrayConfig{
+		Log: &models.LogObject{},
+		Routing: &models.RoutingObject{Rules: []models.RoutingRule{
+			{DomainMatcher: strPtr("linear"), Domain: []string{"geosite:cn"}, OutboundTag: strPtr("direct")},
+			{DomainMatcher: strPtr("mph"), Domain: []string{"geosite:google"}, OutboundTag: strPtr("proxy")},
+		}},
+	}
+
+	report := LintXray(config)
+	assert.Equal(t, []string{"XRAY-DOMAIN-MATCHER-LINEAR", "XRAY-DOMAIN-MATCHER-LINEAR"}, ruleIDs(report.Warnings))
+	assert.Equal(t, "routing.domainMatcher", report.Warnings[0].Path)
+	assert.Equal(t, "routing.rules[0].domainMatcher", report.Warnings[1].Path)
+	assert.Contains(t, report.Warnings[1].Message, "'linear' is deprecated")
+
+	fixed := FixXrayDomainMatcher(config)
+	assert.Equal(t, []string{"routing.domainMatcher", "routing.rules[0].domainMatcher"}, fixed)
+	assert.Equal(t, "mph", *config.Routing.DomainMatcher)
+	assert.Equal(t, "mph", *config.Routing.Rules[0].DomainMatcher)
+	assert.Empty(t, LintXray(config).Warnings)
+	assert.Nil(t, FixXrayDomainMatcher(config), "fixing twice changes nothing")
+}
+
+func TestLintSingBox(t *testing.T) {
+	config := &models.SingBoxConfig{Outbounds: []*models.SingBoxOutbound{
+		{Type: "direct", Tag: "direct"},
+		{Type: "trojan", Tag: "proxy", TLS: map[string]interface{}{"enabled": true, "insecure": true}},
+	}}
+
+	report := LintSingBox(config)
+	assert.Equal(t, []string{"SINGBOX-LOG-MISSING", "SINGBOX-TLS-INSECURE"}, ruleIDs(report.Warnings))
+	assert.Equal(t, "outbounds[1].tls.insecure", report.Warnings[1].Path)
+}
+
+func TestLintSingBox_DNSLegacy(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Log:          &models.SingBoxLogConfig{},
+		ModelVersion: "1.11.0",
+		DNS:          &models.SingBoxDNSConfig{Servers: []*models.SingBoxDNSServer{{Tag: strPtr("google"), Address: strPtr("8.8.8.8")}}},
+	}
+	assert.Empty(t, LintSingBox(config).Warnings, "legacy fields are fine before 1.12")
+
+	config.ModelVersion = "1.12.0"
+	report := LintSingBox(config)
+	assert.Equal(t, []string{"SINGBOX-DNS-LEGACY-SERVER"}, ruleIDs(report.Warnings))
+	assert.Equal(t, "dns.servers[0].address", report.Warnings[0].Path)
+}
+
+func TestLint_ConfigSuppressions(t *testing.T) {
+	config := &models.SingBoxConfig{Outbounds: []*models.SingBoxOutbound{
+		{Type: "trojan", Tag: "proxy", TLS: map[string]interface{}{"enabled": true, "insecure": true}},
+	}}
+	config.LintSuppress = []string{"SINGBOX-TLS-INSECURE"}
+	assert.Equal(t, []string{"SINGBOX-LOG-MISSING"}, ruleIDs(LintSingBox(config).Warnings))
+	assert.Empty(t, LintSingBox(config, "SINGBOX-LOG-MISSING").Warnings, "call and config suppressions combine")
+
+	xray := &models.XrayConfig{LintSuppress: []string{"XRAY-LOG-MISSING"}}
+	assert.NotContains(t, ruleIDs(LintXray(xray).Warnings), "XRAY-LOG-MISSING")
+}
+
+func TestRuleIDsUnique(t *testing.T) {
+	ids := RuleIDs()
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		assert.False(t, seen[id], "duplicate rule id %s", id)
+		seen[id] = true
+	}
+}
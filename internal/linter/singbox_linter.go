@@ -0,0 +1,147 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+)
+
+// LintSingBox runs every SingBox rule not listed in suppress or in the
+// config's own LintSuppress.
+func LintSingBox(config *models.SingBoxConfig, suppress ...string) *Report {
+	return run(singBoxRules, config, config.LintSuppress, suppress)
+}
+
+var singBoxRules = []rule[*models.SingBoxConfig]{
+	{id: "SINGBOX-DUPLICATE-TAG", severity: SeverityError, check: singBoxDuplicateTags},
+	{id: "SINGBOX-DETOUR-CYCLE", severity: SeverityError, check: singBoxDetourCycles},
+	{id: "SINGBOX-DNS-FINAL-UNKNOWN", severity: SeverityError, check: singBoxDNSFinalUnknown},
+	{id: "SINGBOX-ROUTE-OUTBOUND-UNKNOWN", severity: SeverityError, check: singBoxRouteOutboundUnknown},
+	{id: "SINGBOX-FAKEIP-NO-SERVER", severity: SeverityError, check: singBoxFakeIPWithoutServer},
+	{id: "SINGBOX-DNS-LEGACY-SERVER", severity: SeverityWarning, check: singBoxDNSLegacy},
+	{id: "SINGBOX-NTP-DISABLED", severity: SeverityWarning, check: singBoxNTPDisabled},
+	{id: "SINGBOX-LOG-MISSING", severity: SeverityInfo, check: singBoxLogMissing},
+	{id: "SINGBOX-TLS-INSECURE", severity: SeverityWarning, check: singBoxTLSInsecure},
+}
+
+func singBoxDuplicateTags(config *models.SingBoxConfig) []Finding {
+	var findings []Finding
+	for _, e := range validation.SingBoxDuplicateTags(config) {
+		findings = append(findings, Finding{Path: e.Field, Message: fmt.Sprintf("%s: %q", e.Message, e.Value)})
+	}
+	return findings
+}
+
+func singBoxDetourCycles(config *models.SingBoxConfig) []Finding {
+	var findings []Finding
+	for _, e := range validation.SingBoxDetourCycles(config) {
+		findings = append(findings, Finding{Path: e.Field, Message: e.Message})
+	}
+	return findings
+}
+
+func singBoxDNSFinalUnknown(config *models.SingBoxConfig) []Finding {
+	if config.DNS == nil || config.DNS.Final == nil {
+		return nil
+	}
+	for _, server := range config.DNS.Servers {
+		if server != nil && server.Tag != nil && *server.Tag == *config.DNS.Final {
+			return nil
+		}
+	}
+	return []Finding{{Path: "dns.final", Message: fmt.Sprintf("no DNS server is tagged %q", *config.DNS.Final)}}
+}
+
+// singBoxOutboundTags returns the tags a route may target: outbounds and
+// endpoints.
+func singBoxOutboundTags(config *models.SingBoxConfig) map[string]bool {
+	tags := make(map[string]bool)
+	for _, out := range config.Outbounds {
+		if out != nil {
+			tags[out.Tag] = true
+		}
+	}
+	for _, ep := range config.Endpoints {
+		if tag, ok := ep["tag"].(string); ok {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+func singBoxRouteOutboundUnknown(config *models.SingBoxConfig) []Finding {
+	if config.Route == nil {
+		return nil
+	}
+	tags := singBoxOutboundTags(config)
+	var findings []Finding
+	for i, rule := range config.Route.Rules {
+		if rule != nil && rule.Outbound != nil && !tags[*rule.Outbound] {
+			findings = append(findings, Finding{
+				Path:    fmt.Sprintf("route.rules[%d].outbound", i),
+				Message: fmt.Sprintf("no outbound is tagged %q", *rule.Outbound),
+			})
+		}
+	}
+	if final := config.Route.Final; final != nil && !tags[*final] {
+		findings = append(findings, Finding{Path: "route.final", Message: fmt.Sprintf("no outbound is tagged %q", *final)})
+	}
+	return findings
+}
+
+func singBoxFakeIPWithoutServer(config *models.SingBoxConfig) []Finding {
+	dns := config.DNS
+	if dns == nil || dns.FakeIP == nil || dns.FakeIP.Enabled == nil || !*dns.FakeIP.Enabled {
+		return nil
+	}
+	for _, server := range dns.Servers {
+		if server == nil {
+			continue
+		}
+		if (server.Type != nil && *server.Type == "fakeip") || (server.Address != nil && *server.Address == "fakeip") {
+			return nil
+		}
+	}
+	return []Finding{{Path: "dns.fakeip.enabled", Message: "FakeIP is enabled but no DNS server has type fakeip"}}
+}
+
+// singBoxDNSLegacy reports legacy DNS server fields when the config targets a
+// sing-box version that deprecated them.
+func singBoxDNSLegacy(config *models.SingBoxConfig) []Finding {
+	var findings []Finding
+	for _, e := range validation.SingBoxDNSLegacyWarnings(config.DNS, config.ModelVersion) {
+		findings = append(findings, Finding{Path: e.Field, Message: e.Message})
+	}
+	return findings
+}
+
+func singBoxNTPDisabled(config *models.SingBoxConfig) []Finding {
+	if config.NTP == nil || config.NTP.Enabled == nil || *config.NTP.Enabled {
+		return nil
+	}
+	return []Finding{{Path: "ntp.enabled", Message: "NTP section is present but disabled"}}
+}
+
+func singBoxLogMissing(config *models.SingBoxConfig) []Finding {
+	if config.Log != nil {
+		return nil
+	}
+	return []Finding{{Path: "log", Message: "no log configuration; sing-box will use its defaults"}}
+}
+
+func singBoxTLSInsecure(config *models.SingBoxConfig) []Finding {
+	var findings []Finding
+	for i, out := range config.Outbounds {
+		if out == nil {
+			continue
+		}
+		if insecure, _ := out.TLS["insecure"].(bool); insecure {
+			findings = append(findings, Finding{
+				Path:    fmt.Sprintf("outbounds[%d].tls.insecure", i),
+				Message: "insecure disables certificate verification",
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,58 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func findingPaths(findings []Finding) []string {
+	paths := make([]string, 0, len(findings))
+	for _, f := range findings {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+func TestLintSingBox_Misconfigurations(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Log: &models.SingBoxLogConfig{},
+		NTP: &models.SingBoxNTPConfig{Enabled: boolPtr(false)},
+		DNS: &models.SingBoxDNSConfig{
+			Servers: []*models.SingBoxDNSServer{{Tag: strPtr("google"), Type: strPtr("udp"), Server: strPtr("8.8.8.8")}},
+			Final:   strPtr("cloudflare"),
+			FakeIP:  &models.SingBoxFakeIPConfig{Enabled: boolPtr(true)},
+		},
+		Inbounds: []*models.SingBoxInbound{{Tag: "mixed-in"}, {Tag: "mixed-in"}},
+		Outbounds: []*models.SingBoxOutbound{
+			{Type: "direct", Tag: "direct"},
+			{Type: "block", Tag: "block"},
+		},
+		Endpoints: []map[string]interface{}{{"type": "wireguard", "tag": "wg"}},
+		Route: &models.SingBoxRouteConfig{
+			Rules: []*models.SingBoxRouteRule{
+				{Outbound: strPtr("direct")},
+				{Outbound: strPtr("wg")},
+				{Outbound: strPtr("proxy")},
+			},
+			Final: strPtr("direct"),
+		},
+	}
+
+	report := LintSingBox(config)
+	assert.ElementsMatch(t, []string{
+		"inbounds[1].tag", "dns.final", "route.rules[2].outbound", "dns.fakeip.enabled",
+	}, findingPaths(report.Errors))
+	require.Len(t, report.Warnings, 1)
+	assert.Equal(t, "SINGBOX-NTP-DISABLED", report.Warnings[0].Rule)
+	assert.Equal(t, "ntp.enabled", report.Warnings[0].Path)
+
+	// Adding the missing pieces clears the errors
+	config.Inbounds[1].Tag = "socks-in"
+	config.DNS.Final = strPtr("google")
+	config.Outbounds = append(config.Outbounds, &models.SingBoxOutbound{Type: "vless", Tag: "proxy"})
+	config.DNS.Servers = append(config.DNS.Servers, &models.SingBoxDNSServer{Tag: strPtr("fake"), Type: strPtr("fakeip")})
+	assert.Empty(t, LintSingBox(config).Errors)
+}
@@ -0,0 +1,289 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+)
+
+// LintXray runs every Xray rule not listed in suppress or in the config's
+// own LintSuppress.
+func LintXray(config *models.XrayConfig, suppress ...string) *Report {
+	return run(xrayRules, config, config.LintSuppress, suppress)
+}
+
+var xrayRules = []rule[*models.XrayConfig]{
+	{id: "XRAY-LOG-MISSING", severity: SeverityInfo, check: xrayLogMissing},
+	{id: "XRAY-SNIFFING-DISABLED", severity: SeverityInfo, check: xraySniffingDisabled},
+	{id: "XRAY-TLS-INSECURE", severity: SeverityWarning, check: xrayTLSInsecure},
+	{id: "XRAY-REALITY-NO-MIN-CLIENT", severity: SeverityInfo, check: xrayRealityMinClient},
+	{id: "XRAY-MUX-VISION", severity: SeverityWarning, check: xrayMuxWithVision},
+	{id: "XRAY-FREEDOM-NO-DOMAIN-STRATEGY", severity: SeverityInfo, check: xrayFreedomDomainStrategy},
+	{id: "XRAY-STATS-NO-POLICY", severity: SeverityWarning, check: xrayStatsWithoutPolicy},
+	{id: "XRAY-API-NOT-WIRED", severity: SeverityWarning, check: xrayAPINotWired},
+	{id: "XRAY-DETOUR-CYCLE", severity: SeverityError, check: xrayDetourCycles},
+	{id: "XRAY-DOMAIN-MATCHER-LINEAR", severity: SeverityWarning, check: xrayDomainMatcherLinear},
+}
+
+func xrayLogMissing(config *models.XrayConfig) []Finding {
+	if config.Log != nil {
+		return nil
+	}
+	return []Finding{{Path: "log", Message: "no log configuration; xray will use its defaults"}}
+}
+
+func xraySniffingDisabled(config *models.XrayConfig) []Finding {
+	var findings []Finding
+	for i, in := range config.Inbounds {
+		if in.Sniffing != nil && (in.Sniffing.Enabled == nil || *in.Sniffing.Enabled) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Path:    fmt.Sprintf("inbounds[%d].sniffing", i),
+			Message: "sniffing is not enabled; domain based routing rules will not match",
+		})
+	}
+	return findings
+}
+
+// xrayStream is the stream settings of one inbound or outbound.
+type xrayStream struct {
+	path     string
+	settings *models.StreamSettingsObject
+}
+
+// xrayStreams lists the stream settings of every inbound and outbound, in
+// config order.
+func xrayStreams(config *models.XrayConfig) []xrayStream {
+	var streams []xrayStream
+	for i, in := range config.Inbounds {
+		if in.StreamSettings != nil {
+			streams = append(streams, xrayStream{fmt.Sprintf("inbounds[%d].streamSettings", i), in.StreamSettings})
+		}
+	}
+	for i, out := range config.Outbounds {
+		if out.StreamSettings != nil {
+			streams = append(streams, xrayStream{fmt.Sprintf("outbounds[%d].streamSettings", i), out.StreamSettings})
+		}
+	}
+	return streams
+}
+
+func xrayTLSInsecure(config *models.XrayConfig) []Finding {
+	var findings []Finding
+	for _, stream := range xrayStreams(config) {
+		tls := stream.settings.TLSSettings
+		if tls != nil && tls.AllowInsecure != nil && *tls.AllowInsecure {
+			findings = append(findings, Finding{
+				Path:    stream.path + ".tlsSettings.allowInsecure",
+				Message: "allowInsecure disables certificate verification",
+			})
+		}
+	}
+	return findings
+}
+
+func xrayRealityMinClient(config *models.XrayConfig) []Finding {
+	var findings []Finding
+	for i, in := range config.Inbounds {
+		s := in.StreamSettings
+		if s == nil || s.TLSSettings == nil || s.TLSSettings.RealitySettings == nil {
+			continue
+		}
+		if s.TLSSettings.RealitySettings.MinClientVer == nil {
+			findings = append(findings, Finding{
+				Path:    fmt.Sprintf("inbounds[%d].streamSettings.tlsSettings.realitySettings.minClientVer", i),
+				Message: "REALITY without minClientVer accepts outdated clients",
+			})
+		}
+	}
+	return findings
+}
+
+func xrayMuxWithVision(config *models.XrayConfig) []Finding {
+	var findings []Finding
+	for i, out := range config.Outbounds {
+		if out.Mux == nil || out.Mux.Enabled == nil || !*out.Mux.Enabled {
+			continue
+		}
+		if out.Protocol == nil || *out.Protocol != "vless" || !usesVisionFlow(out.Settings) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Path:    fmt.Sprintf("outbounds[%d].mux.enabled", i),
+			Message: "mux cannot be combined with the xtls-rprx-vision flow",
+		})
+	}
+	return findings
+}
+
+// usesVisionFlow reports whether any vnext user of a VLESS outbound sets the
+// xtls-rprx-vision flow.
+func usesVisionFlow(settings map[string]interface{}) bool {
+	vnext, _ := settings["vnext"].([]interface{})
+	for _, server := range vnext {
+		s, _ := server.(map[string]interface{})
+		users, _ := s["users"].([]interface{})
+		for _, user := range users {
+			u, _ := user.(map[string]interface{})
+			if flow, _ := u["flow"].(string); flow == "xtls-rprx-vision" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func xrayFreedomDomainStrategy(config *models.XrayConfig) []Finding {
+	var findings []Finding
+	for i, out := range config.Outbounds {
+		if out.Protocol == nil || *out.Protocol != "freedom" {
+			continue
+		}
+		if _, ok := out.Settings["domainStrategy"]; ok {
+			continue
+		}
+		findings = append(findings, Finding{
+			Path:    fmt.Sprintf("outbounds[%d].settings.domainStrategy", i),
+			Message: "freedom outbound without domainStrategy resolves with the system resolver (AsIs)",
+		})
+	}
+	return findings
+}
+
+func xrayStatsWithoutPolicy(config *models.XrayConfig) []Finding {
+	if config.Stats == nil || xrayHasStatsCounters(config.Policy) {
+		return nil
+	}
+	return []Finding{{
+		Path:    "policy",
+		Message: "stats is enabled but no policy enables any stats counters, so nothing is collected",
+	}}
+}
+
+func xrayHasStatsCounters(policy *models.PolicyObject) bool {
+	if policy == nil {
+		return false
+	}
+	enabled := func(b *bool) bool { return b != nil && *b }
+	if sys := policy.System; sys != nil {
+		if enabled(sys.StatsInboundUplink) || enabled(sys.StatsInboundDownlink) ||
+			enabled(sys.StatsOutboundUplink) || enabled(sys.StatsOutboundDownlink) {
+			return true
+		}
+	}
+	for _, level := range policy.Levels {
+		if enabled(level.StatsUserUplink) || enabled(level.StatsUserDownlink) {
+			return true
+		}
+	}
+	return false
+}
+
+// xrayAPINotWired reports an api object whose tag has no inbound or no
+// routing rule sending that inbound to the API, so the gRPC port is never
+// opened.
+func xrayAPINotWired(config *models.XrayConfig) []Finding {
+	if config.API == nil || config.API.Tag == nil || *config.API.Tag == "" {
+		return nil
+	}
+	tag := *config.API.Tag
+	var findings []Finding
+	hasInbound := false
+	for _, in := range config.Inbounds {
+		hasInbound = hasInbound || in.Tag == tag
+	}
+	if !hasInbound {
+		findings = append(findings, Finding{
+			Path:    "api.tag",
+			Message: fmt.Sprintf("no inbound is tagged %q, so the API is unreachable", tag),
+		})
+	}
+	hasRule := false
+	if config.Routing != nil {
+		for _, rule := range config.Routing.Rules {
+			if rule.OutboundTag != nil && *rule.OutboundTag == tag && containsTag(rule.InboundTag, tag) {
+				hasRule = true
+			}
+		}
+	}
+	if !hasRule {
+		findings = append(findings, Finding{
+			Path:    "routing.rules",
+			Message: fmt.Sprintf("no routing rule sends inbound %q to outboundTag %q", tag, tag),
+		})
+	}
+	return findings
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func xrayDetourCycles(config *models.XrayConfig) []Finding {
+	var findings []Finding
+	for _, e := range validation.XrayDetourCycles(config) {
+		findings = append(findings, Finding{Path: e.Field, Message: e.Message})
+	}
+	return findings
+}
+
+// xrayDomainMatcherLinear reports routing that uses, or defaults to, the
+// deprecated "linear" domain matcher. Rules without their own domainMatcher
+// inherit the routing one and are not reported.
+func xrayDomainMatcherLinear(config *models.XrayConfig) []Finding {
+	if config.Routing == nil {
+		return nil
+	}
+	var findings []Finding
+	switch m := config.Routing.DomainMatcher; {
+	case m == nil:
+		findings = append(findings, Finding{
+			Path:    "routing.domainMatcher",
+			Message: "not set, so 'linear' is used, which is deprecated; use 'mph' for better performance",
+		})
+	case *m == "linear":
+		findings = append(findings, Finding{
+			Path:    "routing.domainMatcher",
+			Message: "'linear' is deprecated; use 'mph' for better performance",
+		})
+	}
+	for i, rule := range config.Routing.Rules {
+		if rule.DomainMatcher != nil && *rule.DomainMatcher == "linear" {
+			findings = append(findings, Finding{
+				Path:    fmt.Sprintf("routing.rules[%d].domainMatcher", i),
+				Message: "'linear' is deprecated; use 'mph' for better performance",
+			})
+		}
+	}
+	return findings
+}
+
+// FixXrayDomainMatcher switches config to the "mph" domain matcher wherever
+// XRAY-DOMAIN-MATCHER-LINEAR would report it, in place, and returns the
+// paths it changed.
+func FixXrayDomainMatcher(config *models.XrayConfig) []string {
+	var fixed []string
+	for _, f := range xrayDomainMatcherLinear(config) {
+		fixed = append(fixed, f.Path)
+	}
+	if len(fixed) == 0 {
+		return nil
+	}
+	mph := func() *string { s := "mph"; return &s }
+	if m := config.Routing.DomainMatcher; m == nil || *m == "linear" {
+		config.Routing.DomainMatcher = mph()
+	}
+	for i := range config.Routing.Rules {
+		if m := config.Routing.Rules[i].DomainMatcher; m != nil && *m == "linear" {
+			config.Routing.Rules[i].DomainMatcher = mph()
+		}
+	}
+	return fixed
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jsonBody = `{"inbounds": [` + strings.Repeat(`{"tag": "in", "port": 443},`, 100) + `{}]}`
+
+func jsonHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", "12345")
+	io.WriteString(w, jsonBody)
+}
+
+func TestGzip_CompressesWhenAccepted(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/configs/xray", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	rec := httptest.NewRecorder()
+	Gzip(http.HandlerFunc(jsonHandler)).ServeHTTP(rec, req)
+
+	res := rec.Result()
+	assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+	assert.Empty(t, res.Header.Get("Content-Length"))
+	assert.Equal(t, "Accept-Encoding", res.Header.Get("Vary"))
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.Less(t, rec.Body.Len(), len(jsonBody))
+
+	zr, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, jsonBody, string(body))
+}
+
+func TestGzip_PassThrough(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		handler        http.HandlerFunc
+	}{
+		{"not accepted", "", jsonHandler},
+		{"refused", "gzip;q=0", jsonHandler},
+		{"refused decimal", "gzip; q=0.000", jsonHandler},
+		{"already encoded", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, jsonBody)
+		}},
+		{"compressed format", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/zip")
+			io.WriteString(w, jsonBody)
+		}},
+		{"no content", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			rec := httptest.NewRecorder()
+			Gzip(tc.handler).ServeHTTP(rec, req)
+			assert.NotEqual(t, "gzip", rec.Header().Get("Content-Encoding"))
+			if rec.Code != http.StatusNoContent {
+				assert.Equal(t, jsonBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGzip_StreamingFlush(t *testing.T) {
+	chunk := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-chunk
+		io.WriteString(w, "data: second\n\n")
+	})
+	srv := httptest.NewServer(Gzip(handler))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip") // set explicitly so the transport does not decode it
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+
+	zr, err := gzip.NewReader(res.Body)
+	require.NoError(t, err)
+	first := make([]byte, len("data: first\n\n"))
+	_, err = io.ReadFull(zr, first)
+	require.NoError(t, err, "flushed data must arrive before the handler returns")
+	assert.Equal(t, "data: first\n\n", string(first))
+
+	close(chunk)
+	rest, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "data: second\n\n", string(rest))
+}
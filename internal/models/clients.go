@@ -0,0 +1,78 @@
+package models
+
+import "strings"
+
+// Config types, as recorded in a ClientPlacement and used for snapshot
+// archive directories and dashboard summaries.
+const (
+	ConfigTypeXray    = "xray"
+	ConfigTypeSingBox = "singbox"
+)
+
+// ClientPlacement records that the client identified by Email is configured
+// on an inbound of a stored config.
+type ClientPlacement struct {
+	Email      string `json:"email" example:"alice@example.com"`
+	ConfigType string `json:"config_type" example:"xray"` // "xray" or "singbox"
+	ConfigID   string `json:"config_id" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"`
+	InboundTag string `json:"inbound_tag" example:"vless-in"`
+	Protocol   string `json:"protocol" example:"vless"`
+}
+
+// XrayClientPlacements lists the clients with an email in the settings of
+// every Xray inbound. Emails are lower-cased.
+func XrayClientPlacements(config *XrayConfig) []ClientPlacement {
+	var placements []ClientPlacement
+	for _, in := range config.Inbounds {
+		for _, email := range settingsUserField(in.Settings, "clients", "email") {
+			placements = append(placements, ClientPlacement{
+				Email:      email,
+				ConfigType: ConfigTypeXray,
+				ConfigID:   config.ID,
+				InboundTag: in.Tag,
+				Protocol:   in.Protocol,
+			})
+		}
+	}
+	return placements
+}
+
+// SingBoxClientPlacements is the SingBox counterpart of XrayClientPlacements.
+// sing-box identifies users by name, which is indexed as the email.
+func SingBoxClientPlacements(config *SingBoxConfig) []ClientPlacement {
+	var placements []ClientPlacement
+	for _, in := range config.Inbounds {
+		if in == nil {
+			continue
+		}
+		for _, name := range settingsUserField(in.Settings, "users", "name") {
+			placements = append(placements, ClientPlacement{
+				Email:      name,
+				ConfigType: ConfigTypeSingBox,
+				ConfigID:   config.ID,
+				InboundTag: in.Tag,
+				Protocol:   in.Type,
+			})
+		}
+	}
+	return placements
+}
+
+// settingsUserField returns the non-empty, lower-cased string value of field
+// for every object in settings[list], each value once.
+func settingsUserField(settings map[string]interface{}, list, field string) []string {
+	users, _ := settings[list].([]interface{})
+	var values []string
+	seen := make(map[string]bool)
+	for _, u := range users {
+		user, _ := u.(map[string]interface{})
+		value, _ := user[field].(string)
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		values = append(values, value)
+	}
+	return values
+}
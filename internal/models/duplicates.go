@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ConfigRef identifies a stored config without its content.
+type ConfigRef struct {
+	ID        string    `json:"id" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"`
+	Name      string    `json:"name" example:"default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DuplicateGroup lists configs sharing the same canonical content hash,
+// oldest first.
+type DuplicateGroup struct {
+	ConfigHash string      `json:"config_hash" example:"3f2a..."`
+	Configs    []ConfigRef `json:"configs"`
+}
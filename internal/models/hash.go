@@ -0,0 +1,102 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// canonicalMetadataKeys are the top-level JSON keys that only describe how a
+// config is managed by the panel. They never reach the proxy binary, so they
+// are excluded from the canonical hash.
+var canonicalMetadataKeys = []string{
+	"id", "name", "description",
+	"created_at", "updated_at", // XrayConfig
+	"createdAt", "updatedAt", // SingBoxConfig
+	"config_hash",
+	"environment", "promoted_from",
+	"model_version",
+	"managed_sections",
+	"lint_suppress",
+}
+
+// CanonicalHashXray returns a stable SHA-256 (hex encoded) over the canonical
+// JSON form of an Xray configuration. Map keys are sorted and panel metadata
+// (ID, name, description, timestamps) is ignored, so two configs that would be
+// deployed identically always produce the same hash.
+func CanonicalHashXray(cfg *XrayConfig) (string, error) {
+	if cfg == nil {
+		return "", fmt.Errorf("cannot hash nil xray config")
+	}
+	return canonicalHash(cfg)
+}
+
+// CanonicalHashSingBox is the SingBox counterpart of CanonicalHashXray.
+func CanonicalHashSingBox(cfg *SingBoxConfig) (string, error) {
+	if cfg == nil {
+		return "", fmt.Errorf("cannot hash nil singbox config")
+	}
+	return canonicalHash(cfg)
+}
+
+// canonicalHash hashes the deployable document of v. encoding/json sorts map
+// keys at every level, which gives us a canonical byte representation
+// independent of field order.
+func canonicalHash(v interface{}) (string, error) {
+	doc, err := DeployableDocument(v)
+	if err != nil {
+		return "", err
+	}
+
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal canonical config: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// DeployableDocument converts a config into a generic JSON document without
+// the panel metadata keys, i.e. what the proxy binary actually receives.
+// Xray routing rules with enabled explicitly set to false are left out too;
+// v itself is not modified. Numbers are kept as json.Number so they
+// round-trip exactly.
+func DeployableDocument(v interface{}) (map[string]interface{}, error) {
+	if cfg, ok := v.(*XrayConfig); ok && cfg != nil && cfg.Routing != nil {
+		emitted := *cfg
+		routing := *cfg.Routing
+		routing.Rules = enabledRoutingRules(cfg.Routing.Rules)
+		emitted.Routing = &routing
+		v = &emitted
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() // Keep numbers exactly as they were written
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	for _, key := range canonicalMetadataKeys {
+		delete(doc, key)
+	}
+	return doc, nil
+}
+
+// enabledRoutingRules returns rules without the disabled ones. A nil Enabled
+// means enabled.
+func enabledRoutingRules(rules []RoutingRule) []RoutingRule {
+	var out []RoutingRule
+	for _, rule := range rules {
+		if rule.Enabled != nil && !*rule.Enabled {
+			continue
+		}
+		out = append(out, rule)
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalHashXray_StableAcrossFieldOrder(t *testing.T) {
+	docA := `{
+		"name": "A",
+		"log": {"loglevel": "warning", "access": "/var/log/access.log"},
+		"inbounds": [{"tag": "in", "port": 443, "protocol": "vless", "settings": {"decryption": "none", "clients": [{"id": "u1", "flow": "xtls-rprx-vision"}]}}]
+	}`
+	docB := `{
+		"inbounds": [{"settings": {"clients": [{"flow": "xtls-rprx-vision", "id": "u1"}], "decryption": "none"}, "protocol": "vless", "port": 443, "tag": "in"}],
+		"log": {"access": "/var/log/access.log", "loglevel": "warning"},
+		"name": "B"
+	}`
+
+	var cfgA, cfgB XrayConfig
+	require.NoError(t, json.Unmarshal([]byte(docA), &cfgA))
+	require.NoError(t, json.Unmarshal([]byte(docB), &cfgB))
+	cfgA.ID, cfgA.CreatedAt = "id-a", time.Now()
+	cfgB.ID, cfgB.UpdatedAt = "id-b", time.Now().Add(time.Hour)
+
+	hashA, err := CanonicalHashXray(&cfgA)
+	require.NoError(t, err)
+	hashB, err := CanonicalHashXray(&cfgB)
+	require.NoError(t, err)
+	assert.Len(t, hashA, 64)
+	assert.Equal(t, hashA, hashB, "metadata and key order must not affect the hash")
+
+	cfgB.Inbounds[0].Port = 8443
+	hashChanged, err := CanonicalHashXray(&cfgB)
+	require.NoError(t, err)
+	assert.NotEqual(t, hashA, hashChanged, "content changes must change the hash")
+}
+
+func TestCanonicalHashSingBox_ChangesWithContent(t *testing.T) {
+	level := "info"
+	cfg := &SingBoxConfig{
+		Name:      "sb",
+		Log:       &SingBoxLogConfig{Level: &level},
+		Outbounds: []*SingBoxOutbound{{Type: "direct", Tag: "direct"}},
+	}
+	first, err := CanonicalHashSingBox(cfg)
+	require.NoError(t, err)
+
+	cfg.ConfigHash = first // A previously computed hash must not feed back into the next one
+	again, err := CanonicalHashSingBox(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	cfg.Outbounds = append(cfg.Outbounds, &SingBoxOutbound{Type: "block", Tag: "block"})
+	changed, err := CanonicalHashSingBox(cfg)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+
+	_, err = CanonicalHashSingBox(nil)
+	assert.Error(t, err)
+}
+
+func TestCanonicalHashXray_RuleOrderMatters(t *testing.T) {
+	direct, block := "direct", "block"
+	cfg := &XrayConfig{Routing: &RoutingObject{Rules: []RoutingRule{
+		{Domain: []string{"example.com"}, OutboundTag: &direct},
+		{IP: []string{"10.0.0.0/8"}, OutboundTag: &block},
+	}}}
+	before, err := CanonicalHashXray(cfg)
+	require.NoError(t, err)
+
+	roundTrip, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	var reparsed XrayConfig
+	require.NoError(t, json.Unmarshal(roundTrip, &reparsed))
+	again, err := CanonicalHashXray(&reparsed)
+	require.NoError(t, err)
+	assert.Equal(t, before, again, "re-marshalling must not change the hash")
+
+	cfg.Routing.Rules[0], cfg.Routing.Rules[1] = cfg.Routing.Rules[1], cfg.Routing.Rules[0]
+	swapped, err := CanonicalHashXray(cfg)
+	require.NoError(t, err)
+	assert.NotEqual(t, before, swapped, "routing rules are evaluated in order")
+}
+
+func TestCanonicalHashXray_IgnoresDisabledRules(t *testing.T) {
+	tag, dns, https := "direct", "53", "443"
+	cfg := &XrayConfig{Routing: &RoutingObject{Rules: []RoutingRule{{OutboundTag: &tag, Port: &dns}}}}
+	before, err := CanonicalHashXray(cfg)
+	require.NoError(t, err)
+
+	disabled := false
+	cfg.Routing.Rules = append(cfg.Routing.Rules, RoutingRule{OutboundTag: &tag, Port: &https, Enabled: &disabled})
+	after, err := CanonicalHashXray(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "a disabled rule is not deployed")
+	assert.Len(t, cfg.Routing.Rules, 2, "the config itself is not modified")
+
+	enabled := true
+	cfg.Routing.Rules[1].Enabled = &enabled
+	changed, err := CanonicalHashXray(cfg)
+	require.NoError(t, err)
+	assert.NotEqual(t, before, changed)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyRecord is the cached response of a create request made with an
+// X-Idempotency-Key header. KeyHash covers both the operation and the client
+// supplied key, so the same key may be reused across operations. Response is
+// empty while the request that reserved the key is still running.
+type IdempotencyRecord struct {
+	KeyHash   string          `json:"key_hash"`
+	Operation string          `json:"operation" example:"create_xray_config"`
+	Response  json.RawMessage `json:"response"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Job run outcomes.
+const (
+	JobOutcomeSuccess = "success"
+	JobOutcomeFailure = "failure"
+)
+
+// JobRun records one execution of a background job.
+type JobRun struct {
+	ID         string    `json:"id" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"`
+	Job        string    `json:"job" example:"prune-idempotency-keys"`
+	Trigger    string    `json:"trigger" example:"schedule"` // "schedule" or "manual"
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Outcome    string    `json:"outcome" example:"success"`
+	Error      string    `json:"error,omitempty"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// ManagedSection marks part of a config as owned by a panel feature. Path is
+// dotted JSON; inside arrays a segment selects the element with that tag, or
+// the element at that index when numeric (e.g. "inbounds.api" or
+// "inbounds.vless-in.settings.clients").
+type ManagedSection struct {
+	Path  string `json:"path" example:"inbounds.api"`
+	Owner string `json:"owner" example:"xray-api"` // Feature that maintains the section
+}
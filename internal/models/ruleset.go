@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Rule-set types and formats accepted by sing-box.
+const (
+	RuleSetTypeRemote = "remote"
+	RuleSetTypeLocal  = "local"
+
+	RuleSetFormatSource = "source"
+	RuleSetFormatBinary = "binary"
+)
+
+// SingBoxRuleSet is a rule-set managed by the panel independently of any
+// configuration. Configs reference it by Tag from route and DNS rules and the
+// generator expands the reference into a route.rule_set entry.
+// Documentation: https://sing-box.sagernet.org/configuration/rule-set/
+type SingBoxRuleSet struct {
+	ID             string    `json:"id,omitempty" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"`
+	Tag            string    `json:"tag" example:"geosite-cn"`
+	Type           string    `json:"type" example:"remote"`   // "remote" or "local"
+	Format         string    `json:"format" example:"binary"` // "source" or "binary"
+	URL            string    `json:"url,omitempty" example:"https://raw.githubusercontent.com/SagerNet/sing-geosite/rule-set/geosite-cn.srs"`
+	Path           string    `json:"path,omitempty"`            // Path on the node for local rule-sets
+	Content        string    `json:"content,omitempty"`         // Uploaded or last downloaded source content, for preview only
+	DownloadDetour string    `json:"download_detour,omitempty"` // Outbound tag used to fetch remote rule-sets
+	UpdateInterval string    `json:"update_interval,omitempty" example:"1d"`
+	CreatedAt      time.Time `json:"createdAt,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt,omitempty"`
+}
+
+// RouteRuleSet returns the object emitted into route.rule_set for this
+// rule-set. Content is never emitted; sing-box loads local rule-sets by path.
+func (r *SingBoxRuleSet) RouteRuleSet() map[string]interface{} {
+	out := map[string]interface{}{
+		"tag":    r.Tag,
+		"type":   r.Type,
+		"format": r.Format,
+	}
+	switch r.Type {
+	case RuleSetTypeRemote:
+		out["url"] = r.URL
+		if r.DownloadDetour != "" {
+			out["download_detour"] = r.DownloadDetour
+		}
+		if r.UpdateInterval != "" {
+			out["update_interval"] = r.UpdateInterval
+		}
+	case RuleSetTypeLocal:
+		out["path"] = r.Path
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package models
+
+// Resource types returned by the store search.
+const (
+	SearchTypeXrayConfig    = "xray_configs"
+	SearchTypeSingBoxConfig = "singbox_configs"
+)
+
+// SearchResult is a single search hit. Field names the field that matched
+// and Snippet is the text around the match.
+type SearchResult struct {
+	Type    string `json:"type" example:"xray_configs"`
+	ID      string `json:"id" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"`
+	Name    string `json:"name" example:"frankfurt-edge"`
+	Field   string `json:"field" example:"name"` // "name", "description" or "inbounds"
+	Snippet string `json:"snippet"`
+}
+
+// SearchResults groups search hits by resource type, best match first.
+type SearchResults struct {
+	XrayConfigs    []SearchResult `json:"xray_configs"`
+	SingBoxConfigs []SearchResult `json:"singbox_configs"`
+}
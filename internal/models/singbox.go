@@ -232,9 +232,10 @@ type SingBoxInbound struct {
 	NetNS                    *string                `json:"netns,omitempty"` // New in 1.12
 
 	// Protocol-specific settings, TLS, and Transport settings are kept generic for now.
-	Settings  map[string]interface{} `json:"settings,omitempty"`  // Protocol-specific settings
-	TLS       map[string]interface{} `json:"tls,omitempty"`       // TLS settings object, see shared/tls
-	Transport map[string]interface{} `json:"transport,omitempty"` // Transport settings object, see shared/v2ray-transport
+	Settings  map[string]interface{}  `json:"settings,omitempty"`  // Protocol-specific settings
+	TLS       map[string]interface{}  `json:"tls,omitempty"`       // TLS settings object, see shared/tls
+	Transport map[string]interface{}  `json:"transport,omitempty"` // Transport settings object, see shared/v2ray-transport
+	Multiplex *SingBoxMultiplexConfig `json:"multiplex,omitempty"` // Inbound multiplex, see shared/multiplex
 }
 
 // SingBoxOutbound defines the structure for outbound connections in SingBox.
@@ -244,7 +245,29 @@ type SingBoxOutbound struct {
 	Settings map[string]interface{} `json:"settings,omitempty"`   // Protocol-specific settings
 	TLS      map[string]interface{} `json:"tls,omitempty"`        // TLS settings object
 	Transport map[string]interface{}`json:"transport,omitempty"` // Transport settings object
-	Multiplex map[string]interface{} `json:"multiplex,omitempty"`// Multiplex settings object
+	Multiplex *SingBoxMultiplexConfig `json:"multiplex,omitempty"` // Multiplex settings object
+}
+
+// SingBoxMultiplexConfig defines multiplexing for inbounds and outbounds.
+// Inbounds only use Enabled, Padding and Brutal.
+// Documentation: https://sing-box.sagernet.org/configuration/shared/multiplex/
+type SingBoxMultiplexConfig struct {
+	Enabled        *bool                `json:"enabled,omitempty"`
+	Protocol       *string              `json:"protocol,omitempty"`        // "smux" (default), "yamux" or "h2mux"
+	MaxConnections *int                 `json:"max_connections,omitempty"` // Conflicts with max_streams
+	MinStreams     *int                 `json:"min_streams,omitempty"`     // Conflicts with max_streams
+	MaxStreams     *int                 `json:"max_streams,omitempty"`     // Conflicts with max_connections and min_streams
+	Padding        *bool                `json:"padding,omitempty"`
+	Brutal         *SingBoxBrutalConfig `json:"brutal,omitempty"`
+}
+
+// SingBoxBrutalConfig enables TCP Brutal congestion control on multiplexed
+// connections.
+// Documentation: https://sing-box.sagernet.org/configuration/shared/tcp-brutal/
+type SingBoxBrutalConfig struct {
+	Enabled  *bool `json:"enabled,omitempty"`
+	UpMbps   *int  `json:"up_mbps,omitempty"`
+	DownMbps *int  `json:"down_mbps,omitempty"`
 }
 
 // SingBoxRouteRule defines a rule within the routing configuration.
@@ -316,6 +339,15 @@ type SingBoxConfig struct {
 	Description string    `json:"description,omitempty" example:"Experimental Sing-box setup"`
 	CreatedAt   time.Time `json:"createdAt,omitempty" example:"2023-01-02T10:00:00Z"`
 	UpdatedAt   time.Time `json:"updatedAt,omitempty" example:"2023-01-02T11:00:00Z"`
+	ConfigHash  string    `json:"config_hash,omitempty" example:"3f2a..."` // Canonical content hash, see CanonicalHashSingBox
+
+	// Deployment tier, used by the promotion workflow
+	Environment  string `json:"environment,omitempty" example:"staging"`                                 // e.g. "staging", "production"
+	PromotedFrom string `json:"promoted_from,omitempty" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"` // ID of the config this one was promoted from
+
+	ModelVersion string `json:"model_version,omitempty" example:"1.9.0"` // sing-box version the config was authored for
+
+	LintSuppress []string `json:"lint_suppress,omitempty" example:"SINGBOX-LOG-MISSING"` // Linter rule IDs not reported for this config
 
 	Log          *SingBoxLogConfig         `json:"log,omitempty"`
 	DNS          *SingBoxDNSConfig         `json:"dns,omitempty"`
@@ -10,6 +10,15 @@ type XrayConfig struct {
 	Description string    `json:"description,omitempty" example:"Main Xray server configuration"`
 	CreatedAt   time.Time `json:"created_at" example:"2023-01-01T12:00:00Z"`
 	UpdatedAt   time.Time `json:"updated_at" example:"2023-01-01T13:00:00Z"`
+	ConfigHash  string    `json:"config_hash,omitempty" example:"3f2a..."` // Canonical content hash, see CanonicalHashXray
+
+	// Deployment tier, used by the promotion workflow
+	Environment  string `json:"environment,omitempty" example:"staging"`                                 // e.g. "staging", "production"
+	PromotedFrom string `json:"promoted_from,omitempty" example:"xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"` // ID of the config this one was promoted from
+
+	ModelVersion string `json:"model_version,omitempty" example:"1.8.0"` // Xray version the config was authored for
+
+	LintSuppress []string `json:"lint_suppress,omitempty" example:"XRAY-LOG-MISSING"` // Linter rule IDs not reported for this config
 
 	// Core Xray configuration fields
 	Log              *LogObject              `json:"log,omitempty"`
@@ -26,7 +35,9 @@ type XrayConfig struct {
 	Metrics          *MetricsObject          `json:"metrics,omitempty"`
 	Observatory      *ObservatoryObject      `json:"observatory,omitempty"`
 	BurstObservatory *BurstObservatoryObject `json:"burstObservatory,omitempty"` // Project X specific
-	Services         map[string]interface{}  `json:"services,omitempty"`         // New, for pluggable services
+	Services         *XrayServices           `json:"services,omitempty"`         // Pluggable services such as browserDialer
+
+	ManagedSections []ManagedSection `json:"managed_sections,omitempty"` // Paths owned by panel features, see configedit.ProtectManagedSections
 }
 
 // LogObject defines logging settings.
@@ -196,6 +207,10 @@ type RealitySettingsObject struct {
 	MaxTimeDiff  *int64   `json:"maxTimeDiff,omitempty"`// Max time difference in ms, default 0 (disabled)
 	ShortIds     []string `json:"shortIds,omitempty"`   // List of short IDs (0-15 byte hex strings)
 	SpiderX      *string  `json:"spiderX,omitempty"`   // Path for crawling destination server, default "/"
+
+	// Client side (outbounds)
+	PublicKey *string `json:"publicKey,omitempty"` // Server's public key, the counterpart of PrivateKey
+	ShortId   *string `json:"shortId,omitempty"`   // One of the server's ShortIds
 }
 
 // TLSSettings defines TLS settings.
@@ -471,7 +486,24 @@ type BurstObservatoryObject struct {
 	ProbeInterval   *string  `json:"probeInterval,omitempty"`
 }
 
+// XrayServices holds the pluggable services of the top-level services block.
+type XrayServices struct {
+	BrowserDialer *BrowserDialerObject `json:"browserDialer,omitempty"`
+}
+
+// BrowserDialerObject routes WebSocket dialing through a browser.
+// Docs: https://xtls.github.io/config/features/browser_dialer.html
+type BrowserDialerObject struct {
+	Address string `json:"address,omitempty"` // Address the browser page connects to, e.g. "ws://127.0.0.1:12345"
+}
+
 // Helper function to get a pointer to an int. Useful for optional int fields.
 func IntPtr(i int) *int {
 	return &i
 }
+
+// XrayConfigBatch is the result of fetching several Xray configs by ID.
+type XrayConfigBatch struct {
+	Configs []*XrayConfig `json:"configs"`
+	Missing []string      `json:"missing"` // Requested IDs that do not exist
+}
@@ -0,0 +1,132 @@
+// Package notify tells agents watching a config that it has changed, so they
+// can fetch and apply the new version.
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// ConfigChangeEvent announces a new version of a stored config.
+type ConfigChangeEvent struct {
+	ConfigID   string    `json:"config_id"`
+	ConfigType string    `json:"config_type"` // models.ConfigTypeXray or models.ConfigTypeSingBox
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// watcherBuffer is the per-watcher channel capacity. Events for a full
+// watcher are dropped so that a slow agent never blocks the config write
+// that triggered them; every event means "reload", so a later one still
+// brings the agent up to date.
+const watcherBuffer = 4
+
+// ConfigChangeNotifier fans config change events out to the agents watching
+// each config ID. The zero value is not usable; create one with
+// NewConfigChangeNotifier.
+type ConfigChangeNotifier struct {
+	mu       sync.RWMutex
+	watchers map[string][]chan ConfigChangeEvent
+}
+
+// NewConfigChangeNotifier creates a notifier without watchers.
+func NewConfigChangeNotifier() *ConfigChangeNotifier {
+	return &ConfigChangeNotifier{watchers: make(map[string][]chan ConfigChangeEvent)}
+}
+
+// Watch returns a channel receiving every change to configID from now on.
+// Callers must call Unwatch when the agent disconnects, which closes it.
+func (n *ConfigChangeNotifier) Watch(configID string) <-chan ConfigChangeEvent {
+	ch := make(chan ConfigChangeEvent, watcherBuffer)
+	n.mu.Lock()
+	n.watchers[configID] = append(n.watchers[configID], ch)
+	n.mu.Unlock()
+	return ch
+}
+
+// Unwatch removes and closes a channel obtained from Watch. Unknown channels
+// are ignored.
+func (n *ConfigChangeNotifier) Unwatch(configID string, watch <-chan ConfigChangeEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	watchers := n.watchers[configID]
+	for i, ch := range watchers {
+		if (<-chan ConfigChangeEvent)(ch) != watch {
+			continue
+		}
+		close(ch)
+		watchers = append(watchers[:i], watchers[i+1:]...)
+		if len(watchers) == 0 {
+			delete(n.watchers, configID)
+		} else {
+			n.watchers[configID] = watchers
+		}
+		return
+	}
+}
+
+// Publish delivers event to every watcher of event.ConfigID without
+// blocking.
+func (n *ConfigChangeNotifier) Publish(event ConfigChangeEvent) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, ch := range n.watchers[event.ConfigID] {
+		select {
+		case ch <- event:
+		default: // Agent is not keeping up; it already has a pending reload
+		}
+	}
+}
+
+// WatcherCount returns the number of agents watching configID.
+func (n *ConfigChangeNotifier) WatcherCount(configID string) int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.watchers[configID])
+}
+
+// Store publishes a ConfigChangeEvent after every successful config update
+// and otherwise delegates to the wrapped store.
+type Store struct {
+	store.Store
+	notifier *ConfigChangeNotifier
+}
+
+// NewStore wraps next so that config updates are published to notifier.
+func NewStore(next store.Store, notifier *ConfigChangeNotifier) *Store {
+	return &Store{Store: next, notifier: notifier}
+}
+
+// UpdateSingBoxConfig updates config and notifies its watchers.
+func (s *Store) UpdateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) error {
+	if err := s.Store.UpdateSingBoxConfig(ctx, config); err != nil {
+		return err
+	}
+	s.notifier.Publish(ConfigChangeEvent{ConfigID: config.ID, ConfigType: models.ConfigTypeSingBox, UpdatedAt: config.UpdatedAt})
+	return nil
+}
+
+// UpdateXrayConfig updates config and notifies its watchers.
+func (s *Store) UpdateXrayConfig(ctx context.Context, config *models.XrayConfig) error {
+	if err := s.Store.UpdateXrayConfig(ctx, config); err != nil {
+		return err
+	}
+	s.notifier.Publish(ConfigChangeEvent{ConfigID: config.ID, ConfigType: models.ConfigTypeXray, UpdatedAt: config.UpdatedAt})
+	return nil
+}
+
+// UpdateXrayConfigs updates configs in one transaction and, once it has
+// committed, notifies the watchers of each config.
+func (s *Store) UpdateXrayConfigs(ctx context.Context, configs ...*models.XrayConfig) error {
+	if err := s.Store.UpdateXrayConfigs(ctx, configs...); err != nil {
+		return err
+	}
+	for _, config := range configs {
+		s.notifier.Publish(ConfigChangeEvent{ConfigID: config.ID, ConfigType: models.ConfigTypeXray, UpdatedAt: config.UpdatedAt})
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func TestStore_UpdatePublishes(t *testing.T) {
+	ctx := context.Background()
+	notifier := NewConfigChangeNotifier()
+	st := NewStore(sqlitetest.New(t), notifier)
+
+	config := &models.SingBoxConfig{Name: "client"}
+	require.NoError(t, st.CreateSingBoxConfig(ctx, config))
+	other := &models.SingBoxConfig{Name: "other"}
+	require.NoError(t, st.CreateSingBoxConfig(ctx, other))
+
+	watch := notifier.Watch(config.ID)
+	unrelated := notifier.Watch(other.ID)
+
+	config.Description = "changed"
+	require.NoError(t, st.UpdateSingBoxConfig(ctx, config))
+	select {
+	case ev := <-watch:
+		assert.Equal(t, ConfigChangeEvent{ConfigID: config.ID, ConfigType: models.ConfigTypeSingBox, UpdatedAt: config.UpdatedAt}, ev)
+	default:
+		t.Fatal("expected a change event")
+	}
+	assert.Len(t, unrelated, 0, "events must not leak to other configs")
+
+	// A failed update publishes nothing
+	missing := &models.SingBoxConfig{ID: "missing", Name: "missing"}
+	require.Error(t, st.UpdateSingBoxConfig(ctx, missing))
+	assert.Len(t, watch, 0)
+
+	notifier.Unwatch(config.ID, watch)
+	_, open := <-watch
+	assert.False(t, open, "unwatch must close the channel")
+	assert.Equal(t, 0, notifier.WatcherCount(config.ID))
+}
+
+func TestStore_UpdateXrayConfigsPublishesEach(t *testing.T) {
+	ctx := context.Background()
+	notifier := NewConfigChangeNotifier()
+	st := NewStore(sqlitetest.New(t), notifier)
+
+	first := &models.XrayConfig{Name: "bridge"}
+	second := &models.XrayConfig{Name: "portal"}
+	require.NoError(t, st.CreateXrayConfig(ctx, first))
+	require.NoError(t, st.CreateXrayConfig(ctx, second))
+	watchFirst := notifier.Watch(first.ID)
+	watchSecond := notifier.Watch(second.ID)
+
+	first.Description, second.Description = "paired", "paired"
+	require.NoError(t, st.UpdateXrayConfigs(ctx, first, second))
+	require.Len(t, watchFirst, 1)
+	require.Len(t, watchSecond, 1)
+	assert.Equal(t, ConfigChangeEvent{ConfigID: second.ID, ConfigType: models.ConfigTypeXray, UpdatedAt: second.UpdatedAt}, <-watchSecond)
+	<-watchFirst
+
+	// A rolled back batch publishes nothing
+	missing := &models.XrayConfig{ID: "missing", Name: "missing"}
+	require.Error(t, st.UpdateXrayConfigs(ctx, first, missing))
+	assert.Len(t, watchFirst, 0)
+}
+
+func TestConfigChangeNotifier_SlowWatcherDoesNotBlock(t *testing.T) {
+	notifier := NewConfigChangeNotifier()
+	watch := notifier.Watch("cfg")
+	for i := 0; i < watcherBuffer*2; i++ {
+		notifier.Publish(ConfigChangeEvent{ConfigID: "cfg"})
+	}
+	assert.Len(t, watch, watcherBuffer)
+}
@@ -0,0 +1,46 @@
+// Package probe checks whether remote endpoints are reachable.
+package probe
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DefaultTimeout bounds a single probe when the caller passes no timeout.
+const DefaultTimeout = 5 * time.Second
+
+// Result describes one reachability check. It is returned for failed
+// probes too, with Error set, so callers can report it as-is.
+type Result struct {
+	Address   string    `json:"address" example:"203.0.113.10:8443"`
+	Reachable bool      `json:"reachable"`
+	LatencyMS int64     `json:"latency_ms" example:"42"` // Time to establish the connection
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// TCP dials address and closes the connection again.
+func TCP(ctx context.Context, address string, timeout time.Duration) Result {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", address)
+	result := Result{
+		Address:   address,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	conn.Close()
+	result.Reachable = true
+	return result
+}
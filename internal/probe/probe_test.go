@@ -0,0 +1,38 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCP_Reachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URL, "http://")
+
+	result := TCP(context.Background(), address, time.Second)
+	assert.True(t, result.Reachable)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, address, result.Address)
+	assert.False(t, result.CheckedAt.IsZero())
+}
+
+func TestTCP_Unreachable(t *testing.T) {
+	// Grab a free port and close it again so nothing is listening there
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	l.Close()
+
+	result := TCP(context.Background(), address, time.Second)
+	assert.False(t, result.Reachable)
+	assert.NotEmpty(t, result.Error)
+}
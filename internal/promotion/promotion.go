@@ -0,0 +1,65 @@
+// Package promotion copies configuration content between deployment tiers,
+// e.g. from a staging config onto its production counterpart.
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// ErrInvalidTarget is returned when the target environment is empty or equal
+// to the source config's own environment.
+var ErrInvalidTarget = errors.New("invalid promotion target environment")
+
+// XrayResult describes a completed promotion.
+type XrayResult struct {
+	Target  *models.XrayConfig `json:"target"`
+	Created bool               `json:"created"` // false if an existing counterpart was updated
+}
+
+// PromoteXray copies the content of the Xray config sourceID onto its
+// counterpart in environment to. The counterpart is the config previously
+// promoted from sourceID into that environment; it keeps its own ID, name,
+// description and labels. When no counterpart exists one is created, named
+// "<source name> (<to>)".
+func PromoteXray(ctx context.Context, st store.Store, sourceID, to string) (*XrayResult, error) {
+	source, err := st.GetXrayConfig(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if to == "" || to == source.Environment {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTarget, to)
+	}
+
+	existing, err := st.GetXrayConfigPromotion(ctx, sourceID, to)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	target := *source // Content fields are copied wholesale
+	target.Environment = to
+	target.PromotedFrom = sourceID
+
+	if existing == nil {
+		target.ID = ""
+		target.Name = fmt.Sprintf("%s (%s)", source.Name, to)
+		if err := st.CreateXrayConfig(ctx, &target); err != nil {
+			return nil, fmt.Errorf("create %s counterpart: %w", to, err)
+		}
+		return &XrayResult{Target: &target, Created: true}, nil
+	}
+
+	target.ID = existing.ID
+	target.Name = existing.Name
+	target.Description = existing.Description
+	target.CreatedAt = existing.CreatedAt
+	if err := st.UpdateXrayConfig(ctx, &target); err != nil {
+		return nil, fmt.Errorf("update %s counterpart: %w", to, err)
+	}
+	return &XrayResult{Target: &target}, nil
+}
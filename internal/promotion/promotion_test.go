@@ -0,0 +1,51 @@
+package promotion
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestPromoteXray(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+
+	staging := &models.XrayConfig{Name: "edge", Environment: "staging", Log: &models.LogObject{Loglevel: strPtr("debug")}}
+	require.NoError(t, st.CreateXrayConfig(ctx, staging))
+
+	// First promotion creates the production counterpart
+	res, err := PromoteXray(ctx, st, staging.ID, "production")
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	prod := res.Target
+	assert.NotEqual(t, staging.ID, prod.ID)
+	assert.Equal(t, "edge (production)", prod.Name)
+	assert.Equal(t, "production", prod.Environment)
+	assert.Equal(t, staging.ID, prod.PromotedFrom)
+	assert.Equal(t, staging.ConfigHash, prod.ConfigHash, "content must be identical")
+
+	// Rename the counterpart, change staging and promote again
+	prod.Name = "edge-prod"
+	require.NoError(t, st.UpdateXrayConfig(ctx, prod))
+	staging.Log.Loglevel = strPtr("warning")
+	require.NoError(t, st.UpdateXrayConfig(ctx, staging))
+
+	res, err = PromoteXray(ctx, st, staging.ID, "production")
+	require.NoError(t, err)
+	assert.False(t, res.Created)
+
+	stored, err := st.GetXrayConfig(ctx, prod.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edge-prod", stored.Name, "target keeps its own name")
+	assert.Equal(t, "warning", *stored.Log.Loglevel)
+	assert.Equal(t, staging.ConfigHash, stored.ConfigHash)
+
+	_, err = PromoteXray(ctx, st, staging.ID, "staging")
+	assert.ErrorIs(t, err, ErrInvalidTarget)
+}
@@ -0,0 +1,252 @@
+// Package reverse sets up Xray reverse proxying, where a bridge inside a
+// private network dials out to a portal on a public server and the portal
+// sends client traffic back through that connection. The two halves live in
+// different configs that have to agree on a domain and tags.
+package reverse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+var (
+	// ErrInvalidPair is returned for incomplete requests and for tags that
+	// are missing from, or already taken in, the configs.
+	ErrInvalidPair = errors.New("invalid reverse pair request")
+	// ErrDomainInUse is returned when another bridge or portal already uses
+	// the requested domain.
+	ErrDomainInUse = errors.New("reverse domain already in use")
+)
+
+// DefaultLocalOutboundTag is the bridge-side outbound used when the request
+// does not name one.
+const DefaultLocalOutboundTag = "reverse-local"
+
+// pageSize is how many configs are requested at a time when checking
+// domains. The store may return fewer, so paging stops at the first empty
+// page.
+const pageSize = 100
+
+// PairRequest describes a bridge and portal to set up. The tunnel and client
+// tags must name existing inbounds and outbounds; the local outbound is
+// added as a freedom outbound if the bridge config has none with that tag.
+type PairRequest struct {
+	BridgeConfigID string `json:"bridge_config_id"`
+	PortalConfigID string `json:"portal_config_id"`
+	Domain         string `json:"domain" example:"reverse.internal.example"`
+	BridgeTag      string `json:"bridge_tag" example:"bridge"`
+	PortalTag      string `json:"portal_tag" example:"portal"`
+
+	TunnelOutboundTag string `json:"tunnel_outbound_tag" example:"to-portal"`  // Bridge side, dials the portal server
+	LocalOutboundTag  string `json:"local_outbound_tag,omitempty"`             // Bridge side, reaches private services
+	TunnelInboundTag  string `json:"tunnel_inbound_tag" example:"from-bridge"` // Portal side, accepts the bridge
+	ClientInboundTag  string `json:"client_inbound_tag" example:"external"`    // Portal side, accepts clients
+}
+
+// Summary lists, as JSON paths, what PairXray added to each config.
+type Summary struct {
+	BridgeConfigID string   `json:"bridge_config_id"`
+	PortalConfigID string   `json:"portal_config_id"`
+	BridgeAdded    []string `json:"bridge_added"`
+	PortalAdded    []string `json:"portal_added"`
+}
+
+// PairXray adds the bridge, its routing rules and local outbound to the
+// bridge config, and the portal and its routing rules to the portal config.
+// Both configs are saved in one transaction, so either both change or
+// neither does. The new rules go first so existing catch-all rules do not
+// shadow them.
+func PairXray(ctx context.Context, st store.Store, req PairRequest) (*Summary, error) {
+	if req.LocalOutboundTag == "" {
+		req.LocalOutboundTag = DefaultLocalOutboundTag
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	bridge, err := st.GetXrayConfig(ctx, req.BridgeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("get bridge config: %w", err)
+	}
+	portal, err := st.GetXrayConfig(ctx, req.PortalConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("get portal config: %w", err)
+	}
+	if err := checkTags(bridge, portal, req); err != nil {
+		return nil, err
+	}
+	if err := checkDomain(ctx, st, req.Domain); err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		BridgeConfigID: bridge.ID,
+		PortalConfigID: portal.ID,
+		BridgeAdded:    addBridge(bridge, req),
+		PortalAdded:    addPortal(portal, req),
+	}
+	if err := st.UpdateXrayConfigs(ctx, bridge, portal); err != nil {
+		return nil, fmt.Errorf("save reverse pair: %w", err)
+	}
+	return summary, nil
+}
+
+func (req PairRequest) validate() error {
+	for _, field := range []struct{ name, value string }{
+		{"bridge_config_id", req.BridgeConfigID},
+		{"portal_config_id", req.PortalConfigID},
+		{"domain", req.Domain},
+		{"bridge_tag", req.BridgeTag},
+		{"portal_tag", req.PortalTag},
+		{"tunnel_outbound_tag", req.TunnelOutboundTag},
+		{"tunnel_inbound_tag", req.TunnelInboundTag},
+		{"client_inbound_tag", req.ClientInboundTag},
+	} {
+		if strings.TrimSpace(field.value) == "" {
+			return fmt.Errorf("%w: %s is required", ErrInvalidPair, field.name)
+		}
+	}
+	if req.BridgeConfigID == req.PortalConfigID {
+		return fmt.Errorf("%w: bridge and portal must be different configs", ErrInvalidPair)
+	}
+	if strings.ContainsAny(req.Domain, " /:") {
+		return fmt.Errorf("%w: domain %q is not a bare domain name", ErrInvalidPair, req.Domain)
+	}
+	return nil
+}
+
+// checkTags verifies the referenced tags exist and the new ones are free.
+func checkTags(bridge, portal *models.XrayConfig, req PairRequest) error {
+	if !hasOutbound(bridge, req.TunnelOutboundTag) {
+		return fmt.Errorf("%w: bridge config has no outbound %q", ErrInvalidPair, req.TunnelOutboundTag)
+	}
+	if hasOutbound(bridge, req.BridgeTag) || hasInbound(bridge, req.BridgeTag) {
+		return fmt.Errorf("%w: tag %q is already used in the bridge config", ErrInvalidPair, req.BridgeTag)
+	}
+	for _, tag := range []string{req.TunnelInboundTag, req.ClientInboundTag} {
+		if !hasInbound(portal, tag) {
+			return fmt.Errorf("%w: portal config has no inbound %q", ErrInvalidPair, tag)
+		}
+	}
+	if hasOutbound(portal, req.PortalTag) || hasInbound(portal, req.PortalTag) {
+		return fmt.Errorf("%w: tag %q is already used in the portal config", ErrInvalidPair, req.PortalTag)
+	}
+	for _, config := range []*models.XrayConfig{bridge, portal} {
+		if config.Reverse == nil {
+			continue
+		}
+		for _, b := range config.Reverse.Bridges {
+			if b.Tag != nil && (*b.Tag == req.BridgeTag || *b.Tag == req.PortalTag) {
+				return fmt.Errorf("%w: reverse tag %q is already used in config %s", ErrInvalidPair, *b.Tag, config.ID)
+			}
+		}
+		for _, p := range config.Reverse.Portals {
+			if p.Tag != nil && (*p.Tag == req.BridgeTag || *p.Tag == req.PortalTag) {
+				return fmt.Errorf("%w: reverse tag %q is already used in config %s", ErrInvalidPair, *p.Tag, config.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDomain reports ErrDomainInUse if any stored config has a bridge or
+// portal for domain.
+func checkDomain(ctx context.Context, st store.Store, domain string) error {
+	for offset := 0; ; {
+		configs, err := st.ListXrayConfigsWithSection(ctx, "reverse", pageSize, offset, store.Sort{})
+		if err != nil {
+			return fmt.Errorf("list reverse configs: %w", err)
+		}
+		for _, c := range configs {
+			for _, b := range c.Reverse.Bridges {
+				if b.Domain != nil && strings.EqualFold(*b.Domain, domain) {
+					return fmt.Errorf("%w: %q is used by a bridge in config %s", ErrDomainInUse, domain, c.ID)
+				}
+			}
+			for _, p := range c.Reverse.Portals {
+				if p.Domain != nil && strings.EqualFold(*p.Domain, domain) {
+					return fmt.Errorf("%w: %q is used by a portal in config %s", ErrDomainInUse, domain, c.ID)
+				}
+			}
+		}
+		if len(configs) == 0 {
+			return nil
+		}
+		offset += len(configs)
+	}
+}
+
+// addBridge adds the bridge, a local freedom outbound if needed, and rules
+// sending the bridge's tunnel domain to the portal and everything else it
+// receives to the local outbound.
+func addBridge(config *models.XrayConfig, req PairRequest) []string {
+	var added []string
+	if config.Reverse == nil {
+		config.Reverse = &models.ReverseObject{}
+	}
+	config.Reverse.Bridges = append(config.Reverse.Bridges, models.Bridge{Tag: strPtr(req.BridgeTag), Domain: strPtr(req.Domain)})
+	added = append(added, fmt.Sprintf("reverse.bridges[%d]", len(config.Reverse.Bridges)-1))
+
+	if !hasOutbound(config, req.LocalOutboundTag) {
+		config.Outbounds = append(config.Outbounds, models.OutboundObject{Tag: strPtr(req.LocalOutboundTag), Protocol: strPtr("freedom")})
+		added = append(added, fmt.Sprintf("outbounds[%d]", len(config.Outbounds)-1))
+	}
+
+	return append(added, prependRules(config,
+		models.RoutingRule{Type: strPtr("field"), InboundTag: []string{req.BridgeTag}, Domain: []string{"full:" + req.Domain}, OutboundTag: strPtr(req.TunnelOutboundTag)},
+		models.RoutingRule{Type: strPtr("field"), InboundTag: []string{req.BridgeTag}, OutboundTag: strPtr(req.LocalOutboundTag)},
+	)...)
+}
+
+// addPortal adds the portal and rules sending client traffic and the
+// bridge's tunnel connections to it.
+func addPortal(config *models.XrayConfig, req PairRequest) []string {
+	if config.Reverse == nil {
+		config.Reverse = &models.ReverseObject{}
+	}
+	config.Reverse.Portals = append(config.Reverse.Portals, models.Portal{Tag: strPtr(req.PortalTag), Domain: strPtr(req.Domain)})
+	added := []string{fmt.Sprintf("reverse.portals[%d]", len(config.Reverse.Portals)-1)}
+
+	return append(added, prependRules(config,
+		models.RoutingRule{Type: strPtr("field"), InboundTag: []string{req.ClientInboundTag}, OutboundTag: strPtr(req.PortalTag)},
+		models.RoutingRule{Type: strPtr("field"), InboundTag: []string{req.TunnelInboundTag}, Domain: []string{"full:" + req.Domain}, OutboundTag: strPtr(req.PortalTag)},
+	)...)
+}
+
+// prependRules inserts rules at the start of the routing rules and returns
+// their paths.
+func prependRules(config *models.XrayConfig, rules ...models.RoutingRule) []string {
+	if config.Routing == nil {
+		config.Routing = &models.RoutingObject{}
+	}
+	config.Routing.Rules = append(rules, config.Routing.Rules...)
+	paths := make([]string, len(rules))
+	for i := range rules {
+		paths[i] = fmt.Sprintf("routing.rules[%d]", i)
+	}
+	return paths
+}
+
+func hasInbound(config *models.XrayConfig, tag string) bool {
+	for _, in := range config.Inbounds {
+		if in.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOutbound(config *models.XrayConfig, tag string) bool {
+	for _, out := range config.Outbounds {
+		if out.Tag != nil && *out.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func strPtr(s string) *string { return &s }
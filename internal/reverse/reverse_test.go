@@ -0,0 +1,147 @@
+package reverse
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+// createPair stores a bridge config with an outbound to the portal server
+// and a portal config with tunnel and client inbounds.
+func createPair(t *testing.T, st store.Store) (bridge, portal *models.XrayConfig) {
+	t.Helper()
+	ctx := context.Background()
+	bridge = &models.XrayConfig{
+		Name:      "office",
+		Outbounds: []models.OutboundObject{{Tag: strPtr("to-portal"), Protocol: strPtr("vless")}},
+		Routing:   &models.RoutingObject{Rules: []models.RoutingRule{{Type: strPtr("field"), OutboundTag: strPtr("to-portal")}}},
+	}
+	portal = &models.XrayConfig{
+		Name:     "edge",
+		Inbounds: []models.InboundObject{{Tag: "from-bridge", Protocol: "vless", Port: 443}, {Tag: "external", Protocol: "socks", Port: 1080}},
+	}
+	require.NoError(t, st.CreateXrayConfig(ctx, bridge))
+	require.NoError(t, st.CreateXrayConfig(ctx, portal))
+	return bridge, portal
+}
+
+func pairRequest(bridge, portal *models.XrayConfig) PairRequest {
+	return PairRequest{
+		BridgeConfigID:    bridge.ID,
+		PortalConfigID:    portal.ID,
+		Domain:            "reverse.internal.example",
+		BridgeTag:         "bridge",
+		PortalTag:         "portal",
+		TunnelOutboundTag: "to-portal",
+		TunnelInboundTag:  "from-bridge",
+		ClientInboundTag:  "external",
+	}
+}
+
+func TestPairXray(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	bridge, portal := createPair(t, st)
+
+	summary, err := PairXray(ctx, st, pairRequest(bridge, portal))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reverse.bridges[0]", "outbounds[1]", "routing.rules[0]", "routing.rules[1]"}, summary.BridgeAdded)
+	assert.Equal(t, []string{"reverse.portals[0]", "routing.rules[0]", "routing.rules[1]"}, summary.PortalAdded)
+
+	gotBridge, err := st.GetXrayConfig(ctx, bridge.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "reverse.internal.example", *gotBridge.Reverse.Bridges[0].Domain)
+	assert.Equal(t, "freedom", *gotBridge.Outbounds[1].Protocol)
+	require.Len(t, gotBridge.Routing.Rules, 3, "new rules go before the existing catch-all")
+	assert.Equal(t, []string{"full:reverse.internal.example"}, gotBridge.Routing.Rules[0].Domain)
+	assert.Equal(t, "to-portal", *gotBridge.Routing.Rules[0].OutboundTag)
+	assert.Equal(t, DefaultLocalOutboundTag, *gotBridge.Routing.Rules[1].OutboundTag)
+
+	gotPortal, err := st.GetXrayConfig(ctx, portal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "portal", *gotPortal.Reverse.Portals[0].Tag)
+	require.Len(t, gotPortal.Routing.Rules, 2)
+	assert.Equal(t, []string{"external"}, gotPortal.Routing.Rules[0].InboundTag)
+	assert.Equal(t, []string{"from-bridge"}, gotPortal.Routing.Rules[1].InboundTag)
+	assert.Equal(t, "portal", *gotPortal.Routing.Rules[1].OutboundTag)
+
+	// The domain is now taken
+	other := &models.XrayConfig{Name: "branch", Outbounds: []models.OutboundObject{{Tag: strPtr("to-portal"), Protocol: strPtr("vless")}}}
+	require.NoError(t, st.CreateXrayConfig(ctx, other))
+	req := pairRequest(other, portal)
+	req.BridgeTag, req.PortalTag = "bridge-2", "portal-2"
+	_, err = PairXray(ctx, st, req)
+	assert.ErrorIs(t, err, ErrDomainInUse)
+}
+
+func TestPairXray_DomainInUseBeyondFirstPage(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	st.SetPagination(store.Pagination{DefaultLimit: 1, MaxLimit: 1})
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, st.CreateXrayConfig(ctx, &models.XrayConfig{
+			Name:    name,
+			Reverse: &models.ReverseObject{Bridges: []models.Bridge{{Tag: strPtr("bridge-" + name), Domain: strPtr(name + ".internal.example")}}},
+		}))
+	}
+	bridge, portal := createPair(t, st)
+
+	// Whatever the list order, one of the two is on the second page
+	for _, domain := range []string{"a.internal.example", "b.internal.example"} {
+		req := pairRequest(bridge, portal)
+		req.Domain = domain
+		_, err := PairXray(ctx, st, req)
+		assert.ErrorIs(t, err, ErrDomainInUse, domain)
+	}
+}
+
+func TestPairXray_InvalidRequest(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	bridge, portal := createPair(t, st)
+
+	req := pairRequest(bridge, portal)
+	req.TunnelInboundTag = "missing"
+	_, err := PairXray(ctx, st, req)
+	assert.ErrorIs(t, err, ErrInvalidPair)
+
+	req = pairRequest(bridge, bridge)
+	_, err = PairXray(ctx, st, req)
+	assert.ErrorIs(t, err, ErrInvalidPair)
+}
+
+// deletingStore removes the portal config just before the pair is saved,
+// so the second of the two updates fails.
+type deletingStore struct {
+	*sqlite.SQLiteStore
+	portalID string
+}
+
+func (s *deletingStore) UpdateXrayConfigs(ctx context.Context, configs ...*models.XrayConfig) error {
+	if err := s.DeleteXrayConfig(ctx, s.portalID); err != nil {
+		return err
+	}
+	return s.SQLiteStore.UpdateXrayConfigs(ctx, configs...)
+}
+
+func TestPairXray_RollsBackWhenSecondUpdateFails(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	bridge, portal := createPair(t, st)
+
+	_, err := PairXray(ctx, &deletingStore{SQLiteStore: st, portalID: portal.ID}, pairRequest(bridge, portal))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := st.GetXrayConfig(ctx, bridge.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.Reverse, "bridge config must be left untouched")
+	assert.Len(t, got.Outbounds, 1)
+	assert.Len(t, got.Routing.Rules, 1)
+}
@@ -0,0 +1,155 @@
+// Package schema describes the config document of each supported service
+// type as JSON Schema, so the UI can render editing forms.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// Draft is the JSON Schema dialect of every generated document.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// ErrUnknownType is returned for a service type without a schema.
+var ErrUnknownType = errors.New("unknown service type")
+
+// Schema is a JSON Schema document.
+type Schema = map[string]interface{}
+
+// typeModels maps each service type to the model its schema is derived from.
+var typeModels = map[string]reflect.Type{
+	"xray":    reflect.TypeOf(models.XrayConfig{}),
+	"singbox": reflect.TypeOf(models.SingBoxConfig{}),
+}
+
+// Types lists the service types with a schema, sorted.
+func Types() []string {
+	types := make([]string, 0, len(typeModels))
+	for t := range typeModels {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// For returns the JSON Schema of serviceType's config document. Every named
+// struct becomes an entry in $defs referenced with $ref, which also covers
+// recursive types such as logical routing rules.
+func For(serviceType string) (Schema, error) {
+	typ, ok := typeModels[serviceType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, serviceType)
+	}
+	g := generator{defs: Schema{}}
+	root := g.structSchema(typ)
+	root["$schema"] = Draft
+	root["title"] = serviceType
+	root["$defs"] = g.defs
+	return root, nil
+}
+
+type generator struct {
+	defs Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// schemaFor returns the schema of a field or element of type t.
+func (g *generator) schemaFor(t reflect.Type) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, done := g.defs[t.Name()]; !done {
+			g.defs[t.Name()] = Schema{} // Placeholder, so recursion stops here
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return Schema{"$ref": "#/$defs/" + t.Name()}
+	default:
+		return Schema{} // interface{} and anything else accepts any value
+	}
+}
+
+// structSchema describes the JSON object t marshals to. Fields tagged
+// without omitempty are required. Untagged embedded structs are flattened
+// into t as encoding/json does, with t's own fields taking precedence.
+func (g *generator) structSchema(t reflect.Type) Schema {
+	properties := Schema{}
+	var required []string
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			embedded = append(embedded, field)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		prop := g.schemaFor(field.Type)
+		if example := field.Tag.Get("example"); example != "" {
+			prop["examples"] = []string{example}
+		}
+		properties[name] = prop
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+	for _, field := range embedded {
+		inner := g.structSchema(indirect(field.Type))
+		for name, prop := range inner["properties"].(Schema) {
+			if _, taken := properties[name]; !taken {
+				properties[name] = prop
+			}
+		}
+		// A nil embedded pointer marshals none of its fields
+		if innerRequired, ok := inner["required"].([]string); ok && field.Type.Kind() != reflect.Ptr {
+			required = append(required, innerRequired...)
+		}
+	}
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFor_Xray(t *testing.T) {
+	s, err := For("xray")
+	require.NoError(t, err)
+	assert.Equal(t, Draft, s["$schema"])
+
+	properties := s["properties"].(Schema)
+	require.Contains(t, properties, "inbounds")
+	inbounds := properties["inbounds"].(Schema)
+	assert.Equal(t, "array", inbounds["type"])
+	assert.Equal(t, Schema{"$ref": "#/$defs/InboundObject"}, inbounds["items"])
+
+	inbound := s["$defs"].(Schema)["InboundObject"].(Schema)
+	assert.Contains(t, inbound["properties"], "streamSettings")
+	assert.Contains(t, inbound["required"], "protocol")
+
+	_, err = json.Marshal(s)
+	assert.NoError(t, err, "the schema must be serialisable")
+}
+
+func TestFor_SingBoxRecursiveRules(t *testing.T) {
+	s, err := For("singbox")
+	require.NoError(t, err)
+	defs := s["$defs"].(Schema)
+	rule, ok := defs["SingBoxRouteRule"].(Schema)
+	require.True(t, ok)
+	assert.NotEmpty(t, rule["properties"])
+}
+
+func TestFor_SingBoxEmbeddedDialFields(t *testing.T) {
+	s, err := For("singbox")
+	require.NoError(t, err)
+	server := s["$defs"].(Schema)["SingBoxDNSServer"].(Schema)
+	properties := server["properties"].(Schema)
+	assert.Contains(t, properties, "detour")
+	assert.Contains(t, properties, "bind_interface")
+	assert.Contains(t, properties, "server")
+	assert.NotContains(t, properties, "SingBoxDialFields")
+	required, _ := server["required"].([]string)
+	assert.NotContains(t, required, "SingBoxDialFields")
+}
+
+func TestFor_UnknownType(t *testing.T) {
+	_, err := For("haproxy")
+	assert.ErrorIs(t, err, ErrUnknownType)
+	assert.Equal(t, []string{"singbox", "xray"}, Types())
+}
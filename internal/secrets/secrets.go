@@ -0,0 +1,330 @@
+// Package secrets encrypts sensitive config values at rest. Each value gets
+// its own random data key, which is wrapped with the server master key
+// (envelope encryption), so rotating the master key only re-wraps data keys.
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables holding base64-encoded 32-byte master keys.
+// EnvPreviousMasterKeys is a comma-separated list of retired keys that are
+// still accepted for decryption until RotateDataKeys has run.
+const (
+	EnvMasterKey          = "DATA_ENCRYPTION_KEY"
+	EnvPreviousMasterKeys = "DATA_ENCRYPTION_PREVIOUS_KEYS"
+)
+
+// Prefix marks an encrypted value. Values without it are legacy plaintext
+// and are returned unchanged on read.
+const Prefix = "enc:v1:"
+
+// SensitiveKeys are the JSON object keys whose string values are encrypted:
+// REALITY and WireGuard private keys, WireGuard pre-shared keys and
+// protocol passwords, in both Xray (camelCase) and sing-box (snake_case)
+// spelling.
+var SensitiveKeys = []string{"privateKey", "private_key", "secretKey", "preSharedKey", "pre_shared_key", "password"}
+
+// ErrUnknownKey is returned when a value was encrypted with a master key the
+// Sealer does not hold.
+var ErrUnknownKey = errors.New("value encrypted with unknown master key")
+
+// Sealer encrypts and decrypts sensitive values with a current master key
+// and, for reading only, any number of previous ones.
+type Sealer struct {
+	current *masterKey
+	keys    map[string]*masterKey
+}
+
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewSealer returns a Sealer encrypting with current. Values encrypted with
+// one of previous can still be decrypted.
+func NewSealer(current []byte, previous ...[]byte) (*Sealer, error) {
+	s := &Sealer{keys: make(map[string]*masterKey)}
+	for i, raw := range append([][]byte{current}, previous...) {
+		key, err := newMasterKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			s.current = key
+		}
+		s.keys[key.id] = key
+	}
+	return s, nil
+}
+
+// NewSealerFromEnv builds a Sealer from EnvMasterKey and
+// EnvPreviousMasterKeys. It returns nil without error when no master key is
+// configured, leaving values unencrypted.
+func NewSealerFromEnv() (*Sealer, error) {
+	encoded := os.Getenv(EnvMasterKey)
+	if encoded == "" {
+		return nil, nil
+	}
+	current, err := ParseKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", EnvMasterKey, err)
+	}
+	var previous [][]byte
+	for _, encoded := range strings.Split(os.Getenv(EnvPreviousMasterKeys), ",") {
+		if encoded = strings.TrimSpace(encoded); encoded == "" {
+			continue
+		}
+		key, err := ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvPreviousMasterKeys, err)
+		}
+		previous = append(previous, key)
+	}
+	return NewSealer(current, previous...)
+}
+
+// ParseKey decodes a base64 master key, which must be 32 bytes long.
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+func newMasterKey(raw []byte) (*masterKey, error) {
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return &masterKey{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyID identifies the current master key inside encrypted values.
+func (s *Sealer) KeyID() string {
+	return s.current.id
+}
+
+// Encrypt encrypts plaintext under a fresh data key. The result has the form
+// Prefix + "<key id>:<wrapped data key>:<ciphertext>".
+func (s *Sealer) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := seal(s.current.aead, dataKey)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return Prefix + s.current.id + ":" + enc.EncodeToString(wrapped) + ":" + enc.EncodeToString(ciphertext), nil
+}
+
+// Decrypt reverses Encrypt. Values without Prefix are returned unchanged.
+func (s *Sealer) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	key, ok := s.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, parts[0])
+	}
+	enc := base64.RawStdEncoding
+	wrapped, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed data key: %w", err)
+	}
+	ciphertext, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+	dataKey, err := open(key.aead, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// seal returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed value too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// SealDocument encrypts every plaintext string stored under one of
+// SensitiveKeys anywhere in the JSON document doc.
+func (s *Sealer) SealDocument(doc []byte) ([]byte, error) {
+	out, _, err := s.transformDocument(doc, false, func(v string) (string, bool, error) {
+		if strings.HasPrefix(v, Prefix) {
+			return v, false, nil
+		}
+		enc, err := s.Encrypt(v)
+		return enc, true, err
+	})
+	return out, err
+}
+
+// OpenDocument decrypts every encrypted string in the JSON document doc,
+// wherever it sits, so values stay readable if SensitiveKeys shrinks.
+func (s *Sealer) OpenDocument(doc []byte) ([]byte, error) {
+	if !bytes.Contains(doc, []byte(Prefix)) {
+		return doc, nil
+	}
+	out, _, err := s.transformDocument(doc, true, func(v string) (string, bool, error) {
+		if !strings.HasPrefix(v, Prefix) {
+			return v, false, nil
+		}
+		plain, err := s.Decrypt(v)
+		return plain, true, err
+	})
+	return out, err
+}
+
+// ResealDocument encrypts sensitive plaintext and re-encrypts values sealed
+// with a previous master key. changed reports whether doc needs rewriting.
+func (s *Sealer) ResealDocument(doc []byte) (out []byte, changed bool, err error) {
+	current := Prefix + s.current.id + ":"
+	return s.transformDocument(doc, false, func(v string) (string, bool, error) {
+		if strings.HasPrefix(v, current) {
+			return v, false, nil
+		}
+		plain, err := s.Decrypt(v)
+		if err != nil {
+			return "", false, err
+		}
+		enc, err := s.Encrypt(plain)
+		return enc, true, err
+	})
+}
+
+// transformDocument applies fn to the string values under SensitiveKeys, or
+// to every string when everywhere is set, and re-encodes doc when fn changed
+// any of them.
+func (s *Sealer) transformDocument(doc []byte, everywhere bool, fn func(string) (string, bool, error)) ([]byte, bool, error) {
+	var tree interface{}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber() // Keep numbers exactly as they were written
+	if err := dec.Decode(&tree); err != nil {
+		return nil, false, fmt.Errorf("failed to decode document: %w", err)
+	}
+	changed, err := walk(tree, everywhere, everywhere, fn)
+	if err != nil || !changed {
+		return doc, false, err
+	}
+	out, err := json.Marshal(tree)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return out, true, nil
+}
+
+// walk visits node, calling fn on strings that sit directly under a
+// sensitive key (or on all strings when everywhere is set).
+func walk(node interface{}, sensitive, everywhere bool, fn func(string) (string, bool, error)) (bool, error) {
+	changed := false
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, child := range n {
+			if str, ok := child.(string); ok {
+				if !everywhere && !isSensitive(key) {
+					continue
+				}
+				out, c, err := fn(str)
+				if err != nil {
+					return false, fmt.Errorf("%s: %w", key, err)
+				}
+				n[key] = out
+				changed = changed || c
+				continue
+			}
+			c, err := walk(child, everywhere || isSensitive(key), everywhere, fn)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	case []interface{}:
+		for i, child := range n {
+			// Some protocols accept a list of passwords
+			if str, ok := child.(string); ok && sensitive {
+				out, c, err := fn(str)
+				if err != nil {
+					return false, err
+				}
+				n[i] = out
+				changed = changed || c
+				continue
+			}
+			c, err := walk(child, everywhere, everywhere, fn)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	}
+	return changed, nil
+}
+
+func isSensitive(key string) bool {
+	for _, k := range SensitiveKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
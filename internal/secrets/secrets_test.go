@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+
+func TestEncryptDecrypt(t *testing.T) {
+	s, err := NewSealer(testKey(1))
+	require.NoError(t, err)
+
+	enc, err := s.Encrypt("hunter2")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enc, Prefix+s.KeyID()+":"))
+	assert.NotContains(t, enc, "hunter2")
+
+	again, err := s.Encrypt("hunter2")
+	require.NoError(t, err)
+	assert.NotEqual(t, enc, again, "every value gets a fresh data key and nonce")
+
+	plain, err := s.Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plain)
+
+	plain, err = s.Decrypt("legacy-plaintext")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-plaintext", plain)
+
+	other, err := NewSealer(testKey(2))
+	require.NoError(t, err)
+	_, err = other.Decrypt(enc)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestDocuments(t *testing.T) {
+	s, err := NewSealer(testKey(1))
+	require.NoError(t, err)
+	doc := []byte(`[{"tag":"in","port":443,"settings":{"clients":[{"password":"p1"}]},` +
+		`"streamSettings":{"realitySettings":{"privateKey":"pk","shortIds":["ab"]}}}]`)
+
+	sealed, err := s.SealDocument(doc)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), `"p1"`)
+	assert.NotContains(t, string(sealed), `"pk"`)
+	assert.Contains(t, string(sealed), `"shortIds":["ab"]`, "other values stay readable")
+	assert.Contains(t, string(sealed), `"port":443`)
+
+	resealed, err := s.SealDocument(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, sealed, resealed, "sealing twice does not double-encrypt")
+
+	opened, err := s.OpenDocument(sealed)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(doc), string(opened))
+
+	opened, err = s.OpenDocument(doc)
+	require.NoError(t, err)
+	assert.Equal(t, doc, opened, "plaintext documents load unchanged")
+}
+
+func TestResealDocument_Rotation(t *testing.T) {
+	old, err := NewSealer(testKey(1))
+	require.NoError(t, err)
+	sealed, err := old.SealDocument([]byte(`{"private_key":"wg","password":["a","b"]}`))
+	require.NoError(t, err)
+
+	rotated, err := NewSealer(testKey(2), testKey(1))
+	require.NoError(t, err)
+	out, changed, err := rotated.ResealDocument(sealed)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotContains(t, string(out), old.KeyID())
+
+	_, changed, err = rotated.ResealDocument(out)
+	require.NoError(t, err)
+	assert.False(t, changed, "values under the current key are left alone")
+
+	current, err := NewSealer(testKey(2))
+	require.NoError(t, err)
+	opened, err := current.OpenDocument(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"private_key":"wg","password":["a","b"]}`, string(opened))
+}
+
+func TestNewSealerFromEnv(t *testing.T) {
+	t.Setenv(EnvMasterKey, "")
+	s, err := NewSealerFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	t.Setenv(EnvMasterKey, base64.StdEncoding.EncodeToString(testKey(1)))
+	t.Setenv(EnvPreviousMasterKeys, base64.StdEncoding.EncodeToString(testKey(2)))
+	s, err = NewSealerFromEnv()
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	assert.Len(t, s.keys, 2)
+
+	t.Setenv(EnvMasterKey, base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = NewSealerFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package shareuri
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+var (
+	// ErrOutboundNotFound is returned by BuildProxyURIForTag when no
+	// outbound has the requested tag.
+	ErrOutboundNotFound = errors.New("outbound not found")
+	// ErrNotEncodable is returned for outbounds that have no share link
+	// form, such as freedom, or that lack a server address or credentials.
+	ErrNotEncodable = errors.New("outbound cannot be encoded as a share link")
+)
+
+// BuildProxyURIForTag builds the share link of the outbound tagged tag.
+func BuildProxyURIForTag(config *models.XrayConfig, tag string) (string, error) {
+	for i := range config.Outbounds {
+		if out := &config.Outbounds[i]; out.Tag != nil && *out.Tag == tag {
+			return BuildProxyURI(out)
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrOutboundNotFound, tag)
+}
+
+// BuildProxyURI is the inverse of ParseProxyURI: it encodes a vless, vmess,
+// trojan or shadowsocks outbound and its stream settings as a share link,
+// using the outbound tag as the link name.
+func BuildProxyURI(o *models.OutboundObject) (string, error) {
+	l, err := linkFromXray(o)
+	if err != nil {
+		return "", err
+	}
+	switch l.protocol {
+	case "vmess":
+		return l.vmessURI()
+	case "shadowsocks":
+		userinfo := base64.RawURLEncoding.EncodeToString([]byte(l.method + ":" + l.id))
+		u := url.URL{Scheme: "ss", User: url.User(userinfo), Host: net.JoinHostPort(l.address, strconv.Itoa(l.port)), Fragment: l.name}
+		return u.String(), nil
+	default:
+		u := url.URL{
+			Scheme:   l.protocol,
+			User:     url.User(l.id),
+			Host:     net.JoinHostPort(l.address, strconv.Itoa(l.port)),
+			RawQuery: l.query().Encode(),
+			Fragment: l.name,
+		}
+		return u.String(), nil
+	}
+}
+
+// linkFromXray reads the first server of o and its stream settings.
+func linkFromXray(o *models.OutboundObject) (*link, error) {
+	if o.Protocol == nil {
+		return nil, fmt.Errorf("%w: no protocol", ErrNotEncodable)
+	}
+	l := &link{protocol: *o.Protocol}
+	if o.Tag != nil {
+		l.name = *o.Tag
+	}
+
+	var server map[string]interface{}
+	switch l.protocol {
+	case "vless", "vmess":
+		server = first(o.Settings["vnext"])
+		user := first(server["users"])
+		l.id, _ = user["id"].(string)
+		l.flow, _ = user["flow"].(string)
+		l.cipher, _ = user["security"].(string)
+		l.alterID, _ = intValue(user["alterId"])
+	case "trojan", "shadowsocks":
+		server = first(o.Settings["servers"])
+		l.id, _ = server["password"].(string)
+		l.method, _ = server["method"].(string)
+	default:
+		return nil, fmt.Errorf("%w: protocol %q", ErrNotEncodable, l.protocol)
+	}
+	l.address, _ = server["address"].(string)
+	port, ok := intValue(server["port"])
+	if !ok || l.address == "" || l.id == "" || (l.protocol == "shadowsocks" && l.method == "") {
+		return nil, fmt.Errorf("%w: missing address, port or credentials", ErrNotEncodable)
+	}
+	l.port = port
+
+	stream := o.StreamSettings
+	if stream == nil {
+		return l, nil
+	}
+	if stream.Network != nil {
+		l.network = *stream.Network
+	}
+	switch {
+	case stream.WSSettings != nil:
+		if stream.WSSettings.Path != nil {
+			l.path = *stream.WSSettings.Path
+		}
+		l.host = stream.WSSettings.Headers["Host"]
+	case stream.GRPCSettings != nil && stream.GRPCSettings.ServiceName != nil:
+		l.service = *stream.GRPCSettings.ServiceName
+	case stream.HTTPSettings != nil:
+		if stream.HTTPSettings.Path != nil {
+			l.path = *stream.HTTPSettings.Path
+		}
+		if len(stream.HTTPSettings.Host) > 0 {
+			l.host = stream.HTTPSettings.Host[0]
+		}
+	}
+	if stream.Security != nil {
+		l.security = *stream.Security
+	}
+	if tls := stream.TLSSettings; tls != nil {
+		if tls.ServerName != nil {
+			l.sni = *tls.ServerName
+		}
+		if tls.Fingerprint != nil {
+			l.fingerprint = *tls.Fingerprint
+		}
+		l.alpn = tls.ALPN
+		l.allowInsecure = tls.AllowInsecure != nil && *tls.AllowInsecure
+		if r := tls.RealitySettings; r != nil {
+			if r.PublicKey != nil {
+				l.publicKey = *r.PublicKey
+			}
+			if r.ShortId != nil {
+				l.shortID = *r.ShortId
+			}
+			if r.SpiderX != nil {
+				l.spiderX = *r.SpiderX
+			}
+		}
+	}
+	return l, nil
+}
+
+// query returns the URI parameters of a vless or trojan link.
+func (l *link) query() url.Values {
+	q := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	if l.protocol == "vless" {
+		q.Set("encryption", "none")
+	}
+	set("flow", l.flow)
+	set("type", l.network)
+	set("security", l.security)
+	set("path", l.path)
+	set("host", l.host)
+	set("serviceName", l.service)
+	set("sni", l.sni)
+	set("fp", l.fingerprint)
+	set("alpn", strings.Join(l.alpn, ","))
+	set("pbk", l.publicKey)
+	set("sid", l.shortID)
+	set("spx", l.spiderX)
+	if l.allowInsecure {
+		q.Set("allowInsecure", "1")
+	}
+	return q
+}
+
+// vmessURI encodes l as the base64 JSON payload of a vmess:// link.
+func (l *link) vmessURI() (string, error) {
+	p := vmessPayload{
+		Version: "2", Name: l.name, Address: l.address, Port: json.Number(strconv.Itoa(l.port)),
+		ID: l.id, AlterID: json.Number(strconv.Itoa(l.alterID)), Cipher: l.cipher,
+		Network: l.network, Host: l.host, Path: l.path, TLS: l.security, SNI: l.sni,
+		ALPN: strings.Join(l.alpn, ","), Fingerprint: l.fingerprint,
+	}
+	if p.Network == "" {
+		p.Network = "tcp"
+	}
+	if p.TLS == "none" {
+		p.TLS = ""
+	}
+	if l.network == "grpc" {
+		p.Path = l.service // vmess links carry the gRPC service name in path
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("marshal vmess payload: %w", err)
+	}
+	return "vmess://" + base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// first returns the first element of a JSON array of objects, or nil.
+func first(v interface{}) map[string]interface{} {
+	if list, ok := v.([]interface{}); ok && len(list) > 0 {
+		m, _ := list[0].(map[string]interface{})
+		return m
+	}
+	return nil
+}
+
+// intValue reads a JSON number that may have been decoded as float64 or
+// built in code as int.
+func intValue(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
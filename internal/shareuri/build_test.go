@@ -0,0 +1,65 @@
+package shareuri
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestBuildProxyURI_RoundTrip(t *testing.T) {
+	vmess := base64.StdEncoding.EncodeToString([]byte(`{"v":"2","ps":"hk-ws","add":"hk.example.com","port":8443,` +
+		`"id":"5f3c1d7e-0000-4000-8000-000000000002","aid":0,"scy":"auto","net":"ws","host":"cdn.example.com","path":"/ray","tls":"tls","sni":"cdn.example.com"}`))
+
+	for name, uri := range map[string]string{
+		"vless reality": "vless://5f3c1d7e-0000-4000-8000-000000000001@example.com:443?encryption=none&flow=xtls-rprx-vision" +
+			"&fp=chrome&pbk=QmFzZTY0UHVibGljS2V5&security=reality&sid=6ba85179&sni=www.microsoft.com&type=tcp#Tokyo%201",
+		"vless ws tls": "vless://5f3c1d7e-0000-4000-8000-000000000001@[2001:db8::1]:443?alpn=h2%2Chttp%2F1.1&encryption=none&host=cdn.example.com&path=%2Fws&security=tls&type=ws#v6",
+		"trojan grpc":  "trojan://pa55@trojan.example.com:443?security=tls&serviceName=tun&sni=trojan.example.com&type=grpc#tj",
+		"shadowsocks":  "ss://" + base64.RawURLEncoding.EncodeToString([]byte("2022-blake3-aes-128-gcm:c2VjcmV0")) + "@198.51.100.7:8388#ss-node",
+		"vmess ws tls": "vmess://" + vmess,
+	} {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseProxyURI(uri)
+			require.NoError(t, err)
+
+			// Outbounds read back from the store carry float64 ports
+			raw, err := json.Marshal(parsed)
+			require.NoError(t, err)
+			var stored models.OutboundObject
+			require.NoError(t, json.Unmarshal(raw, &stored))
+
+			built, err := BuildProxyURI(&stored)
+			require.NoError(t, err)
+			if name != "vmess ws tls" {
+				assert.Equal(t, uri, built)
+			}
+			reparsed, err := ParseProxyURI(built)
+			require.NoError(t, err)
+			assert.Equal(t, parsed, reparsed)
+		})
+	}
+}
+
+func TestBuildProxyURIForTag(t *testing.T) {
+	vless, err := ParseProxyURI("vless://5f3c1d7e-0000-4000-8000-000000000001@example.com:443?type=tcp#proxy")
+	require.NoError(t, err)
+	freedom := "freedom"
+	config := &models.XrayConfig{Outbounds: []models.OutboundObject{
+		{Tag: &freedom, Protocol: &freedom},
+		*vless,
+	}}
+
+	uri, err := BuildProxyURIForTag(config, "proxy")
+	require.NoError(t, err)
+	assert.Equal(t, "vless://5f3c1d7e-0000-4000-8000-000000000001@example.com:443?encryption=none&type=tcp#proxy", uri)
+
+	_, err = BuildProxyURIForTag(config, "freedom")
+	assert.ErrorIs(t, err, ErrNotEncodable)
+
+	_, err = BuildProxyURIForTag(config, "missing")
+	assert.ErrorIs(t, err, ErrOutboundNotFound)
+}
@@ -0,0 +1,171 @@
+package shareuri
+
+import (
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// xrayOutbound converts l into an Xray outbound.
+func (l *link) xrayOutbound() *models.OutboundObject {
+	protocol := l.protocol
+	out := &models.OutboundObject{Protocol: &protocol}
+	if l.name != "" {
+		out.Tag = stringPtr(l.name)
+	}
+
+	switch l.protocol {
+	case "vless":
+		user := map[string]interface{}{"id": l.id, "encryption": "none"}
+		if l.flow != "" {
+			user["flow"] = l.flow
+		}
+		out.Settings = vnext(l, user)
+	case "vmess":
+		out.Settings = vnext(l, map[string]interface{}{"id": l.id, "alterId": l.alterID, "security": l.cipher})
+	case "trojan":
+		out.Settings = map[string]interface{}{"servers": []interface{}{
+			map[string]interface{}{"address": l.address, "port": l.port, "password": l.id},
+		}}
+	case "shadowsocks":
+		out.Settings = map[string]interface{}{"servers": []interface{}{
+			map[string]interface{}{"address": l.address, "port": l.port, "method": l.method, "password": l.id},
+		}}
+		return out // No transport options in shadowsocks links
+	}
+
+	stream := &models.StreamSettingsObject{}
+	if l.network != "" {
+		stream.Network = stringPtr(l.network)
+	}
+	switch l.network {
+	case "ws":
+		ws := &models.WSSettings{}
+		if l.path != "" {
+			ws.Path = stringPtr(l.path)
+		}
+		if l.host != "" {
+			ws.Headers = map[string]string{"Host": l.host}
+		}
+		stream.WSSettings = ws
+	case "grpc":
+		stream.GRPCSettings = &models.GRPCSettings{}
+		if l.service != "" {
+			stream.GRPCSettings.ServiceName = stringPtr(l.service)
+		}
+	case "http", "h2":
+		stream.Network = stringPtr("http")
+		h2 := &models.HTTP2Settings{}
+		if l.host != "" {
+			h2.Host = []string{l.host}
+		}
+		if l.path != "" {
+			h2.Path = stringPtr(l.path)
+		}
+		stream.HTTPSettings = h2
+	}
+
+	if l.security == "tls" || l.security == "reality" {
+		stream.Security = stringPtr(l.security)
+		tls := &models.TLSSettings{ALPN: l.alpn}
+		if l.sni != "" {
+			tls.ServerName = stringPtr(l.sni)
+		}
+		if l.fingerprint != "" {
+			tls.Fingerprint = stringPtr(l.fingerprint)
+		}
+		if l.allowInsecure {
+			tls.AllowInsecure = boolPtr(true)
+		}
+		if l.security == "reality" {
+			reality := &models.RealitySettingsObject{PublicKey: stringPtr(l.publicKey)}
+			if l.shortID != "" {
+				reality.ShortId = stringPtr(l.shortID)
+			}
+			if l.spiderX != "" {
+				reality.SpiderX = stringPtr(l.spiderX)
+			}
+			tls.RealitySettings = reality
+		}
+		stream.TLSSettings = tls
+	}
+	if stream.Network != nil || stream.Security != nil {
+		out.StreamSettings = stream
+	}
+	return out
+}
+
+func vnext(l *link, user map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"vnext": []interface{}{
+		map[string]interface{}{"address": l.address, "port": l.port, "users": []interface{}{user}},
+	}}
+}
+
+// singBoxOutbound converts l into a sing-box outbound.
+func (l *link) singBoxOutbound() *models.SingBoxOutbound {
+	out := &models.SingBoxOutbound{Type: l.protocol, Tag: l.name}
+	settings := map[string]interface{}{"server": l.address, "server_port": l.port}
+	switch l.protocol {
+	case "vless":
+		settings["uuid"] = l.id
+		if l.flow != "" {
+			settings["flow"] = l.flow
+		}
+	case "vmess":
+		settings["uuid"] = l.id
+		settings["alter_id"] = l.alterID
+		settings["security"] = l.cipher
+	case "trojan":
+		settings["password"] = l.id
+	case "shadowsocks":
+		settings["method"] = l.method
+		settings["password"] = l.id
+	}
+	out.Settings = settings
+
+	switch l.network {
+	case "ws":
+		transport := map[string]interface{}{"type": "ws"}
+		if l.path != "" {
+			transport["path"] = l.path
+		}
+		if l.host != "" {
+			transport["headers"] = map[string]interface{}{"Host": l.host}
+		}
+		out.Transport = transport
+	case "grpc":
+		out.Transport = map[string]interface{}{"type": "grpc", "service_name": l.service}
+	case "http", "h2":
+		transport := map[string]interface{}{"type": "http"}
+		if l.host != "" {
+			transport["host"] = []interface{}{l.host}
+		}
+		if l.path != "" {
+			transport["path"] = l.path
+		}
+		out.Transport = transport
+	}
+
+	if l.security == "tls" || l.security == "reality" {
+		tls := map[string]interface{}{"enabled": true}
+		if l.sni != "" {
+			tls["server_name"] = l.sni
+		}
+		if l.allowInsecure {
+			tls["insecure"] = true
+		}
+		if len(l.alpn) > 0 {
+			tls["alpn"] = l.alpn
+		}
+		if l.fingerprint != "" {
+			tls["utls"] = map[string]interface{}{"enabled": true, "fingerprint": l.fingerprint}
+		}
+		if l.security == "reality" {
+			tls["reality"] = map[string]interface{}{"enabled": true, "public_key": l.publicKey, "short_id": l.shortID}
+		}
+		out.TLS = tls
+	}
+	return out
+}
+
+func stringPtr(s string) *string { return &s }
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,255 @@
+// Package shareuri parses proxy share links (vless://, vmess://, trojan://
+// and ss://) into Xray and sing-box outbounds.
+package shareuri
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+var (
+	// ErrUnsupportedScheme is returned for links other than vless, vmess,
+	// trojan and ss.
+	ErrUnsupportedScheme = errors.New("unsupported share link scheme")
+	// ErrInvalidURI is returned for a link of a supported scheme that cannot
+	// be parsed.
+	ErrInvalidURI = errors.New("invalid share link")
+)
+
+// link is the protocol-neutral content of a share link.
+type link struct {
+	protocol string // "vless", "vmess", "trojan" or "shadowsocks"
+	name     string
+	address  string
+	port     int
+
+	id       string // UUID for vless/vmess, password for trojan/shadowsocks
+	method   string // Shadowsocks cipher
+	flow     string // VLESS flow, e.g. "xtls-rprx-vision"
+	alterID  int    // VMess
+	cipher   string // VMess security, e.g. "auto"
+	network  string // "tcp", "ws", "grpc" or "http"
+	path     string
+	host     string
+	service  string // gRPC service name
+	security string // "none", "tls" or "reality"
+
+	sni           string
+	fingerprint   string
+	alpn          []string
+	allowInsecure bool
+	publicKey     string
+	shortID       string
+	spiderX       string
+}
+
+// ParseProxyURI parses a share link into an Xray outbound tagged with the
+// link's #name, if any.
+func ParseProxyURI(uri string) (*models.OutboundObject, error) {
+	l, err := parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	return l.xrayOutbound(), nil
+}
+
+// ParseProxyURISingBox is the sing-box counterpart of ParseProxyURI.
+func ParseProxyURISingBox(uri string) (*models.SingBoxOutbound, error) {
+	l, err := parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	return l.singBoxOutbound(), nil
+}
+
+func parse(uri string) (*link, error) {
+	uri = strings.TrimSpace(uri)
+	scheme, _, found := strings.Cut(uri, "://")
+	if !found {
+		return nil, fmt.Errorf("%w: missing scheme", ErrInvalidURI)
+	}
+	switch strings.ToLower(scheme) {
+	case "vless":
+		return parseStandard(uri, "vless")
+	case "trojan":
+		return parseStandard(uri, "trojan")
+	case "vmess":
+		return parseVMess(uri)
+	case "ss":
+		return parseShadowsocks(uri)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, scheme)
+	}
+}
+
+// parseStandard parses the common scheme://secret@host:port?params#name form
+// used by VLESS and Trojan links.
+func parseStandard(uri, protocol string) (*link, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURI, err)
+	}
+	l := &link{protocol: protocol, name: u.Fragment}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("%w: missing credentials", ErrInvalidURI)
+	}
+	l.id = u.User.Username()
+	if l.address, l.port, err = hostPort(u.Host); err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	l.flow = q.Get("flow")
+	l.network = q.Get("type")
+	l.path = q.Get("path")
+	l.host = q.Get("host")
+	l.service = q.Get("serviceName")
+	l.security = q.Get("security")
+	if l.security == "" && protocol == "trojan" {
+		l.security = "tls" // Trojan always runs over TLS
+	}
+	l.sni = q.Get("sni")
+	l.fingerprint = q.Get("fp")
+	if alpn := q.Get("alpn"); alpn != "" {
+		l.alpn = strings.Split(alpn, ",")
+	}
+	l.allowInsecure = q.Get("allowInsecure") == "1" || q.Get("allowInsecure") == "true"
+	l.publicKey = q.Get("pbk")
+	l.shortID = q.Get("sid")
+	l.spiderX = q.Get("spx")
+	if l.security == "reality" && l.publicKey == "" {
+		return nil, fmt.Errorf("%w: reality link without pbk", ErrInvalidURI)
+	}
+	return l, nil
+}
+
+// vmessPayload is the base64-encoded JSON body of a vmess:// link. Numbers
+// are written as strings by some clients, hence json.Number.
+type vmessPayload struct {
+	Version     string      `json:"v"`
+	Name        string      `json:"ps"`
+	Address     string      `json:"add"`
+	Port        json.Number `json:"port"`
+	ID          string      `json:"id"`
+	AlterID     json.Number `json:"aid"`
+	Cipher      string      `json:"scy"`
+	Network     string      `json:"net"`
+	Host        string      `json:"host"`
+	Path        string      `json:"path"`
+	TLS         string      `json:"tls"`
+	SNI         string      `json:"sni"`
+	ALPN        string      `json:"alpn"`
+	Fingerprint string      `json:"fp"`
+}
+
+func parseVMess(uri string) (*link, error) {
+	body, fragment, _ := strings.Cut(uri[len("vmess://"):], "#")
+	raw, err := decodeBase64(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: vmess payload is not base64", ErrInvalidURI)
+	}
+	var p vmessPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("%w: vmess payload: %v", ErrInvalidURI, err)
+	}
+	port, err := strconv.Atoi(p.Port.String())
+	if err != nil || port < 1 || port > 65535 || p.Address == "" || p.ID == "" {
+		return nil, fmt.Errorf("%w: vmess payload needs add, port and id", ErrInvalidURI)
+	}
+	l := &link{
+		protocol: "vmess", name: p.Name, address: p.Address, port: port,
+		id: p.ID, cipher: p.Cipher, network: p.Network, host: p.Host, path: p.Path,
+		security: p.TLS, sni: p.SNI, fingerprint: p.Fingerprint,
+	}
+	if name, err := url.PathUnescape(fragment); err == nil && name != "" {
+		l.name = name
+	}
+	if p.AlterID != "" {
+		l.alterID, _ = strconv.Atoi(p.AlterID.String())
+	}
+	if l.cipher == "" {
+		l.cipher = "auto"
+	}
+	if p.ALPN != "" {
+		l.alpn = strings.Split(p.ALPN, ",")
+	}
+	if l.network == "grpc" {
+		l.service, l.path = l.path, ""
+	}
+	return l, nil
+}
+
+// parseShadowsocks accepts SIP002 links (userinfo base64url-encoded or
+// percent-encoded) and legacy links with the whole body base64-encoded.
+func parseShadowsocks(uri string) (*link, error) {
+	body, fragment, _ := strings.Cut(uri[len("ss://"):], "#")
+	if !strings.Contains(body, "@") {
+		decoded, err := decodeBase64(body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: shadowsocks link is not base64", ErrInvalidURI)
+		}
+		body = string(decoded)
+	}
+	userinfo, hostport, found := cutLast(body, "@")
+	if !found {
+		return nil, fmt.Errorf("%w: missing credentials", ErrInvalidURI)
+	}
+	hostport, _, _ = strings.Cut(hostport, "?") // Plugin parameters are not supported
+	hostport = strings.TrimSuffix(hostport, "/")
+
+	if decoded, err := decodeBase64(userinfo); err == nil && strings.Contains(string(decoded), ":") {
+		userinfo = string(decoded)
+	} else if unescaped, err := url.PathUnescape(userinfo); err == nil {
+		userinfo = unescaped
+	}
+	method, password, found := strings.Cut(userinfo, ":")
+	if !found || method == "" || password == "" {
+		return nil, fmt.Errorf("%w: shadowsocks credentials must be method:password", ErrInvalidURI)
+	}
+
+	l := &link{protocol: "shadowsocks", method: method, id: password}
+	var err error
+	if l.address, l.port, err = hostPort(hostport); err != nil {
+		return nil, err
+	}
+	if name, err := url.PathUnescape(fragment); err == nil {
+		l.name = name
+	}
+	return l, nil
+}
+
+func hostPort(hostport string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrInvalidURI, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 || host == "" {
+		return "", 0, fmt.Errorf("%w: invalid address %q", ErrInvalidURI, hostport)
+	}
+	return host, port, nil
+}
+
+// decodeBase64 accepts standard and URL-safe base64, padded or not.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,113 @@
+package shareuri
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProxyURI_VLESSReality(t *testing.T) {
+	uri := "vless://5f3c1d7e-0000-4000-8000-000000000001@example.com:443" +
+		"?type=tcp&security=reality&pbk=QmFzZTY0UHVibGljS2V5&sid=6ba85179&sni=www.microsoft.com&fp=chrome&flow=xtls-rprx-vision" +
+		"#Tokyo%201"
+
+	out, err := ParseProxyURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "vless", *out.Protocol)
+	assert.Equal(t, "Tokyo 1", *out.Tag)
+
+	server := out.Settings["vnext"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "example.com", server["address"])
+	assert.Equal(t, 443, server["port"])
+	user := server["users"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "5f3c1d7e-0000-4000-8000-000000000001", user["id"])
+	assert.Equal(t, "xtls-rprx-vision", user["flow"])
+
+	require.NotNil(t, out.StreamSettings)
+	assert.Equal(t, "reality", *out.StreamSettings.Security)
+	tls := out.StreamSettings.TLSSettings
+	assert.Equal(t, "www.microsoft.com", *tls.ServerName)
+	assert.Equal(t, "chrome", *tls.Fingerprint)
+	assert.Equal(t, "QmFzZTY0UHVibGljS2V5", *tls.RealitySettings.PublicKey)
+	assert.Equal(t, "6ba85179", *tls.RealitySettings.ShortId)
+
+	sb, err := ParseProxyURISingBox(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "vless", sb.Type)
+	assert.Equal(t, "example.com", sb.Settings["server"])
+	reality := sb.TLS["reality"].(map[string]interface{})
+	assert.Equal(t, "QmFzZTY0UHVibGljS2V5", reality["public_key"])
+}
+
+func TestParseProxyURI_VMess(t *testing.T) {
+	payload := `{"v":"2","ps":"hk-ws","add":"hk.example.com","port":"8443","id":"5f3c1d7e-0000-4000-8000-000000000002",` +
+		`"aid":0,"net":"ws","host":"cdn.example.com","path":"/ray","tls":"tls","sni":"cdn.example.com"}`
+	out, err := ParseProxyURI("vmess://" + base64.StdEncoding.EncodeToString([]byte(payload)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "vmess", *out.Protocol)
+	assert.Equal(t, "hk-ws", *out.Tag)
+	server := out.Settings["vnext"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, 8443, server["port"])
+	user := server["users"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "auto", user["security"])
+
+	stream := out.StreamSettings
+	assert.Equal(t, "ws", *stream.Network)
+	assert.Equal(t, "/ray", *stream.WSSettings.Path)
+	assert.Equal(t, "cdn.example.com", stream.WSSettings.Headers["Host"])
+	assert.Equal(t, "tls", *stream.Security)
+
+	sb, err := ParseProxyURISingBox("vmess://" + base64.RawURLEncoding.EncodeToString([]byte(payload)))
+	require.NoError(t, err)
+	assert.Equal(t, "ws", sb.Transport["type"])
+	assert.Equal(t, true, sb.TLS["enabled"])
+}
+
+func TestParseProxyURI_Shadowsocks(t *testing.T) {
+	userinfo := base64.RawURLEncoding.EncodeToString([]byte("chacha20-ietf-poly1305:s3cret"))
+	for name, uri := range map[string]string{
+		"sip002": "ss://" + userinfo + "@198.51.100.7:8388#ss-node",
+		"plain":  "ss://chacha20-ietf-poly1305:s3cret@198.51.100.7:8388#ss-node",
+		"legacy": "ss://" + base64.StdEncoding.EncodeToString([]byte("chacha20-ietf-poly1305:s3cret@198.51.100.7:8388")) + "#ss-node",
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := ParseProxyURI(uri)
+			require.NoError(t, err)
+			assert.Equal(t, "shadowsocks", *out.Protocol)
+			assert.Equal(t, "ss-node", *out.Tag)
+			server := out.Settings["servers"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, "198.51.100.7", server["address"])
+			assert.Equal(t, 8388, server["port"])
+			assert.Equal(t, "chacha20-ietf-poly1305", server["method"])
+			assert.Equal(t, "s3cret", server["password"])
+		})
+	}
+}
+
+func TestParseProxyURI_TrojanDefaultsToTLS(t *testing.T) {
+	out, err := ParseProxyURI("trojan://pa55@trojan.example.com:443?sni=trojan.example.com&type=grpc&serviceName=tun")
+	require.NoError(t, err)
+	assert.Equal(t, "tls", *out.StreamSettings.Security)
+	assert.Equal(t, "tun", *out.StreamSettings.GRPCSettings.ServiceName)
+	assert.Nil(t, out.Tag)
+}
+
+func TestParseProxyURI_Errors(t *testing.T) {
+	_, err := ParseProxyURI("hysteria2://secret@example.com:443")
+	assert.ErrorIs(t, err, ErrUnsupportedScheme)
+
+	for _, uri := range []string{
+		"example.com:443",
+		"vless://example.com:443",
+		"vless://id@example.com:99999",
+		"vless://id@example.com:443?security=reality",
+		"vmess://not-base64!",
+		"ss://bm90LWEtY3JlZGVudGlhbA@example.com:8388",
+	} {
+		_, err := ParseProxyURI(uri)
+		assert.ErrorIs(t, err, ErrInvalidURI, uri)
+	}
+}
@@ -0,0 +1,237 @@
+// Package snapshot exports every stored configuration into a single ZIP
+// archive and restores such an archive into a store.
+package snapshot
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+)
+
+// Filename is the suggested download name for an exported snapshot.
+const Filename = "ezfw-snapshot.zip"
+
+// pageSize is the number of configs requested per List call while exporting.
+// The store may return fewer, so paging stops at the first empty page.
+const pageSize = 100
+
+// RestoreOptions controls Restore.
+type RestoreOptions struct {
+	// DryRun performs every decode, validation and conflict check but writes
+	// nothing.
+	DryRun bool
+}
+
+// RestoreReport lists the outcome of every file in a restored archive. A dry
+// run reports exactly what a real run would, with DryRun set.
+type RestoreReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Restored []string          `json:"restored"`
+	Failed   map[string]string `json:"failed"` // archive path -> error
+}
+
+// Export writes all Xray and SingBox configs to w as a ZIP archive with one
+// JSON file per config named "{type}/{name}.json". Names that are empty,
+// unsafe as file names, or repeated get the config ID appended.
+func Export(ctx context.Context, st store.Store, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	used := make(map[string]bool)
+
+	for offset := 0; ; {
+		configs, err := st.ListXrayConfigs(ctx, pageSize, offset, store.Sort{})
+		if err != nil {
+			return fmt.Errorf("list xray configs: %w", err)
+		}
+		for _, c := range configs {
+			if err := writeEntry(zw, used, models.ConfigTypeXray, c.Name, c.ID, c); err != nil {
+				return err
+			}
+		}
+		if len(configs) == 0 {
+			break
+		}
+		offset += len(configs)
+	}
+
+	for offset := 0; ; {
+		configs, err := st.ListSingBoxConfigs(ctx, pageSize, offset, store.Sort{})
+		if err != nil {
+			return fmt.Errorf("list singbox configs: %w", err)
+		}
+		for _, c := range configs {
+			if err := writeEntry(zw, used, models.ConfigTypeSingBox, c.Name, c.ID, c); err != nil {
+				return err
+			}
+		}
+		if len(configs) == 0 {
+			break
+		}
+		offset += len(configs)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finalize snapshot archive: %w", err)
+	}
+	return nil
+}
+
+func writeEntry(zw *zip.Writer, used map[string]bool, configType, name, id string, config interface{}) error {
+	entry := entryName(used, configType, name, id)
+	f, err := zw.Create(entry)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", entry, err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(config); err != nil {
+		return fmt.Errorf("encode %s: %w", entry, err)
+	}
+	return nil
+}
+
+func entryName(used map[string]bool, configType, name, id string) string {
+	base := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if base == "" || base == "." || base == ".." {
+		base = id
+	}
+	entry := path.Join(configType, base+".json")
+	if used[entry] {
+		entry = path.Join(configType, base+"-"+id+".json")
+	}
+	used[entry] = true
+	return entry
+}
+
+// Restore reads an archive produced by Export and creates every config it
+// contains through the store. Config IDs are preserved; timestamps are set
+// by the store as for any new config.
+//
+// Every file is decoded, validated as the store would on create and checked
+// for conflicts (an ID already in the store or repeated in the archive, an
+// Xray name already taken) before anything is created, so a dry run reports the same outcome a real run would. A file
+// that fails is recorded in the report and does not stop the others; only an
+// unreadable archive returns an error.
+func Restore(ctx context.Context, st store.Store, r io.ReaderAt, size int64, opts RestoreOptions) (*RestoreReport, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open snapshot archive: %w", err)
+	}
+
+	report := &RestoreReport{DryRun: opts.DryRun, Restored: []string{}, Failed: map[string]string{}}
+	plan := &restorePlan{ids: map[string]bool{}, xrayNames: map[string]bool{}}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		entry, err := plan.check(ctx, st, f)
+		if err == nil && !opts.DryRun {
+			err = entry.create(ctx, st)
+		}
+		if err != nil {
+			report.Failed[f.Name] = err.Error()
+			continue
+		}
+		report.Restored = append(report.Restored, f.Name)
+	}
+	return report, nil
+}
+
+// restorePlan tracks what earlier files in the archive will create, so
+// conflicts between archive entries are caught in dry runs too.
+type restorePlan struct {
+	ids       map[string]bool
+	xrayNames map[string]bool
+}
+
+// restoreEntry is a decoded archive file ready to be created.
+type restoreEntry struct {
+	xray    *models.XrayConfig
+	singBox *models.SingBoxConfig
+}
+
+func (e *restoreEntry) create(ctx context.Context, st store.Store) error {
+	if e.xray != nil {
+		return st.CreateXrayConfig(ctx, e.xray)
+	}
+	return st.CreateSingBoxConfig(ctx, e.singBox)
+}
+
+func (p *restorePlan) check(ctx context.Context, st store.Store, f *zip.File) (*restoreEntry, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	dec := json.NewDecoder(rc)
+
+	var id string
+	entry := &restoreEntry{}
+	switch path.Dir(f.Name) {
+	case models.ConfigTypeXray:
+		entry.xray = &models.XrayConfig{}
+		if err := dec.Decode(entry.xray); err != nil {
+			return nil, fmt.Errorf("decode xray config: %w", err)
+		}
+		if errs := validation.XrayConfigErrors(entry.xray); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid xray config: %w", errs[0])
+		}
+		id = entry.xray.ID
+		if p.xrayNames[entry.xray.Name] {
+			return nil, fmt.Errorf("xray config name %q is repeated in the archive", entry.xray.Name)
+		}
+		if _, err := st.GetXrayConfigByName(ctx, entry.xray.Name); err == nil {
+			return nil, fmt.Errorf("xray config name %q already exists", entry.xray.Name)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if id != "" {
+			if _, err := st.GetXrayConfig(ctx, id); err == nil {
+				return nil, fmt.Errorf("config id %s already exists", id)
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+		}
+		p.xrayNames[entry.xray.Name] = true
+	case models.ConfigTypeSingBox:
+		entry.singBox = &models.SingBoxConfig{}
+		if err := dec.Decode(entry.singBox); err != nil {
+			return nil, fmt.Errorf("decode singbox config: %w", err)
+		}
+		if errs := validation.SingBoxConfigErrors(entry.singBox); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid singbox config: %w", errs[0])
+		}
+		id = entry.singBox.ID
+		if id != "" {
+			if _, err := st.GetSingBoxConfig(ctx, id); err == nil {
+				return nil, fmt.Errorf("config id %s already exists", id)
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown config type directory %q", path.Dir(f.Name))
+	}
+
+	if id != "" {
+		if p.ids[id] {
+			return nil, fmt.Errorf("config id %s is repeated in the archive", id)
+		}
+		p.ids[id] = true
+	}
+	return entry, nil
+}
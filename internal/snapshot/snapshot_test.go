@@ -0,0 +1,143 @@
+package snapshot
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestExportRestoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := sqlitetest.New(t)
+
+	xray := &models.XrayConfig{
+		Name:     "edge",
+		Log:      &models.LogObject{Loglevel: strPtr("warning")},
+		Inbounds: []models.InboundObject{{Tag: "in", Protocol: "vless", Port: float64(443)}},
+	}
+	require.NoError(t, src.CreateXrayConfig(ctx, xray))
+	sb1 := &models.SingBoxConfig{Name: "client", Log: &models.SingBoxLogConfig{Level: strPtr("info")}}
+	sb2 := &models.SingBoxConfig{Name: "client", Route: &models.SingBoxRouteConfig{Final: strPtr("direct")}}
+	require.NoError(t, src.CreateSingBoxConfig(ctx, sb1))
+	require.NoError(t, src.CreateSingBoxConfig(ctx, sb2))
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(ctx, src, &buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "xray/edge.json")
+	assert.Len(t, names, 3, "duplicate singbox names must not overwrite each other")
+
+	dst := sqlitetest.New(t)
+	report, err := Restore(ctx, dst, bytes.NewReader(buf.Bytes()), int64(buf.Len()), RestoreOptions{})
+	require.NoError(t, err)
+	assert.Len(t, report.Restored, 3)
+	assert.Empty(t, report.Failed)
+
+	gotXray, err := dst.GetXrayConfig(ctx, xray.ID)
+	require.NoError(t, err)
+	assert.Equal(t, xray.ConfigHash, gotXray.ConfigHash)
+	assert.Equal(t, "edge", gotXray.Name)
+	for _, want := range []*models.SingBoxConfig{sb1, sb2} {
+		got, err := dst.GetSingBoxConfig(ctx, want.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want.ConfigHash, got.ConfigHash)
+	}
+
+	// Restoring into the same store again fails per file, not as a whole
+	report, err = Restore(ctx, dst, bytes.NewReader(buf.Bytes()), int64(buf.Len()), RestoreOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Restored)
+	assert.Len(t, report.Failed, 3)
+}
+
+func TestExport_SmallMaxLimit(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+	st.SetPagination(store.Pagination{DefaultLimit: 2, MaxLimit: 2})
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, st.CreateXrayConfig(ctx, &models.XrayConfig{Name: name}))
+	}
+	for _, name := range []string{"x", "y", "z"} {
+		require.NoError(t, st.CreateSingBoxConfig(ctx, &models.SingBoxConfig{Name: name}))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(ctx, st, &buf))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Len(t, zr.File, 8, "every page is exported when the store clamps the page size")
+}
+
+func TestRestore_DryRun(t *testing.T) {
+	ctx := context.Background()
+	src := sqlitetest.New(t)
+	require.NoError(t, src.CreateXrayConfig(ctx, &models.XrayConfig{Name: "taken"}))
+	require.NoError(t, src.CreateXrayConfig(ctx, &models.XrayConfig{Name: "free"}))
+	require.NoError(t, src.CreateSingBoxConfig(ctx, &models.SingBoxConfig{Name: "client"}))
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(ctx, src, &buf))
+	archive := func() (*bytes.Reader, int64) { return bytes.NewReader(buf.Bytes()), int64(buf.Len()) }
+
+	dst := sqlitetest.New(t)
+	require.NoError(t, dst.CreateXrayConfig(ctx, &models.XrayConfig{Name: "taken"}))
+
+	r, size := archive()
+	dry, err := Restore(ctx, dst, r, size, RestoreOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, dry.DryRun)
+	assert.ElementsMatch(t, []string{"xray/free.json", "singbox/client.json"}, dry.Restored)
+	require.Contains(t, dry.Failed, "xray/taken.json")
+	assert.Contains(t, dry.Failed["xray/taken.json"], "already exists")
+
+	// Nothing may have been written
+	xrays, err := dst.ListXrayConfigs(ctx, 10, 0, store.Sort{})
+	require.NoError(t, err)
+	assert.Len(t, xrays, 1)
+	singBoxes, err := dst.ListSingBoxConfigs(ctx, 10, 0, store.Sort{})
+	require.NoError(t, err)
+	assert.Empty(t, singBoxes)
+
+	// A real run reports the same outcome
+	r, size = archive()
+	applied, err := Restore(ctx, dst, r, size, RestoreOptions{})
+	require.NoError(t, err)
+	assert.False(t, applied.DryRun)
+	assert.ElementsMatch(t, dry.Restored, applied.Restored)
+	assert.Equal(t, dry.Failed, applied.Failed)
+}
+
+func TestRestore_DryRunValidates(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("singbox/bad.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`{"name":"bad","outbounds":[{"type":"direct","tag":"d"},{"type":"block","tag":"d"}]}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	dst := sqlitetest.New(t)
+	for _, dryRun := range []bool{true, false} {
+		report, err := Restore(ctx, dst, bytes.NewReader(buf.Bytes()), int64(buf.Len()), RestoreOptions{DryRun: dryRun})
+		require.NoError(t, err)
+		assert.Empty(t, report.Restored, "dry run %v", dryRun)
+		require.Contains(t, report.Failed, "singbox/bad.json")
+		assert.Contains(t, report.Failed["singbox/bad.json"], "duplicate tag")
+	}
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// MaxBatchIDs caps the number of distinct IDs a single batch fetch accepts.
+const MaxBatchIDs = 100
+
+// ErrTooManyIDs is returned when a batch fetch names more than MaxBatchIDs
+// distinct IDs.
+var ErrTooManyIDs = errors.New("too many IDs in batch")
+
+// BatchGetXrayConfigs fetches the Xray configs with the given IDs using one
+// GetMultipleXrayConfigs call. Found configs and missing IDs are both
+// returned in request order; repeated IDs are looked up once.
+func BatchGetXrayConfigs(ctx context.Context, st Store, ids []string) (*models.XrayConfigBatch, error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > MaxBatchIDs {
+		return nil, fmt.Errorf("%w: %d (max %d)", ErrTooManyIDs, len(unique), MaxBatchIDs)
+	}
+
+	found, err := st.GetMultipleXrayConfigs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	batch := &models.XrayConfigBatch{Configs: []*models.XrayConfig{}, Missing: []string{}}
+	for _, id := range unique {
+		if config, ok := found[id]; ok {
+			batch.Configs = append(batch.Configs, config)
+		} else {
+			batch.Missing = append(batch.Missing, id)
+		}
+	}
+	return batch, nil
+}
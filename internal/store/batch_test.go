@@ -0,0 +1,42 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func TestBatchGetXrayConfigs(t *testing.T) {
+	ctx := context.Background()
+	st := sqlitetest.New(t)
+
+	a := &models.XrayConfig{Name: "a"}
+	b := &models.XrayConfig{Name: "b"}
+	require.NoError(t, st.CreateXrayConfig(ctx, a))
+	require.NoError(t, st.CreateXrayConfig(ctx, b))
+
+	batch, err := store.BatchGetXrayConfigs(ctx, st, []string{b.ID, "gone-1", a.ID, b.ID, "gone-2"})
+	require.NoError(t, err)
+	require.Len(t, batch.Configs, 2)
+	assert.Equal(t, b.ID, batch.Configs[0].ID, "configs follow request order")
+	assert.Equal(t, a.ID, batch.Configs[1].ID)
+	assert.Equal(t, []string{"gone-1", "gone-2"}, batch.Missing)
+
+	batch, err = store.BatchGetXrayConfigs(ctx, st, nil)
+	require.NoError(t, err)
+	assert.Empty(t, batch.Configs)
+	assert.Empty(t, batch.Missing)
+
+	tooMany := make([]string, store.MaxBatchIDs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("id-%d", i)
+	}
+	_, err = store.BatchGetXrayConfigs(ctx, st, tooMany)
+	assert.ErrorIs(t, err, store.ErrTooManyIDs)
+}
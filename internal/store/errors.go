@@ -0,0 +1,16 @@
+package store
+
+import "errors"
+
+// ErrBusy is returned when the database stayed locked by other writers for
+// the whole retry budget. The operation may be retried later; API handlers
+// map it to 503 Service Unavailable with Retry-After.
+var ErrBusy = errors.New("store is busy")
+
+// ErrConflict is returned when a write would violate a uniqueness rule, such
+// as two Xray configs sharing a name.
+var ErrConflict = errors.New("conflicts with an existing record")
+
+// ErrUnknownSection is returned when a section filter names something that is
+// not a top-level config section, such as has=foo on the Xray list endpoint.
+var ErrUnknownSection = errors.New("unknown config section")
@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// LatencyBuckets are the upper bounds of the latency histogram kept for each
+// store method. Slower calls land in a final overflow bucket.
+var LatencyBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 500 * time.Millisecond, time.Second,
+}
+
+// MethodStats are the recorded calls of one store method.
+type MethodStats struct {
+	Method  string        `json:"method" example:"GetXrayConfig"`
+	Calls   int64         `json:"calls"`
+	Errors  int64         `json:"errors"`
+	Total   time.Duration `json:"total_ns"`
+	Buckets []int64       `json:"buckets"` // call counts per LatencyBuckets entry, plus overflow
+}
+
+// Instrumented is a Store decorator recording call counts, error counts and
+// latency histograms per method. Calls slower than the slow threshold are
+// logged. Every method is written out explicitly rather than embedding Store,
+// so a new interface method fails to compile until it is instrumented.
+type Instrumented struct {
+	next          Store
+	slowThreshold time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*MethodStats
+}
+
+var _ Store = (*Instrumented)(nil)
+
+// NewInstrumented wraps next. A zero slowThreshold disables slow-call logging.
+func NewInstrumented(next Store, slowThreshold time.Duration) *Instrumented {
+	return &Instrumented{next: next, slowThreshold: slowThreshold, stats: make(map[string]*MethodStats)}
+}
+
+// Stats returns a snapshot of the recorded calls, sorted by method name.
+func (s *Instrumented) Stats() []MethodStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]MethodStats, 0, len(s.stats))
+	for _, st := range s.stats {
+		snapshot := *st
+		snapshot.Buckets = append([]int64(nil), st.Buckets...)
+		out = append(out, snapshot)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Method < out[j].Method })
+	return out
+}
+
+// observe records a call to method that started at start. It is deferred with
+// a pointer to the method's named error result.
+func (s *Instrumented) observe(method string, start time.Time, err *error) {
+	elapsed := time.Since(start)
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool { return elapsed <= LatencyBuckets[i] })
+
+	s.mu.Lock()
+	st := s.stats[method]
+	if st == nil {
+		st = &MethodStats{Method: method, Buckets: make([]int64, len(LatencyBuckets)+1)}
+		s.stats[method] = st
+	}
+	st.Calls++
+	st.Total += elapsed
+	st.Buckets[bucket]++
+	if *err != nil {
+		st.Errors++
+	}
+	s.mu.Unlock()
+
+	if s.slowThreshold > 0 && elapsed >= s.slowThreshold {
+		log.Printf("slow store call: %s took %s (err=%v)", method, elapsed, *err)
+	}
+}
+
+func (s *Instrumented) CreateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) (err error) {
+	defer s.observe("CreateSingBoxConfig", time.Now(), &err)
+	return s.next.CreateSingBoxConfig(ctx, config)
+}
+
+func (s *Instrumented) GetSingBoxConfig(ctx context.Context, id string) (result *models.SingBoxConfig, err error) {
+	defer s.observe("GetSingBoxConfig", time.Now(), &err)
+	return s.next.GetSingBoxConfig(ctx, id)
+}
+
+func (s *Instrumented) ListSingBoxConfigs(ctx context.Context, limit, offset int, order Sort) (result []*models.SingBoxConfig, err error) {
+	defer s.observe("ListSingBoxConfigs", time.Now(), &err)
+	return s.next.ListSingBoxConfigs(ctx, limit, offset, order)
+}
+
+func (s *Instrumented) UpdateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) (err error) {
+	defer s.observe("UpdateSingBoxConfig", time.Now(), &err)
+	return s.next.UpdateSingBoxConfig(ctx, config)
+}
+
+func (s *Instrumented) DeleteSingBoxConfig(ctx context.Context, id string) (err error) {
+	defer s.observe("DeleteSingBoxConfig", time.Now(), &err)
+	return s.next.DeleteSingBoxConfig(ctx, id)
+}
+
+func (s *Instrumented) CountSingBoxConfigs(ctx context.Context) (result int, err error) {
+	defer s.observe("CountSingBoxConfigs", time.Now(), &err)
+	return s.next.CountSingBoxConfigs(ctx)
+}
+
+func (s *Instrumented) ListSingBoxConfigsUpdatedSince(ctx context.Context, since time.Time) (result []*models.SingBoxConfig, err error) {
+	defer s.observe("ListSingBoxConfigsUpdatedSince", time.Now(), &err)
+	return s.next.ListSingBoxConfigsUpdatedSince(ctx, since)
+}
+
+func (s *Instrumented) CreateXrayConfig(ctx context.Context, config *models.XrayConfig) (err error) {
+	defer s.observe("CreateXrayConfig", time.Now(), &err)
+	return s.next.CreateXrayConfig(ctx, config)
+}
+
+func (s *Instrumented) GetXrayConfig(ctx context.Context, id string) (result *models.XrayConfig, err error) {
+	defer s.observe("GetXrayConfig", time.Now(), &err)
+	return s.next.GetXrayConfig(ctx, id)
+}
+
+func (s *Instrumented) GetXrayConfigByName(ctx context.Context, name string) (result *models.XrayConfig, err error) {
+	defer s.observe("GetXrayConfigByName", time.Now(), &err)
+	return s.next.GetXrayConfigByName(ctx, name)
+}
+
+func (s *Instrumented) GetMultipleXrayConfigs(ctx context.Context, ids []string) (result map[string]*models.XrayConfig, err error) {
+	defer s.observe("GetMultipleXrayConfigs", time.Now(), &err)
+	return s.next.GetMultipleXrayConfigs(ctx, ids)
+}
+
+func (s *Instrumented) ListXrayConfigs(ctx context.Context, limit, offset int, order Sort) (result []*models.XrayConfig, err error) {
+	defer s.observe("ListXrayConfigs", time.Now(), &err)
+	return s.next.ListXrayConfigs(ctx, limit, offset, order)
+}
+
+func (s *Instrumented) ListXrayConfigsByModelVersion(ctx context.Context, modelVersion string, limit, offset int, order Sort) (result []*models.XrayConfig, err error) {
+	defer s.observe("ListXrayConfigsByModelVersion", time.Now(), &err)
+	return s.next.ListXrayConfigsByModelVersion(ctx, modelVersion, limit, offset, order)
+}
+
+func (s *Instrumented) ListXrayConfigsWithSection(ctx context.Context, section string, limit, offset int, order Sort) (result []*models.XrayConfig, err error) {
+	defer s.observe("ListXrayConfigsWithSection", time.Now(), &err)
+	return s.next.ListXrayConfigsWithSection(ctx, section, limit, offset, order)
+}
+
+func (s *Instrumented) UpdateXrayConfig(ctx context.Context, config *models.XrayConfig) (err error) {
+	defer s.observe("UpdateXrayConfig", time.Now(), &err)
+	return s.next.UpdateXrayConfig(ctx, config)
+}
+
+func (s *Instrumented) UpdateXrayConfigs(ctx context.Context, configs ...*models.XrayConfig) (err error) {
+	defer s.observe("UpdateXrayConfigs", time.Now(), &err)
+	return s.next.UpdateXrayConfigs(ctx, configs...)
+}
+
+func (s *Instrumented) RenameXrayConfig(ctx context.Context, id, newName string) (err error) {
+	defer s.observe("RenameXrayConfig", time.Now(), &err)
+	return s.next.RenameXrayConfig(ctx, id, newName)
+}
+
+func (s *Instrumented) DeleteXrayConfig(ctx context.Context, id string) (err error) {
+	defer s.observe("DeleteXrayConfig", time.Now(), &err)
+	return s.next.DeleteXrayConfig(ctx, id)
+}
+
+func (s *Instrumented) CountXrayConfigs(ctx context.Context) (result int, err error) {
+	defer s.observe("CountXrayConfigs", time.Now(), &err)
+	return s.next.CountXrayConfigs(ctx)
+}
+
+func (s *Instrumented) XrayConfigExists(ctx context.Context, id string) (result bool, err error) {
+	defer s.observe("XrayConfigExists", time.Now(), &err)
+	return s.next.XrayConfigExists(ctx, id)
+}
+
+func (s *Instrumented) ListXrayConfigsUpdatedSince(ctx context.Context, since time.Time) (result []*models.XrayConfig, err error) {
+	defer s.observe("ListXrayConfigsUpdatedSince", time.Now(), &err)
+	return s.next.ListXrayConfigsUpdatedSince(ctx, since)
+}
+
+func (s *Instrumented) ListXrayConfigsByHash(ctx context.Context, hash string) (result []models.ConfigRef, err error) {
+	defer s.observe("ListXrayConfigsByHash", time.Now(), &err)
+	return s.next.ListXrayConfigsByHash(ctx, hash)
+}
+
+func (s *Instrumented) ListXrayDuplicates(ctx context.Context) (result []models.DuplicateGroup, err error) {
+	defer s.observe("ListXrayDuplicates", time.Now(), &err)
+	return s.next.ListXrayDuplicates(ctx)
+}
+
+func (s *Instrumented) GetXrayConfigPromotion(ctx context.Context, sourceID, environment string) (result *models.XrayConfig, err error) {
+	defer s.observe("GetXrayConfigPromotion", time.Now(), &err)
+	return s.next.GetXrayConfigPromotion(ctx, sourceID, environment)
+}
+
+func (s *Instrumented) CreateRuleSet(ctx context.Context, ruleSet *models.SingBoxRuleSet) (err error) {
+	defer s.observe("CreateRuleSet", time.Now(), &err)
+	return s.next.CreateRuleSet(ctx, ruleSet)
+}
+
+func (s *Instrumented) GetRuleSet(ctx context.Context, id string) (result *models.SingBoxRuleSet, err error) {
+	defer s.observe("GetRuleSet", time.Now(), &err)
+	return s.next.GetRuleSet(ctx, id)
+}
+
+func (s *Instrumented) ListRuleSets(ctx context.Context) (result []*models.SingBoxRuleSet, err error) {
+	defer s.observe("ListRuleSets", time.Now(), &err)
+	return s.next.ListRuleSets(ctx)
+}
+
+func (s *Instrumented) UpdateRuleSet(ctx context.Context, ruleSet *models.SingBoxRuleSet) (err error) {
+	defer s.observe("UpdateRuleSet", time.Now(), &err)
+	return s.next.UpdateRuleSet(ctx, ruleSet)
+}
+
+func (s *Instrumented) DeleteRuleSet(ctx context.Context, id string) (err error) {
+	defer s.observe("DeleteRuleSet", time.Now(), &err)
+	return s.next.DeleteRuleSet(ctx, id)
+}
+
+func (s *Instrumented) ListClientPlacements(ctx context.Context, email string) (result []models.ClientPlacement, err error) {
+	defer s.observe("ListClientPlacements", time.Now(), &err)
+	return s.next.ListClientPlacements(ctx, email)
+}
+
+func (s *Instrumented) RebuildClientIndex(ctx context.Context) (result int, err error) {
+	defer s.observe("RebuildClientIndex", time.Now(), &err)
+	return s.next.RebuildClientIndex(ctx)
+}
+
+func (s *Instrumented) RotateDataKeys(ctx context.Context) (result int, err error) {
+	defer s.observe("RotateDataKeys", time.Now(), &err)
+	return s.next.RotateDataKeys(ctx)
+}
+
+func (s *Instrumented) Search(ctx context.Context, query string, types []string, limitPerType int) (result *models.SearchResults, err error) {
+	defer s.observe("Search", time.Now(), &err)
+	return s.next.Search(ctx, query, types, limitPerType)
+}
+
+func (s *Instrumented) GetIdempotencyRecord(ctx context.Context, keyHash string) (result *models.IdempotencyRecord, err error) {
+	defer s.observe("GetIdempotencyRecord", time.Now(), &err)
+	return s.next.GetIdempotencyRecord(ctx, keyHash)
+}
+
+func (s *Instrumented) SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) (err error) {
+	defer s.observe("SaveIdempotencyRecord", time.Now(), &err)
+	return s.next.SaveIdempotencyRecord(ctx, record)
+}
+
+func (s *Instrumented) ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) (result bool, err error) {
+	defer s.observe("ReserveIdempotencyKey", time.Now(), &err)
+	return s.next.ReserveIdempotencyKey(ctx, record)
+}
+
+func (s *Instrumented) ReleaseIdempotencyKey(ctx context.Context, keyHash string) (err error) {
+	defer s.observe("ReleaseIdempotencyKey", time.Now(), &err)
+	return s.next.ReleaseIdempotencyKey(ctx, keyHash)
+}
+
+func (s *Instrumented) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (result int64, err error) {
+	defer s.observe("DeleteExpiredIdempotencyRecords", time.Now(), &err)
+	return s.next.DeleteExpiredIdempotencyRecords(ctx, now)
+}
+
+func (s *Instrumented) CreateJobRun(ctx context.Context, run *models.JobRun) (err error) {
+	defer s.observe("CreateJobRun", time.Now(), &err)
+	return s.next.CreateJobRun(ctx, run)
+}
+
+func (s *Instrumented) ListJobRuns(ctx context.Context, job string, limit int) (result []*models.JobRun, err error) {
+	defer s.observe("ListJobRuns", time.Now(), &err)
+	return s.next.ListJobRuns(ctx, job, limit)
+}
+
+func (s *Instrumented) DeleteJobRunsBefore(ctx context.Context, cutoff time.Time) (result int64, err error) {
+	defer s.observe("DeleteJobRunsBefore", time.Now(), &err)
+	return s.next.DeleteJobRunsBefore(ctx, cutoff)
+}
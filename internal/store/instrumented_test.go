@@ -0,0 +1,66 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+func TestInstrumented_RecordsCalls(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewInstrumented(sqlitetest.New(t), 0)
+
+	config := &models.XrayConfig{Name: "edge"}
+	require.NoError(t, st.CreateXrayConfig(ctx, config))
+	got, err := st.GetXrayConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edge", got.Name, "results are passed through")
+	_, err = st.GetXrayConfig(ctx, "missing")
+	require.Error(t, err)
+
+	stats := st.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "CreateXrayConfig", stats[0].Method)
+	assert.EqualValues(t, 1, stats[0].Calls)
+	assert.EqualValues(t, 0, stats[0].Errors)
+
+	get := stats[1]
+	assert.Equal(t, "GetXrayConfig", get.Method)
+	assert.EqualValues(t, 2, get.Calls)
+	assert.EqualValues(t, 1, get.Errors)
+	assert.Len(t, get.Buckets, len(store.LatencyBuckets)+1)
+	var bucketed int64
+	for _, n := range get.Buckets {
+		bucketed += n
+	}
+	assert.EqualValues(t, 2, bucketed)
+	assert.Positive(t, get.Total)
+}
+
+func benchmarkGetXrayConfig(b *testing.B, st store.Store) {
+	ctx := context.Background()
+	config := &models.XrayConfig{Name: "bench"}
+	require.NoError(b, st.CreateXrayConfig(ctx, config))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := st.GetXrayConfig(ctx, config.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkInstrumentedOverhead compares a plain store read with the same
+// read through the decorator; the difference is the instrumentation cost.
+func BenchmarkInstrumentedOverhead(b *testing.B) {
+	b.Run("plain", func(b *testing.B) {
+		benchmarkGetXrayConfig(b, sqlitetest.New(b))
+	})
+	b.Run("instrumented", func(b *testing.B) {
+		benchmarkGetXrayConfig(b, store.NewInstrumented(sqlitetest.New(b), 0))
+	})
+}
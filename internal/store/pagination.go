@@ -0,0 +1,29 @@
+package store
+
+// Pagination holds the page size limits applied by List methods. It is set
+// once at startup.
+type Pagination struct {
+	DefaultLimit int // used when the caller asks for no limit
+	MaxLimit     int // upper bound, enforced regardless of the requested limit
+}
+
+// DefaultPagination is used when no Pagination is configured.
+var DefaultPagination = Pagination{DefaultLimit: 10, MaxLimit: 100}
+
+// Limit returns the page size to use for a requested limit.
+func (p Pagination) Limit(requested int) int {
+	defaultLimit, maxLimit := p.DefaultLimit, p.MaxLimit
+	if defaultLimit <= 0 {
+		defaultLimit = DefaultPagination.DefaultLimit
+	}
+	if maxLimit <= 0 {
+		maxLimit = DefaultPagination.MaxLimit
+	}
+	if requested <= 0 {
+		requested = defaultLimit
+	}
+	if requested > maxLimit {
+		requested = maxLimit
+	}
+	return requested
+}
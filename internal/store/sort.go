@@ -0,0 +1,46 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidSort is returned for a sort key outside SortFields.
+var ErrInvalidSort = errors.New("invalid sort key")
+
+// SortFields lists the fields List methods can order by.
+var SortFields = []string{"name", "created_at", "updated_at"}
+
+// Sort selects the order of a List method's results. The zero value keeps
+// the method's default order, most recently updated first.
+type Sort struct {
+	Field      string // one of SortFields, or empty
+	Descending bool
+}
+
+// ParseSort parses a sort query parameter such as "name" or "-updated_at";
+// a leading "-" means descending. An empty key yields the zero Sort.
+func ParseSort(key string) (Sort, error) {
+	if key == "" {
+		return Sort{}, nil
+	}
+	sort := Sort{Field: strings.TrimPrefix(key, "-"), Descending: strings.HasPrefix(key, "-")}
+	if sort.Field == "" || !sort.Valid() {
+		return Sort{}, fmt.Errorf("%w: %q (expected one of %s, optionally prefixed with -)", ErrInvalidSort, key, strings.Join(SortFields, ", "))
+	}
+	return sort, nil
+}
+
+// Valid reports whether s is the zero Sort or names a field in SortFields.
+func (s Sort) Valid() bool {
+	if s.Field == "" {
+		return true
+	}
+	for _, f := range SortFields {
+		if s.Field == f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+func TestParseSort(t *testing.T) {
+	sort, err := store.ParseSort("name")
+	require.NoError(t, err)
+	assert.Equal(t, store.Sort{Field: "name"}, sort)
+
+	sort, err = store.ParseSort("-updated_at")
+	require.NoError(t, err)
+	assert.Equal(t, store.Sort{Field: "updated_at", Descending: true}, sort)
+
+	sort, err = store.ParseSort("")
+	require.NoError(t, err)
+	assert.Equal(t, store.Sort{}, sort)
+
+	for _, bad := range []string{"id", "name; DROP TABLE xray_configs", "--name", "-"} {
+		_, err = store.ParseSort(bad)
+		assert.ErrorIs(t, err, store.ErrInvalidSort, bad)
+	}
+}
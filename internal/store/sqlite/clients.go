@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// indexClients replaces the client_index rows of one config with placements,
+// inside the transaction that writes the config.
+func indexClients(ctx context.Context, tx *sql.Tx, configType, configID string, placements []models.ClientPlacement) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM client_index WHERE config_type = ? AND config_id = ?`, configType, configID); err != nil {
+		return fmt.Errorf("failed to clear client index for %s config %s: %w", configType, configID, err)
+	}
+	for _, p := range placements {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO client_index (email, config_type, config_id, inbound_tag, protocol) VALUES (?, ?, ?, ?, ?)`,
+			p.Email, p.ConfigType, p.ConfigID, p.InboundTag, p.Protocol,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to index client %s: %w", p.Email, err)
+		}
+	}
+	return nil
+}
+
+// ListClientPlacements returns every inbound the client email is configured
+// on. The match is case-insensitive.
+func (s *SQLiteStore) ListClientPlacements(ctx context.Context, email string) ([]models.ClientPlacement, error) {
+	stmt := `SELECT email, config_type, config_id, inbound_tag, protocol FROM client_index
+    WHERE email = ? ORDER BY config_type, config_id, inbound_tag`
+	rows, err := s.db.QueryContext(ctx, stmt, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query client index: %w", err)
+	}
+	defer rows.Close()
+
+	placements := []models.ClientPlacement{}
+	for rows.Next() {
+		var p models.ClientPlacement
+		if err := rows.Scan(&p.Email, &p.ConfigType, &p.ConfigID, &p.InboundTag, &p.Protocol); err != nil {
+			return nil, fmt.Errorf("failed to scan client index row: %w", err)
+		}
+		placements = append(placements, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating client index rows: %w", err)
+	}
+	return placements, nil
+}
+
+// RebuildClientIndex regenerates the client index from every stored config
+// and returns the number of placements indexed.
+func (s *SQLiteStore) RebuildClientIndex(ctx context.Context) (int, error) {
+	xrays, err := s.queryXrayConfigs(ctx, `SELECT `+xrayColumns+` FROM xray_configs`)
+	if err != nil {
+		return 0, err
+	}
+	singBoxes, err := s.querySingBoxConfigs(ctx, `SELECT `+singBoxColumns+` FROM singbox_configs`)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = s.db.withTx(ctx, func(tx *sql.Tx) error {
+		count = 0
+		if _, err := tx.ExecContext(ctx, `DELETE FROM client_index`); err != nil {
+			return fmt.Errorf("failed to clear client index: %w", err)
+		}
+		for _, config := range xrays {
+			placements := models.XrayClientPlacements(config)
+			if err := indexClients(ctx, tx, models.ConfigTypeXray, config.ID, placements); err != nil {
+				return err
+			}
+			count += len(placements)
+		}
+		for _, config := range singBoxes {
+			placements := models.SingBoxClientPlacements(config)
+			if err := indexClients(ctx, tx, models.ConfigTypeSingBox, config.ID, placements); err != nil {
+				return err
+			}
+			count += len(placements)
+		}
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func vlessInbound(tag string, emails ...string) models.InboundObject {
+	clients := make([]interface{}, 0, len(emails))
+	for _, email := range emails {
+		clients = append(clients, map[string]interface{}{"id": "uuid-" + email, "email": email})
+	}
+	return models.InboundObject{Tag: tag, Protocol: "vless", Settings: map[string]interface{}{"clients": clients}}
+}
+
+func TestClientIndex_Maintenance(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	xray := &models.XrayConfig{Name: "edge", Inbounds: []models.InboundObject{vlessInbound("vless-in", "Alice@Example.com", "bob@example.com")}}
+	require.NoError(t, st.CreateXrayConfig(ctx, xray))
+	singBox := &models.SingBoxConfig{Name: "sb", Inbounds: []*models.SingBoxInbound{{
+		Type: "trojan", Tag: "trojan-in",
+		Settings: map[string]interface{}{"users": []interface{}{map[string]interface{}{"name": "alice@example.com"}}},
+	}}}
+	require.NoError(t, st.CreateSingBoxConfig(ctx, singBox))
+
+	placements, err := st.ListClientPlacements(ctx, "ALICE@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []models.ClientPlacement{
+		{Email: "alice@example.com", ConfigType: models.ConfigTypeSingBox, ConfigID: singBox.ID, InboundTag: "trojan-in", Protocol: "trojan"},
+		{Email: "alice@example.com", ConfigType: models.ConfigTypeXray, ConfigID: xray.ID, InboundTag: "vless-in", Protocol: "vless"},
+	}, placements)
+
+	// Removing a client on update drops it from the index, adding one adds it
+	xray.Inbounds = []models.InboundObject{vlessInbound("vless-in", "bob@example.com", "carol@example.com")}
+	require.NoError(t, st.UpdateXrayConfig(ctx, xray))
+	placements, err = st.ListClientPlacements(ctx, "alice@example.com")
+	require.NoError(t, err)
+	require.Len(t, placements, 1)
+	assert.Equal(t, models.ConfigTypeSingBox, placements[0].ConfigType)
+	placements, err = st.ListClientPlacements(ctx, "carol@example.com")
+	require.NoError(t, err)
+	assert.Len(t, placements, 1)
+
+	// Deleting a config removes its placements
+	require.NoError(t, st.DeleteXrayConfig(ctx, xray.ID))
+	placements, err = st.ListClientPlacements(ctx, "bob@example.com")
+	require.NoError(t, err)
+	assert.Empty(t, placements)
+
+}
+
+func TestRebuildClientIndex(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	xray := &models.XrayConfig{Name: "edge", Inbounds: []models.InboundObject{vlessInbound("vless-in", "alice@example.com", "bob@example.com")}}
+	require.NoError(t, st.CreateXrayConfig(ctx, xray))
+	// Simulate data written before the index existed
+	_, err := st.db.ExecContext(ctx, `DELETE FROM client_index`)
+	require.NoError(t, err)
+
+	count, err := st.RebuildClientIndex(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	placements, err := st.ListClientPlacements(ctx, "bob@example.com")
+	require.NoError(t, err)
+	require.Len(t, placements, 1)
+	assert.Equal(t, xray.ID, placements[0].ConfigID)
+
+	count, err = st.RebuildClientIndex(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "rebuilding is idempotent")
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// GetIdempotencyRecord retrieves a cached response by key hash, whether or not
+// it has expired.
+func (s *SQLiteStore) GetIdempotencyRecord(ctx context.Context, keyHash string) (*models.IdempotencyRecord, error) {
+	stmt := `SELECT key_hash, operation, response, created_at, expires_at FROM idempotency_keys WHERE key_hash = ?`
+	record := &models.IdempotencyRecord{}
+	var response sql.NullString
+	err := s.db.QueryRowContext(ctx, stmt, keyHash).Scan(
+		&record.KeyHash, &record.Operation, &response, &record.CreatedAt, &record.ExpiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("idempotency key %s not found: %w", keyHash, sql.ErrNoRows)
+		}
+		return nil, fmt.Errorf("failed to scan idempotency key: %w", err)
+	}
+	if err := s.unmarshalSealed(response, &record.Response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency response: %w", err)
+	}
+	return record, nil
+}
+
+// SaveIdempotencyRecord stores a cached response, replacing an expired record
+// with the same key hash. Sensitive values in the response are sealed like
+// those of the config it holds.
+func (s *SQLiteStore) SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	response, err := s.marshalSealed(record.Response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency response: %w", err)
+	}
+	stmt := `INSERT OR REPLACE INTO idempotency_keys (key_hash, operation, response, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`
+	_, err = s.db.ExecContext(
+		ctx, stmt,
+		record.KeyHash, record.Operation, response.String, record.CreatedAt, record.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReserveIdempotencyKey inserts record as pending, with an empty response,
+// unless a record with the same key hash exists that has not expired by
+// record.CreatedAt. It reports whether the key was reserved. The check and
+// the insert are one statement, so of several concurrent callers only one
+// reserves the key.
+func (s *SQLiteStore) ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+	stmt := `INSERT INTO idempotency_keys (key_hash, operation, response, created_at, expires_at) VALUES (?, ?, '', ?, ?)
+	ON CONFLICT(key_hash) DO UPDATE SET
+		operation = excluded.operation, response = '', created_at = excluded.created_at, expires_at = excluded.expires_at
+	WHERE idempotency_keys.expires_at < excluded.created_at`
+	result, err := s.db.ExecContext(ctx, stmt, record.KeyHash, record.Operation, record.CreatedAt.UTC(), record.ExpiresAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected for idempotency key reservation: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseIdempotencyKey removes a pending record, so the key can be used
+// again. Completed records are left alone.
+func (s *SQLiteStore) ReleaseIdempotencyKey(ctx context.Context, keyHash string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key_hash = ? AND response = ''`, keyHash); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyRecords removes records that expired before now and
+// returns how many were removed.
+func (s *SQLiteStore) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < ?`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected for idempotency key cleanup: %w", err)
+	}
+	return n, nil
+}
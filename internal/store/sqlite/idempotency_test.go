@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestIdempotencyRecords(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_, err := store.GetIdempotencyRecord(ctx, "missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	live := &models.IdempotencyRecord{KeyHash: "live", Operation: "op", Response: json.RawMessage(`{"id":"1"}`), ExpiresAt: now.Add(time.Hour)}
+	expired := &models.IdempotencyRecord{KeyHash: "expired", Operation: "op", Response: json.RawMessage(`{}`), ExpiresAt: now.Add(-time.Hour)}
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, live))
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, expired))
+
+	got, err := store.GetIdempotencyRecord(ctx, "live")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1"}`, string(got.Response))
+	assert.Equal(t, "op", got.Operation)
+
+	n, err := store.DeleteExpiredIdempotencyRecords(ctx, now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	_, err = store.GetIdempotencyRecord(ctx, "expired")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Saving again replaces the record
+	live.Response = json.RawMessage(`{"id":"2"}`)
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, live))
+	got, err = store.GetIdempotencyRecord(ctx, "live")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"2"}`, string(got.Response))
+}
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	pending := &models.IdempotencyRecord{KeyHash: "k", Operation: "op", CreatedAt: now, ExpiresAt: now.Add(time.Minute)}
+	ok, err := store.ReserveIdempotencyKey(ctx, pending)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.ReserveIdempotencyKey(ctx, pending)
+	require.NoError(t, err)
+	assert.False(t, ok, "a pending key cannot be reserved twice")
+
+	got, err := store.GetIdempotencyRecord(ctx, "k")
+	require.NoError(t, err)
+	assert.Empty(t, got.Response)
+
+	// Releasing a pending key frees it
+	require.NoError(t, store.ReleaseIdempotencyKey(ctx, "k"))
+	ok, err = store.ReserveIdempotencyKey(ctx, pending)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// A completed record is kept by release and taken over once expired
+	require.NoError(t, store.SaveIdempotencyRecord(ctx, &models.IdempotencyRecord{KeyHash: "k", Operation: "op", Response: json.RawMessage(`{}`), ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, store.ReleaseIdempotencyKey(ctx, "k"))
+	_, err = store.GetIdempotencyRecord(ctx, "k")
+	require.NoError(t, err)
+	later := &models.IdempotencyRecord{KeyHash: "k", Operation: "op", CreatedAt: now.Add(2 * time.Hour), ExpiresAt: now.Add(3 * time.Hour)}
+	ok, err = store.ReserveIdempotencyKey(ctx, later)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// CreateJobRun records a finished background job run.
+func (s *SQLiteStore) CreateJobRun(ctx context.Context, run *models.JobRun) error {
+	if run.ID == "" {
+		run.ID = uuid.NewString()
+	}
+	stmt := `INSERT INTO job_runs (id, job, trigger, started_at, duration_ms, outcome, error) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(
+		ctx, stmt,
+		run.ID, run.Job, run.Trigger, run.StartedAt.UTC(), run.DurationMS, run.Outcome, run.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert job run: %w", err)
+	}
+	return nil
+}
+
+// ListJobRuns returns the most recent runs of job, newest first. An empty job
+// lists runs of every job.
+func (s *SQLiteStore) ListJobRuns(ctx context.Context, job string, limit int) ([]*models.JobRun, error) {
+	limit = s.pagination.Limit(limit)
+	stmt := `SELECT id, job, trigger, started_at, duration_ms, outcome, error FROM job_runs
+    WHERE ? = '' OR job = ? ORDER BY started_at DESC LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, stmt, job, job, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*models.JobRun{}
+	for rows.Next() {
+		run := &models.JobRun{}
+		if err := rows.Scan(&run.ID, &run.Job, &run.Trigger, &run.StartedAt, &run.DurationMS, &run.Outcome, &run.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan job run row: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating job run rows: %w", err)
+	}
+	return runs, nil
+}
+
+// DeleteJobRunsBefore removes runs started before cutoff and returns how many
+// were removed.
+func (s *SQLiteStore) DeleteJobRunsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM job_runs WHERE started_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete job runs: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected for job run cleanup: %w", err)
+	}
+	return n, nil
+}
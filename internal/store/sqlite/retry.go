@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// DefaultBusyRetryBudget is how long a statement is retried while the
+// database is locked before store.ErrBusy is returned.
+const DefaultBusyRetryBudget = 2 * time.Second
+
+const (
+	busyBackoffMin = 5 * time.Millisecond
+	busyBackoffMax = 100 * time.Millisecond
+)
+
+// retryDB wraps *sql.DB so that every ExecContext, QueryContext and
+// QueryRowContext is retried with jittered exponential backoff while SQLite
+// reports SQLITE_BUSY or SQLITE_LOCKED. Store methods get this by using s.db
+// as usual.
+type retryDB struct {
+	*sql.DB
+	budget time.Duration
+}
+
+// retry calls op until it returns an error other than a busy error, the
+// budget is spent or ctx is done. An exhausted budget yields store.ErrBusy
+// and a done ctx its own error, so a cancelled request is not reported as a
+// busy store.
+func (db *retryDB) retry(ctx context.Context, op func() error) error {
+	deadline := time.Now().Add(db.budget)
+	backoff := busyBackoffMin
+	for {
+		err := op()
+		if !isBusy(err) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %v", store.ErrBusy, err)
+		}
+		// Full jitter: sleep somewhere in [backoff/2, backoff)
+		sleep := backoff/2 + rand.N(backoff/2)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+		backoff = min(backoff*2, busyBackoffMax)
+	}
+}
+
+func (db *retryDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := db.retry(ctx, func() (err error) {
+		result, err = db.DB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// withTx runs fn in a transaction and commits it. If any statement finds the
+// database busy the transaction is rolled back and fn runs again, within the
+// same budget as single statements.
+func (db *retryDB) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return db.retry(ctx, func() error {
+		tx, err := db.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// QueryContext runs the query and steps to the first row, which is where
+// SQLite takes its lock, so that a busy database is retried here rather than
+// surfacing from rows.Next.
+func (db *retryDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*retryRows, error) {
+	var rows *retryRows
+	err := db.retry(ctx, func() error {
+		r, err := db.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		rows = &retryRows{Rows: r, peeked: true, hasRow: r.Next()}
+		if !rows.hasRow && isBusy(r.Err()) {
+			r.Close()
+			return r.Err()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// retryRows is what QueryContext returns. It iterates like *sql.Rows; the
+// first row has already been stepped to.
+type retryRows struct {
+	*sql.Rows
+	peeked bool
+	hasRow bool
+}
+
+// Next prepares the next row for Scan, like (*sql.Rows).Next.
+func (r *retryRows) Next() bool {
+	if r.peeked {
+		r.peeked = false
+		return r.hasRow
+	}
+	return r.Rows.Next()
+}
+
+// Err returns the error hit during iteration, like (*sql.Rows).Err, with busy
+// errors mapped to store.ErrBusy.
+func (r *retryRows) Err() error {
+	err := r.Rows.Err()
+	if isBusy(err) {
+		return fmt.Errorf("%w: %v", store.ErrBusy, err)
+	}
+	return err
+}
+
+// retryRow is what QueryRowContext returns; it scans like *sql.Row.
+type retryRow struct {
+	rows *retryRows
+	err  error
+}
+
+// QueryRowContext runs the query through QueryContext, so the single row is
+// stepped to, and retried if busy, before Scan is called.
+func (db *retryDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *retryRow {
+	rows, err := db.QueryContext(ctx, query, args...)
+	return &retryRow{rows: rows, err: err}
+}
+
+// Scan copies the columns of the row into dest, like (*sql.Row).Scan.
+func (r *retryRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	return r.rows.Close()
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+)
+
+// concurrentWrites runs writers goroutines, each creating, updating and
+// reading configs through its own SQLiteStore on the same file, and returns every
+// error they hit. busy_timeout is 0 so SQLite reports contention at once
+// instead of waiting in the driver.
+func concurrentWrites(t *testing.T, budget time.Duration, writers, perWriter int) []error {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "busy.db") + "?_busy_timeout=0"
+
+	stores := make([]*SQLiteStore, writers)
+	for i := range stores {
+		st, err := NewSQLiteStore(dsn)
+		require.NoError(t, err)
+		st.SetBusyRetryBudget(budget)
+		t.Cleanup(func() { st.Close() })
+		stores[i] = st
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for w, st := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := context.Background()
+			for i := 0; i < perWriter; i++ {
+				config := &models.XrayConfig{Name: fmt.Sprintf("w%d-%d", w, i)}
+				err := st.CreateXrayConfig(ctx, config)
+				if err == nil {
+					config.Description = "updated"
+					err = st.UpdateXrayConfig(ctx, config)
+				}
+				if err == nil {
+					_, err = st.GetXrayConfig(ctx, config.ID)
+				}
+				if err == nil {
+					_, err = st.ListXrayConfigs(ctx, 10, 0, defaultOrder)
+				}
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestBusyRetry_ConcurrentWriters(t *testing.T) {
+	errs := concurrentWrites(t, 10*time.Second, 8, 25)
+	assert.Empty(t, errs, "no busy error may escape while the retry budget lasts")
+}
+
+func TestBusyRetry_ExhaustedBudgetIsTyped(t *testing.T) {
+	errs := concurrentWrites(t, 0, 8, 25)
+	require.NotEmpty(t, errs, "without retries the writers must collide")
+	for _, err := range errs {
+		assert.True(t, errors.Is(err, store.ErrBusy), "raw error escaped: %v", err)
+	}
+}
+
+func TestBusyRetry_CancelledContext(t *testing.T) {
+	db := &retryDB{budget: time.Minute}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := db.retry(ctx, func() error { return sqlite3.Error{Code: sqlite3.ErrBusy} })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, store.ErrBusy, "a client that went away is not a busy store")
+}
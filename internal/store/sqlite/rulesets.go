@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// ruleSetColumns lists the rule_sets columns in the order scanRuleSet expects.
+const ruleSetColumns = `id, tag, type, format, url, path, content, download_detour, update_interval, created_at, updated_at`
+
+func scanRuleSet(row rowScanner) (*models.SingBoxRuleSet, error) {
+	rs := &models.SingBoxRuleSet{}
+	err := row.Scan(
+		&rs.ID, &rs.Tag, &rs.Type, &rs.Format, &rs.URL, &rs.Path, &rs.Content,
+		&rs.DownloadDetour, &rs.UpdateInterval, &rs.CreatedAt, &rs.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// CreateRuleSet inserts a new managed rule-set. Tags are unique.
+func (s *SQLiteStore) CreateRuleSet(ctx context.Context, ruleSet *models.SingBoxRuleSet) error {
+	if ruleSet.ID == "" {
+		ruleSet.ID = uuid.NewString()
+	}
+	now := time.Now().UTC()
+	ruleSet.CreatedAt = now
+	ruleSet.UpdatedAt = now
+
+	stmt := `INSERT INTO rule_sets (` + ruleSetColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(
+		ctx, stmt,
+		ruleSet.ID, ruleSet.Tag, ruleSet.Type, ruleSet.Format, ruleSet.URL, ruleSet.Path, ruleSet.Content,
+		ruleSet.DownloadDetour, ruleSet.UpdateInterval, ruleSet.CreatedAt, ruleSet.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert rule set: %w", err)
+	}
+	return nil
+}
+
+// GetRuleSet retrieves a managed rule-set by its ID.
+func (s *SQLiteStore) GetRuleSet(ctx context.Context, id string) (*models.SingBoxRuleSet, error) {
+	stmt := `SELECT ` + ruleSetColumns + ` FROM rule_sets WHERE id = ?`
+	rs, err := scanRuleSet(s.db.QueryRowContext(ctx, stmt, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("rule set with id %s not found: %w", id, sql.ErrNoRows)
+		}
+		return nil, fmt.Errorf("failed to scan rule set: %w", err)
+	}
+	return rs, nil
+}
+
+// ListRuleSets returns all managed rule-sets ordered by tag. The set is
+// expected to be small and the generator needs all of it, so it is not paged.
+func (s *SQLiteStore) ListRuleSets(ctx context.Context) ([]*models.SingBoxRuleSet, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+ruleSetColumns+` FROM rule_sets ORDER BY tag ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule sets: %w", err)
+	}
+	defer rows.Close()
+
+	var ruleSets []*models.SingBoxRuleSet
+	for rows.Next() {
+		rs, err := scanRuleSet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule set row: %w", err)
+		}
+		ruleSets = append(ruleSets, rs)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rule set rows: %w", err)
+	}
+	return ruleSets, nil
+}
+
+// UpdateRuleSet replaces a managed rule-set.
+func (s *SQLiteStore) UpdateRuleSet(ctx context.Context, ruleSet *models.SingBoxRuleSet) error {
+	if ruleSet.ID == "" {
+		return fmt.Errorf("cannot update rule set: ID is missing")
+	}
+	ruleSet.UpdatedAt = time.Now().UTC()
+
+	stmt := `
+	UPDATE rule_sets SET
+		tag = ?, type = ?, format = ?, url = ?, path = ?, content = ?,
+		download_detour = ?, update_interval = ?, updated_at = ?
+	WHERE id = ?`
+	result, err := s.db.ExecContext(
+		ctx, stmt,
+		ruleSet.Tag, ruleSet.Type, ruleSet.Format, ruleSet.URL, ruleSet.Path, ruleSet.Content,
+		ruleSet.DownloadDetour, ruleSet.UpdateInterval, ruleSet.UpdatedAt,
+		ruleSet.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update rule set: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for rule set update: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("rule set with id %s not found for update: %w", ruleSet.ID, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// DeleteRuleSet removes a managed rule-set. Configs still referencing its tag
+// will fail validation until the reference is removed.
+func (s *SQLiteStore) DeleteRuleSet(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM rule_sets WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule set: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for rule set delete: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("rule set with id %s not found for deletion: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
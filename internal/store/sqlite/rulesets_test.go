@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestRuleSetCRUD(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	rs := &models.SingBoxRuleSet{
+		Tag: "geosite-cn", Type: models.RuleSetTypeRemote, Format: models.RuleSetFormatBinary,
+		URL: "https://example.com/geosite-cn.srs", UpdateInterval: "1d",
+	}
+	require.NoError(t, store.CreateRuleSet(ctx, rs))
+	require.NotEmpty(t, rs.ID)
+
+	got, err := store.GetRuleSet(ctx, rs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "geosite-cn", got.Tag)
+	assert.Equal(t, "1d", got.UpdateInterval)
+
+	dup := &models.SingBoxRuleSet{Tag: "geosite-cn", Type: models.RuleSetTypeLocal, Format: models.RuleSetFormatSource}
+	err = store.CreateRuleSet(ctx, dup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "UNIQUE constraint failed: rule_sets.tag")
+
+	require.NoError(t, store.CreateRuleSet(ctx, &models.SingBoxRuleSet{Tag: "ads", Type: models.RuleSetTypeLocal, Format: models.RuleSetFormatSource, Path: "ads.json"}))
+	list, err := store.ListRuleSets(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "ads", list[0].Tag, "ordered by tag")
+
+	rs.URL = "https://example.com/v2/geosite-cn.srs"
+	require.NoError(t, store.UpdateRuleSet(ctx, rs))
+	got, err = store.GetRuleSet(ctx, rs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rs.URL, got.URL)
+
+	require.NoError(t, store.DeleteRuleSet(ctx, rs.ID))
+	_, err = store.GetRuleSet(ctx, rs.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, store.DeleteRuleSet(ctx, rs.ID), sql.ErrNoRows)
+}
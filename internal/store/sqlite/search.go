@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// snippetRadius is how many characters of context a search snippet keeps on
+// each side of the match.
+const snippetRadius = 30
+
+// Search finds configs whose name, description or inbound summary contain
+// query, case-insensitively. types selects the resource types to search (all
+// when empty). Each type is capped at limitPerType results, ranked exact name
+// match first, then name prefix, name substring, description and inbounds.
+// Inbounds are matched on their tag, protocol, listen address and port only,
+// so protocol settings such as passwords never reach a snippet.
+func (s *SQLiteStore) Search(ctx context.Context, query string, types []string, limitPerType int) (*models.SearchResults, error) {
+	results := &models.SearchResults{XrayConfigs: []models.SearchResult{}, SingBoxConfigs: []models.SearchResult{}}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return results, nil
+	}
+	if len(types) == 0 {
+		types = []string{models.SearchTypeXrayConfig, models.SearchTypeSingBoxConfig}
+	}
+	limit := s.pagination.Limit(limitPerType)
+
+	for _, t := range types {
+		var table string
+		var dest *[]models.SearchResult
+		switch t {
+		case models.SearchTypeXrayConfig:
+			table, dest = "xray_configs", &results.XrayConfigs
+		case models.SearchTypeSingBoxConfig:
+			table, dest = "singbox_configs", &results.SingBoxConfigs
+		default:
+			return nil, fmt.Errorf("unknown search type %q", t)
+		}
+		hits, err := s.searchConfigTable(ctx, table, t, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		*dest = hits
+	}
+	return results, nil
+}
+
+// inboundSummary is an SQL expression listing the non-secret fields of each
+// inbound, for example "vless-in vless 0.0.0.0 443", separated by ", ". It
+// covers both the Xray and the sing-box field names.
+const inboundSummary = `(
+    SELECT group_concat(concat_ws(' ',
+        json_extract(value, '$.tag'), json_extract(value, '$.protocol'), json_extract(value, '$.type'),
+        json_extract(value, '$.listen'), json_extract(value, '$.port'), json_extract(value, '$.listen_port')), ', ')
+    FROM json_each(CASE WHEN json_valid(inbounds) THEN inbounds END))`
+
+func (s *SQLiteStore) searchConfigTable(ctx context.Context, table, resourceType, query string, limit int) ([]models.SearchResult, error) {
+	escaped := escapeLike(query)
+	contains := "%" + escaped + "%"
+	stmt := `
+    SELECT id, name, description, inbounds FROM (
+        SELECT id, name, description, ` + inboundSummary + ` AS inbounds FROM ` + table + `
+    )
+    WHERE name LIKE ?1 ESCAPE '\' OR description LIKE ?1 ESCAPE '\' OR inbounds LIKE ?1 ESCAPE '\'
+    ORDER BY CASE
+        WHEN lower(name) = lower(?2) THEN 0
+        WHEN name LIKE ?3 ESCAPE '\' THEN 1
+        WHEN name LIKE ?1 ESCAPE '\' THEN 2
+        WHEN description LIKE ?1 ESCAPE '\' THEN 3
+        ELSE 4
+    END, name
+    LIMIT ?4`
+
+	rows, err := s.db.QueryContext(ctx, stmt, contains, query, escaped+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	hits := []models.SearchResult{}
+	for rows.Next() {
+		var id, name string
+		var description, inbounds sql.NullString
+		if err := rows.Scan(&id, &name, &description, &inbounds); err != nil {
+			return nil, fmt.Errorf("failed to scan %s search row: %w", table, err)
+		}
+		hit := models.SearchResult{Type: resourceType, ID: id, Name: name}
+		for _, field := range []struct{ name, value string }{
+			{"name", name}, {"description", description.String}, {"inbounds", inbounds.String},
+		} {
+			if snippet, ok := searchSnippet(field.value, query); ok {
+				hit.Field, hit.Snippet = field.name, snippet
+				break
+			}
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s search rows: %w", table, err)
+	}
+	return hits, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// searchSnippet returns the part of text around the first case-insensitive
+// occurrence of query.
+func searchSnippet(text, query string) (string, bool) {
+	i := strings.Index(strings.ToLower(text), strings.ToLower(query))
+	if i < 0 {
+		return "", false
+	}
+	start, end := i-snippetRadius, i+len(query)+snippetRadius
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(text) {
+		end, suffix = len(text), ""
+	}
+	if start > end { // lower-casing changed the byte length
+		start = end
+	}
+	return prefix + strings.ToValidUTF8(text[start:end], "") + suffix, true
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func searchNames(hits []models.SearchResult) []string {
+	names := make([]string, 0, len(hits))
+	for _, h := range hits {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+func TestSearch(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, c := range []*models.XrayConfig{
+		{Name: "frankfurt-2"},
+		{Name: "edge", Description: "Primary node in Frankfurt"},
+		{Name: "old-frankfurt"},
+		{Name: "Frankfurt"},
+		{Name: "tls", Inbounds: []models.InboundObject{{Tag: "vless-in", Protocol: "vless", Port: float64(443)}}},
+		{Name: "100%_done"},
+	} {
+		require.NoError(t, store.CreateXrayConfig(ctx, c))
+	}
+	require.NoError(t, store.CreateSingBoxConfig(ctx, &models.SingBoxConfig{Name: "frankfurt client"}))
+
+	results, err := store.Search(ctx, "frankfurt", nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Frankfurt", "frankfurt-2", "old-frankfurt", "edge"}, searchNames(results.XrayConfigs),
+		"exact name first, then prefix, substring and description matches")
+	assert.Equal(t, "name", results.XrayConfigs[0].Field)
+	assert.Equal(t, "description", results.XrayConfigs[3].Field)
+	assert.Equal(t, "Primary node in Frankfurt", results.XrayConfigs[3].Snippet)
+	assert.Equal(t, []string{"frankfurt client"}, searchNames(results.SingBoxConfigs))
+
+	// Capped per type and restricted to the requested types
+	results, err = store.Search(ctx, "frankfurt", []string{models.SearchTypeXrayConfig}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Frankfurt", "frankfurt-2"}, searchNames(results.XrayConfigs))
+	assert.Empty(t, results.SingBoxConfigs)
+
+	// Ports are found in the inbounds
+	results, err = store.Search(ctx, "443", nil, 10)
+	require.NoError(t, err)
+	require.Len(t, results.XrayConfigs, 1)
+	assert.Equal(t, "tls", results.XrayConfigs[0].Name)
+	assert.Equal(t, "inbounds", results.XrayConfigs[0].Field)
+	assert.Contains(t, results.XrayConfigs[0].Snippet, "443")
+
+	// Protocol settings are neither searched nor shown in snippets
+	require.NoError(t, store.CreateXrayConfig(ctx, &models.XrayConfig{Name: "ss", Inbounds: []models.InboundObject{{
+		Tag: "ss-in", Protocol: "shadowsocks", Port: float64(8388),
+		Settings: map[string]interface{}{"method": "aes-256-gcm", "password": "SuperSecretPw123"},
+	}}}))
+	results, err = store.Search(ctx, "aes-256-gcm", nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, results.XrayConfigs)
+	results, err = store.Search(ctx, "shadowsocks", nil, 10)
+	require.NoError(t, err)
+	require.Len(t, results.XrayConfigs, 1)
+	assert.Equal(t, "ss-in shadowsocks 8388", results.XrayConfigs[0].Snippet)
+	assert.NotContains(t, results.XrayConfigs[0].Snippet, "SuperSecretPw123")
+
+	// LIKE wildcards in the query are literal
+	results, err = store.Search(ctx, "%_", nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100%_done"}, searchNames(results.XrayConfigs))
+
+	_, err = store.Search(ctx, "x", []string{"nodes"}, 10)
+	assert.Error(t, err)
+}
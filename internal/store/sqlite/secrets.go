@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/secrets"
+)
+
+// sealedTable lists the JSON columns of a table that may hold sensitive
+// values and therefore go through the sealer, and the table's key column.
+type sealedTable struct {
+	name    string
+	key     string
+	columns []string
+}
+
+var sealedTables = []sealedTable{
+	{name: "xray_configs", key: "id", columns: []string{"inbounds", "outbounds"}},
+	{name: "singbox_configs", key: "id", columns: []string{"inbounds", "outbounds", "endpoints_config"}},
+	// Cached create responses are full configs
+	{name: "idempotency_keys", key: "key_hash", columns: []string{"response"}},
+}
+
+// SetSealer enables encryption of sensitive values at rest. Rows written
+// before, or while no sealer was set, stay readable and are encrypted by
+// RotateDataKeys. It is meant to be called once at startup.
+func (s *SQLiteStore) SetSealer(sealer *secrets.Sealer) {
+	s.sealer = sealer
+}
+
+// marshalSealed is marshalToJSON followed by encryption of sensitive values.
+func (s *SQLiteStore) marshalSealed(v interface{}) (sql.NullString, error) {
+	ns, err := marshalToJSON(v)
+	if err != nil || s.sealer == nil || !ns.Valid {
+		return ns, err
+	}
+	sealed, err := s.sealer.SealDocument([]byte(ns.String))
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("encrypt sensitive values: %w", err)
+	}
+	return sql.NullString{String: string(sealed), Valid: true}, nil
+}
+
+// unmarshalSealed is unmarshalFromJSON for columns written by marshalSealed.
+// Encrypted values found without a sealer are an error rather than being
+// handed out as ciphertext.
+func (s *SQLiteStore) unmarshalSealed(ns sql.NullString, ptr interface{}) error {
+	if ns.Valid && strings.Contains(ns.String, secrets.Prefix) {
+		if s.sealer == nil {
+			return fmt.Errorf("column holds encrypted values but no %s is configured", secrets.EnvMasterKey)
+		}
+		opened, err := s.sealer.OpenDocument([]byte(ns.String))
+		if err != nil {
+			return fmt.Errorf("decrypt sensitive values: %w", err)
+		}
+		ns.String = string(opened)
+	}
+	return unmarshalFromJSON(ns, ptr)
+}
+
+// RotateDataKeys re-encrypts sensitive values sealed with a previous master
+// key, and encrypts legacy plaintext ones, with the current master key. It
+// returns how many rows were rewritten and is a no-op without a sealer. Row
+// timestamps and content hashes are unchanged since the plaintext is. Rows
+// updated while the rotation runs are left alone; they were written with
+// the current key and anything left over is picked up by the next run.
+func (s *SQLiteStore) RotateDataKeys(ctx context.Context) (int, error) {
+	if s.sealer == nil {
+		return 0, nil
+	}
+	rotated := 0
+	for _, table := range sealedTables {
+		updates, err := s.resealTable(ctx, table)
+		if err != nil {
+			return rotated, err
+		}
+		n, err := s.rewriteResealed(ctx, table, updates)
+		rotated += n
+		if err != nil {
+			return rotated, err
+		}
+	}
+	return rotated, nil
+}
+
+// resealed holds the columns of a row as read and as resealed.
+type resealed struct {
+	old, new []interface{}
+}
+
+// resealTable reads the sealed columns of every row in table and returns the
+// resealed values of the rows that changed, keyed by the key column.
+func (s *SQLiteStore) resealTable(ctx context.Context, t sealedTable) (map[string]resealed, error) {
+	table, columns := t.name, t.columns
+	rows, err := s.db.QueryContext(ctx, `SELECT `+t.key+`, `+strings.Join(columns, ", ")+` FROM `+table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	updates := make(map[string]resealed)
+	for rows.Next() {
+		var id string
+		values := make([]sql.NullString, len(columns))
+		dest := []interface{}{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s row: %w", table, err)
+		}
+
+		changed := false
+		row := resealed{old: make([]interface{}, len(columns)), new: make([]interface{}, len(columns))}
+		for i, v := range values {
+			row.old[i], row.new[i] = v, v
+			if !v.Valid || v.String == "" || v.String == "null" {
+				continue
+			}
+			out, c, err := s.sealer.ResealDocument([]byte(v.String))
+			if err != nil {
+				return nil, fmt.Errorf("reseal %s.%s of %s: %w", table, columns[i], id, err)
+			}
+			if c {
+				row.new[i] = sql.NullString{String: string(out), Valid: true}
+				changed = true
+			}
+		}
+		if changed {
+			updates[id] = row
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s rows: %w", table, err)
+	}
+	return updates, nil
+}
+
+// rewriteResealed writes the resealed values of each row, provided its
+// columns still hold what resealTable read, and returns how many rows were
+// rewritten.
+func (s *SQLiteStore) rewriteResealed(ctx context.Context, t sealedTable, updates map[string]resealed) (int, error) {
+	table, columns := t.name, t.columns
+	stmt := `UPDATE ` + table + ` SET ` + strings.Join(columns, " = ?, ") + ` = ? WHERE ` + t.key + ` = ? AND ` +
+		strings.Join(columns, " IS ? AND ") + ` IS ?`
+	rewritten := 0
+	for id, row := range updates {
+		args := append(append(append([]interface{}{}, row.new...), id), row.old...)
+		result, err := s.db.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to rewrite %s row %s: %w", table, id, err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			rewritten++
+		}
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/secrets"
+)
+
+func newTestSealer(t *testing.T, current byte, previous ...byte) *secrets.Sealer {
+	t.Helper()
+	var prev [][]byte
+	for _, b := range previous {
+		prev = append(prev, bytes.Repeat([]byte{b}, 32))
+	}
+	sealer, err := secrets.NewSealer(bytes.Repeat([]byte{current}, 32), prev...)
+	require.NoError(t, err)
+	return sealer
+}
+
+func wireguardConfig(name string) *models.XrayConfig {
+	return &models.XrayConfig{
+		Name: name,
+		Inbounds: []models.InboundObject{{
+			Tag: "wg-in", Protocol: "wireguard", Port: 51820,
+			Settings: map[string]interface{}{
+				"secretKey": "super-secret-private-key",
+				"peers":     []interface{}{map[string]interface{}{"publicKey": "peer-public-key"}},
+			},
+		}},
+	}
+}
+
+func rawInbounds(t *testing.T, st *SQLiteStore, id string) string {
+	t.Helper()
+	var raw string
+	require.NoError(t, st.db.QueryRow(`SELECT inbounds FROM xray_configs WHERE id = ?`, id).Scan(&raw))
+	return raw
+}
+
+func TestSealedColumns(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	legacy := wireguardConfig("legacy")
+	require.NoError(t, st.CreateXrayConfig(ctx, legacy))
+	assert.Contains(t, rawInbounds(t, st, legacy.ID), "super-secret-private-key")
+
+	st.SetSealer(newTestSealer(t, 1))
+	config := wireguardConfig("sealed")
+	require.NoError(t, st.CreateXrayConfig(ctx, config))
+	raw := rawInbounds(t, st, config.ID)
+	assert.NotContains(t, raw, "super-secret-private-key")
+	assert.Contains(t, raw, secrets.Prefix)
+	assert.Contains(t, raw, "peer-public-key", "only sensitive values are encrypted")
+
+	got, err := st.GetXrayConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "super-secret-private-key", got.Inbounds[0].Settings["secretKey"])
+	assert.Equal(t, legacy.ConfigHash, got.ConfigHash, "hashes cover the plaintext")
+
+	got, err = st.GetXrayConfig(ctx, legacy.ID)
+	require.NoError(t, err, "legacy plaintext rows still load")
+	assert.Equal(t, "super-secret-private-key", got.Inbounds[0].Settings["secretKey"])
+
+	// Without the key, encrypted rows fail instead of leaking ciphertext
+	st.SetSealer(nil)
+	_, err = st.GetXrayConfig(ctx, config.ID)
+	assert.Error(t, err)
+}
+
+func TestRotateDataKeys(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	legacy := wireguardConfig("legacy")
+	require.NoError(t, st.CreateXrayConfig(ctx, legacy))
+	st.SetSealer(newTestSealer(t, 1))
+	config := wireguardConfig("sealed")
+	require.NoError(t, st.CreateXrayConfig(ctx, config))
+	wg := &models.SingBoxConfig{Name: "wg", Endpoints: []map[string]interface{}{{
+		"type": "wireguard", "tag": "wg-ep", "private_key": "wg-private-key", "address": "10.0.0.2/32",
+		"peers": []interface{}{map[string]interface{}{"public_key": base64.StdEncoding.EncodeToString(make([]byte, 32)), "allowed_ips": "0.0.0.0/0"}},
+	}}}
+	require.NoError(t, st.CreateSingBoxConfig(ctx, wg))
+
+	rotated := newTestSealer(t, 2, 1)
+	st.SetSealer(rotated)
+	n, err := st.RotateDataKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n, "the legacy row is encrypted, the other two re-encrypted")
+
+	n, err = st.RotateDataKeys(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// The retired key is no longer needed
+	st.SetSealer(newTestSealer(t, 2))
+	for _, id := range []string{legacy.ID, config.ID} {
+		raw := rawInbounds(t, st, id)
+		assert.NotContains(t, raw, "super-secret-private-key")
+		got, err := st.GetXrayConfig(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "super-secret-private-key", got.Inbounds[0].Settings["secretKey"])
+	}
+	got, err := st.GetSingBoxConfig(ctx, wg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "wg-private-key", got.Endpoints[0]["private_key"])
+}
+
+func TestRotateDataKeys_SkipsRowsUpdatedMeanwhile(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := wireguardConfig("legacy")
+	require.NoError(t, st.CreateXrayConfig(ctx, config))
+	st.SetSealer(newTestSealer(t, 1))
+
+	table := sealedTables[0]
+	updates, err := st.resealTable(ctx, table)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+
+	// An edit lands between the read and the rewrite
+	config.Inbounds[0].Settings["secretKey"] = "edited-private-key"
+	require.NoError(t, st.UpdateXrayConfig(ctx, config))
+
+	n, err := st.rewriteResealed(ctx, table, updates)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	got, err := st.GetXrayConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited-private-key", got.Inbounds[0].Settings["secretKey"])
+}
+
+func TestSealedIdempotencyResponse(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	response, err := json.Marshal(wireguardConfig("cached"))
+	require.NoError(t, err)
+	record := &models.IdempotencyRecord{KeyHash: "k", Operation: "op", Response: response, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, st.SaveIdempotencyRecord(ctx, record))
+
+	st.SetSealer(newTestSealer(t, 1))
+	n, err := st.RotateDataKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "the legacy plaintext response is encrypted")
+
+	var raw string
+	require.NoError(t, st.db.QueryRow(`SELECT response FROM idempotency_keys WHERE key_hash = ?`, "k").Scan(&raw))
+	assert.NotContains(t, raw, "super-secret-private-key")
+
+	got, err := st.GetIdempotencyRecord(ctx, "k")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(response), string(got.Response))
+}
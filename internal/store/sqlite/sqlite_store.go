@@ -5,16 +5,24 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
 	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/secrets"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+	"github.com/tools4net/ezfw/backend/internal/version"
 )
 
 // SQLiteStore implements the store.Store interface using SQLite.
 type SQLiteStore struct {
-	db *sql.DB
+	db         *retryDB
+	pagination store.Pagination
+	sealer     *secrets.Sealer // Encrypts sensitive values at rest when set
 }
 
 // NewSQLiteStore creates a new SQLiteStore and initializes the database schema.
@@ -29,7 +37,10 @@ func NewSQLiteStore(dataSourceName string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to ping sqlite database: %w", err)
 	}
 
-	store := &SQLiteStore{db: db}
+	store := &SQLiteStore{
+		db:         &retryDB{DB: db, budget: DefaultBusyRetryBudget},
+		pagination: store.DefaultPagination,
+	}
 	if err := store.initSchema(); err != nil {
 		db.Close() // Close the DB if schema init fails
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
@@ -38,6 +49,18 @@ func NewSQLiteStore(dataSourceName string) (*SQLiteStore, error) {
 	return store, nil
 }
 
+// SetPagination replaces the default and maximum page sizes used by the List
+// methods. It is meant to be called once at startup.
+func (s *SQLiteStore) SetPagination(p store.Pagination) {
+	s.pagination = p
+}
+
+// SetBusyRetryBudget sets how long statements are retried while the database
+// is locked before store.ErrBusy is returned.
+func (s *SQLiteStore) SetBusyRetryBudget(budget time.Duration) {
+	s.db.budget = budget
+}
+
 // initSchema creates the necessary tables if they don't exist.
 func (s *SQLiteStore) initSchema() error {
 	createSingBoxTableSQL := `
@@ -87,6 +110,147 @@ func (s *SQLiteStore) initSchema() error {
 	if _, err := s.db.Exec(createXrayTableSQL); err != nil {
 		return fmt.Errorf("failed to create xray_configs table: %w", err)
 	}
+
+	createRuleSetsTableSQL := `
+	CREATE TABLE IF NOT EXISTS rule_sets (
+		id TEXT PRIMARY KEY,
+		tag TEXT UNIQUE NOT NULL,
+		type TEXT NOT NULL,
+		format TEXT NOT NULL,
+		url TEXT,
+		path TEXT,
+		content TEXT,
+		download_detour TEXT,
+		update_interval TEXT,
+		created_at DATETIME,
+		updated_at DATETIME
+	);`
+	if _, err := s.db.Exec(createRuleSetsTableSQL); err != nil {
+		return fmt.Errorf("failed to create rule_sets table: %w", err)
+	}
+
+	createIdempotencyKeysTableSQL := `
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key_hash TEXT PRIMARY KEY,
+		operation TEXT NOT NULL,
+		response TEXT NOT NULL,
+		created_at DATETIME,
+		expires_at DATETIME
+	);`
+	if _, err := s.db.Exec(createIdempotencyKeysTableSQL); err != nil {
+		return fmt.Errorf("failed to create idempotency_keys table: %w", err)
+	}
+
+	createJobRunsTableSQL := `
+	CREATE TABLE IF NOT EXISTS job_runs (
+		id TEXT PRIMARY KEY,
+		job TEXT NOT NULL,
+		trigger TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		outcome TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs (job, started_at);`
+	if _, err := s.db.Exec(createJobRunsTableSQL); err != nil {
+		return fmt.Errorf("failed to create job_runs table: %w", err)
+	}
+
+	createClientIndexTableSQL := `
+	CREATE TABLE IF NOT EXISTS client_index (
+		email TEXT NOT NULL,
+		config_type TEXT NOT NULL,
+		config_id TEXT NOT NULL,
+		inbound_tag TEXT NOT NULL,
+		protocol TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_client_index_email ON client_index (email);
+	CREATE INDEX IF NOT EXISTS idx_client_index_config ON client_index (config_type, config_id);`
+	if _, err := s.db.Exec(createClientIndexTableSQL); err != nil {
+		return fmt.Errorf("failed to create client_index table: %w", err)
+	}
+
+	// Columns added after the initial schema. ensureColumn adds them to
+	// databases created by older versions.
+	for _, table := range []string{"singbox_configs", "xray_configs"} {
+		if err := s.ensureColumn(table, "environment", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := s.ensureColumn(table, "promoted_from", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := s.ensureColumn(table, "model_version", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := s.ensureColumn(table, "content_hash", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := s.ensureColumn(table, "lint_suppress", "TEXT"); err != nil {
+			return err
+		}
+		if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_` + table + `_content_hash ON ` + table + ` (content_hash)`); err != nil {
+			return fmt.Errorf("failed to index %s.content_hash: %w", table, err)
+		}
+	}
+	if err := s.ensureColumn("xray_configs", "services_config", "TEXT"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn("xray_configs", "managed_sections", "TEXT"); err != nil {
+		return err
+	}
+	return s.backfillContentHashes()
+}
+
+// backfillContentHashes stores the content hash of rows written before the
+// content_hash column existed. Scanning a config computes its hash.
+func (s *SQLiteStore) backfillContentHashes() error {
+	ctx := context.Background()
+	singBoxes, err := s.querySingBoxConfigs(ctx, `SELECT `+singBoxColumns+` FROM singbox_configs WHERE content_hash = ''`)
+	if err != nil {
+		return err
+	}
+	for _, config := range singBoxes {
+		if _, err := s.db.ExecContext(ctx, `UPDATE singbox_configs SET content_hash = ? WHERE id = ?`, config.ConfigHash, config.ID); err != nil {
+			return fmt.Errorf("failed to backfill singbox content hash: %w", err)
+		}
+	}
+	xrays, err := s.queryXrayConfigs(ctx, `SELECT `+xrayColumns+` FROM xray_configs WHERE content_hash = ''`)
+	if err != nil {
+		return err
+	}
+	for _, config := range xrays {
+		if _, err := s.db.ExecContext(ctx, `UPDATE xray_configs SET content_hash = ? WHERE id = ?`, config.ConfigHash, config.ID); err != nil {
+			return fmt.Errorf("failed to backfill xray content hash: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensureColumn adds column to table with the given declaration unless the
+// table already has it.
+func (s *SQLiteStore) ensureColumn(table, column, decl string) error {
+	rows, err := s.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s columns: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to scan %s column: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect %s columns: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, decl)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
 	return nil
 }
 
@@ -195,6 +359,10 @@ func marshalToJSON(v interface{}) (sql.NullString, error) {
 		if val == nil {
 			return sql.NullString{}, nil
 		}
+	case *models.XrayServices:
+		if val == nil {
+			return sql.NullString{}, nil
+		}
 	// Generic map/slice types (often used for placeholders)
 	case *map[string]interface{}: // For SingBox Experimental, etc.
 		if val == nil {
@@ -231,290 +399,212 @@ func unmarshalFromJSON(ns sql.NullString, ptr interface{}) error {
 	return json.Unmarshal([]byte(ns.String), ptr)
 }
 
-// --- SingBox Methods ---
-
-// CreateSingBoxConfig creates a new SingBox configuration.
-func (s *SQLiteStore) CreateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) error {
-	if config.ID == "" {
-		config.ID = uuid.NewString()
-	}
-	now := time.Now().UTC()
-	config.CreatedAt = now
-	config.UpdatedAt = now
-
-	logJSON, err := marshalToJSON(config.Log)
-	if err != nil {
-		return fmt.Errorf("marshal Log: %w", err)
-	}
-	dnsJSON, err := marshalToJSON(config.DNS)
-	if err != nil {
-		return fmt.Errorf("marshal DNS: %w", err)
-	}
-	ntpJSON, err := marshalToJSON(config.NTP)
-	if err != nil {
-		return fmt.Errorf("marshal NTP: %w", err)
-	}
-	inboundsJSON, err := marshalToJSON(config.Inbounds)
-	if err != nil {
-		return fmt.Errorf("marshal Inbounds: %w", err)
-	}
-	outboundsJSON, err := marshalToJSON(config.Outbounds)
-	if err != nil {
-		return fmt.Errorf("marshal Outbounds: %w", err)
-	}
-	routeJSON, err := marshalToJSON(config.Route)
-	if err != nil {
-		return fmt.Errorf("marshal Route: %w", err)
-	}
-	experimentalJSON, err := marshalToJSON(config.Experimental)
-	if err != nil {
-		return fmt.Errorf("marshal Experimental: %w", err)
-	}
-	servicesJSON, err := marshalToJSON(config.Services)
-	if err != nil {
-		return fmt.Errorf("marshal Services: %w", err)
-	}
-	endpointsJSON, err := marshalToJSON(config.Endpoints)
-	if err != nil {
-		return fmt.Errorf("marshal Endpoints: %w", err)
-	}
-	certificateJSON, err := marshalToJSON(config.Certificate)
-	if err != nil {
-		return fmt.Errorf("marshal Certificate: %w", err)
-	}
-
-	stmt := `
-    INSERT INTO singbox_configs (
-        id, name, description, created_at, updated_at,
-        log_config, dns_config, ntp_config, inbounds, outbounds, route_config,
-        experimental_config, services_config, endpoints_config, certificate_config
-    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	_, err = s.db.ExecContext(
-		ctx, stmt,
-		config.ID, config.Name, config.Description, config.CreatedAt, config.UpdatedAt,
-		logJSON, dnsJSON, ntpJSON, inboundsJSON, outboundsJSON, routeJSON,
-		experimentalJSON, servicesJSON, endpointsJSON, certificateJSON,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to insert singbox config: %w", err)
-	}
-	return nil
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// GetSingBoxConfig retrieves a SingBox configuration by its ID.
-func (s *SQLiteStore) GetSingBoxConfig(ctx context.Context, id string) (*models.SingBoxConfig, error) {
-	stmt := `
-    SELECT id, name, description, created_at, updated_at,
+// singBoxColumns lists the singbox_configs columns in the order scanSingBoxConfig expects.
+const singBoxColumns = `id, name, description, created_at, updated_at,
            log_config, dns_config, ntp_config, inbounds, outbounds, route_config,
-           experimental_config, services_config, endpoints_config, certificate_config
-    FROM singbox_configs WHERE id = ?`
+           experimental_config, services_config, endpoints_config, certificate_config,
+           environment, promoted_from, model_version, lint_suppress`
 
-	row := s.db.QueryRowContext(ctx, stmt, id)
+// scanSingBoxConfig scans a row selected with singBoxColumns and unmarshals its
+// JSON columns. Scan errors (including sql.ErrNoRows) are returned unwrapped so
+// callers can decide how to report them.
+func (s *SQLiteStore) scanSingBoxConfig(row rowScanner) (*models.SingBoxConfig, error) {
 	config := &models.SingBoxConfig{}
-
 	var logJSON, dnsJSON, ntpJSON, inboundsJSON, outboundsJSON, routeJSON sql.NullString
-	var experimentalJSON, servicesJSON, endpointsJSON, certificateJSON sql.NullString
+	var experimentalJSON, servicesJSON, endpointsJSON, certificateJSON, suppressJSON sql.NullString
 
 	err := row.Scan(
 		&config.ID, &config.Name, &config.Description, &config.CreatedAt, &config.UpdatedAt,
 		&logJSON, &dnsJSON, &ntpJSON, &inboundsJSON, &outboundsJSON, &routeJSON,
 		&experimentalJSON, &servicesJSON, &endpointsJSON, &certificateJSON,
+		&config.Environment, &config.PromotedFrom, &config.ModelVersion, &suppressJSON,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("singbox config with id %s not found: %w", id, sql.ErrNoRows) // Wrap ErrNoRows
-		}
-		return nil, fmt.Errorf("failed to scan singbox config: %w", err)
+		return nil, err
 	}
 
 	if err := unmarshalFromJSON(logJSON, &config.Log); err != nil {
-		return nil, fmt.Errorf("unmarshal Log: %w", err)
+		return nil, fmt.Errorf("unmarshal Log for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(dnsJSON, &config.DNS); err != nil {
-		return nil, fmt.Errorf("unmarshal DNS: %w", err)
+		return nil, fmt.Errorf("unmarshal DNS for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(ntpJSON, &config.NTP); err != nil {
-		return nil, fmt.Errorf("unmarshal NTP: %w", err)
+		return nil, fmt.Errorf("unmarshal NTP for %s: %w", config.ID, err)
 	}
-	if err := unmarshalFromJSON(inboundsJSON, &config.Inbounds); err != nil {
-		return nil, fmt.Errorf("unmarshal Inbounds: %w", err)
+	if err := s.unmarshalSealed(inboundsJSON, &config.Inbounds); err != nil {
+		return nil, fmt.Errorf("unmarshal Inbounds for %s: %w", config.ID, err)
 	}
-	if err := unmarshalFromJSON(outboundsJSON, &config.Outbounds); err != nil {
-		return nil, fmt.Errorf("unmarshal Outbounds: %w", err)
+	if err := s.unmarshalSealed(outboundsJSON, &config.Outbounds); err != nil {
+		return nil, fmt.Errorf("unmarshal Outbounds for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(routeJSON, &config.Route); err != nil {
-		return nil, fmt.Errorf("unmarshal Route: %w", err)
+		return nil, fmt.Errorf("unmarshal Route for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(experimentalJSON, &config.Experimental); err != nil {
-		return nil, fmt.Errorf("unmarshal Experimental: %w", err)
+		return nil, fmt.Errorf("unmarshal Experimental for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(servicesJSON, &config.Services); err != nil {
-		return nil, fmt.Errorf("unmarshal Services: %w", err)
+		return nil, fmt.Errorf("unmarshal Services for %s: %w", config.ID, err)
 	}
-	if err := unmarshalFromJSON(endpointsJSON, &config.Endpoints); err != nil {
-		return nil, fmt.Errorf("unmarshal Endpoints: %w", err)
+	if err := s.unmarshalSealed(endpointsJSON, &config.Endpoints); err != nil {
+		return nil, fmt.Errorf("unmarshal Endpoints for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(certificateJSON, &config.Certificate); err != nil {
-		return nil, fmt.Errorf("unmarshal Certificate: %w", err)
+		return nil, fmt.Errorf("unmarshal Certificate for %s: %w", config.ID, err)
+	}
+	if err := unmarshalFromJSON(suppressJSON, &config.LintSuppress); err != nil {
+		return nil, fmt.Errorf("unmarshal LintSuppress for %s: %w", config.ID, err)
+	}
+	if config.ConfigHash, err = models.CanonicalHashSingBox(config); err != nil {
+		return nil, fmt.Errorf("hash singbox config %s: %w", config.ID, err)
 	}
-
 	return config, nil
 }
 
-// GetXrayConfigByName retrieves an Xray configuration by its name.
-func (s *SQLiteStore) GetXrayConfigByName(ctx context.Context, name string) (*models.XrayConfig, error) {
-	stmt := `
-    SELECT id, name, description, created_at, updated_at,
+// querySingBoxConfigs runs a query selecting singBoxColumns and scans every row.
+func (s *SQLiteStore) querySingBoxConfigs(ctx context.Context, query string, args ...interface{}) ([]*models.SingBoxConfig, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query singbox configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []*models.SingBoxConfig
+	for rows.Next() {
+		config, err := s.scanSingBoxConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan singbox config row: %w", err)
+		}
+		configs = append(configs, config)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating singbox config rows: %w", err)
+	}
+	return configs, nil
+}
+
+// xrayColumns lists the xray_configs columns in the order scanXrayConfig expects.
+const xrayColumns = `id, name, description, created_at, updated_at,
            log_config, api_config, dns_config, routing_config, policy_config,
            inbounds, outbounds, transport_config, stats_config, reverse_config,
-           fakedns_config, metrics_config, observatory_config, burst_observatory_config
-    FROM xray_configs WHERE name = ?`
+           fakedns_config, metrics_config, observatory_config, burst_observatory_config,
+           environment, promoted_from, model_version, services_config, managed_sections, lint_suppress`
 
-	row := s.db.QueryRowContext(ctx, stmt, name)
+// scanXrayConfig scans a row selected with xrayColumns and unmarshals its JSON
+// columns. Scan errors (including sql.ErrNoRows) are returned unwrapped.
+func (s *SQLiteStore) scanXrayConfig(row rowScanner) (*models.XrayConfig, error) {
 	config := &models.XrayConfig{}
-
-	var logJ, apiJ, dnsJ, routingJ, policyJ, inboundsJ, outboundsJ, transportJ, statsJ, reverseJ, fakednsJ, metricsJ, obsJ, burstObsJ sql.NullString
+	var logJ, apiJ, dnsJ, routingJ, policyJ, inboundsJ, outboundsJ, transportJ, statsJ, reverseJ, fakednsJ, metricsJ, obsJ, burstObsJ, servicesJ, managedJ, suppressJ sql.NullString
 
 	err := row.Scan(
 		&config.ID, &config.Name, &config.Description, &config.CreatedAt, &config.UpdatedAt,
 		&logJ, &apiJ, &dnsJ, &routingJ, &policyJ, &inboundsJ, &outboundsJ, &transportJ,
 		&statsJ, &reverseJ, &fakednsJ, &metricsJ, &obsJ, &burstObsJ,
+		&config.Environment, &config.PromotedFrom, &config.ModelVersion, &servicesJ, &managedJ, &suppressJ,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("xray config with name %s not found: %w", name, sql.ErrNoRows)
-		}
-		return nil, fmt.Errorf("failed to scan xray config by name: %w", err)
+		return nil, err
 	}
 
 	// Unmarshal JSON blobs
 	if err := unmarshalFromJSON(logJ, &config.Log); err != nil {
-		return nil, fmt.Errorf("unmarshal Log: %w", err)
+		return nil, fmt.Errorf("unmarshal Log for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(apiJ, &config.API); err != nil {
-		return nil, fmt.Errorf("unmarshal API: %w", err)
+		return nil, fmt.Errorf("unmarshal API for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(dnsJ, &config.DNS); err != nil {
-		return nil, fmt.Errorf("unmarshal DNS: %w", err)
+		return nil, fmt.Errorf("unmarshal DNS for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(routingJ, &config.Routing); err != nil {
-		return nil, fmt.Errorf("unmarshal Routing: %w", err)
+		return nil, fmt.Errorf("unmarshal Routing for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(policyJ, &config.Policy); err != nil {
-		return nil, fmt.Errorf("unmarshal Policy: %w", err)
+		return nil, fmt.Errorf("unmarshal Policy for %s: %w", config.ID, err)
 	}
-	if err := unmarshalFromJSON(inboundsJ, &config.Inbounds); err != nil {
-		return nil, fmt.Errorf("unmarshal Inbounds: %w", err)
+	if err := s.unmarshalSealed(inboundsJ, &config.Inbounds); err != nil {
+		return nil, fmt.Errorf("unmarshal Inbounds for %s: %w", config.ID, err)
 	}
-	if err := unmarshalFromJSON(outboundsJ, &config.Outbounds); err != nil {
-		return nil, fmt.Errorf("unmarshal Outbounds: %w", err)
+	if err := s.unmarshalSealed(outboundsJ, &config.Outbounds); err != nil {
+		return nil, fmt.Errorf("unmarshal Outbounds for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(transportJ, &config.Transport); err != nil {
-		return nil, fmt.Errorf("unmarshal Transport: %w", err)
+		return nil, fmt.Errorf("unmarshal Transport for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(statsJ, &config.Stats); err != nil {
-		return nil, fmt.Errorf("unmarshal Stats: %w", err)
+		return nil, fmt.Errorf("unmarshal Stats for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(reverseJ, &config.Reverse); err != nil {
-		return nil, fmt.Errorf("unmarshal Reverse: %w", err)
+		return nil, fmt.Errorf("unmarshal Reverse for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(fakednsJ, &config.FakeDNS); err != nil {
-		return nil, fmt.Errorf("unmarshal FakeDNS: %w", err)
+		return nil, fmt.Errorf("unmarshal FakeDNS for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(metricsJ, &config.Metrics); err != nil {
-		return nil, fmt.Errorf("unmarshal Metrics: %w", err)
+		return nil, fmt.Errorf("unmarshal Metrics for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(obsJ, &config.Observatory); err != nil {
-		return nil, fmt.Errorf("unmarshal Observatory: %w", err)
+		return nil, fmt.Errorf("unmarshal Observatory for %s: %w", config.ID, err)
 	}
 	if err := unmarshalFromJSON(burstObsJ, &config.BurstObservatory); err != nil {
-		return nil, fmt.Errorf("unmarshal BurstObservatory: %w", err)
+		return nil, fmt.Errorf("unmarshal BurstObservatory for %s: %w", config.ID, err)
 	}
-
-	return config, nil
-}
-
-// ListSingBoxConfigs retrieves a list of SingBox configurations with pagination.
-func (s *SQLiteStore) ListSingBoxConfigs(ctx context.Context, limit, offset int) ([]*models.SingBoxConfig, error) {
-	if limit <= 0 {
-		limit = 10
+	if err := unmarshalFromJSON(servicesJ, &config.Services); err != nil {
+		return nil, fmt.Errorf("unmarshal Services for %s: %w", config.ID, err)
 	}
-	if offset < 0 {
-		offset = 0
+	if err := unmarshalFromJSON(managedJ, &config.ManagedSections); err != nil {
+		return nil, fmt.Errorf("unmarshal ManagedSections for %s: %w", config.ID, err)
 	}
+	if err := unmarshalFromJSON(suppressJ, &config.LintSuppress); err != nil {
+		return nil, fmt.Errorf("unmarshal LintSuppress for %s: %w", config.ID, err)
+	}
+	if config.ConfigHash, err = models.CanonicalHashXray(config); err != nil {
+		return nil, fmt.Errorf("hash xray config %s: %w", config.ID, err)
+	}
+	return config, nil
+}
 
-	stmt := `
-    SELECT id, name, description, created_at, updated_at,
-           log_config, dns_config, ntp_config, inbounds, outbounds, route_config,
-           experimental_config, services_config, endpoints_config, certificate_config
-    FROM singbox_configs ORDER BY updated_at DESC LIMIT ? OFFSET ?`
-
-	rows, err := s.db.QueryContext(ctx, stmt, limit, offset)
+// queryXrayConfigs runs a query selecting xrayColumns and scans every row.
+func (s *SQLiteStore) queryXrayConfigs(ctx context.Context, query string, args ...interface{}) ([]*models.XrayConfig, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query singbox configs: %w", err)
+		return nil, fmt.Errorf("failed to query xray configs: %w", err)
 	}
 	defer rows.Close()
 
-	var configs []*models.SingBoxConfig
+	var configs []*models.XrayConfig
 	for rows.Next() {
-		config := &models.SingBoxConfig{}
-		var logJSON, dnsJSON, ntpJSON, inboundsJSON, outboundsJSON, routeJSON sql.NullString
-		var experimentalJSON, servicesJSON, endpointsJSON, certificateJSON sql.NullString
-
-		err := rows.Scan(
-			&config.ID, &config.Name, &config.Description, &config.CreatedAt, &config.UpdatedAt,
-			&logJSON, &dnsJSON, &ntpJSON, &inboundsJSON, &outboundsJSON, &routeJSON,
-			&experimentalJSON, &servicesJSON, &endpointsJSON, &certificateJSON,
-		)
+		config, err := s.scanXrayConfig(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan singbox config row: %w", err)
-		}
-
-		if err := unmarshalFromJSON(logJSON, &config.Log); err != nil {
-			return nil, fmt.Errorf("unmarshal Log for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(dnsJSON, &config.DNS); err != nil {
-			return nil, fmt.Errorf("unmarshal DNS for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(ntpJSON, &config.NTP); err != nil {
-			return nil, fmt.Errorf("unmarshal NTP for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(inboundsJSON, &config.Inbounds); err != nil {
-			return nil, fmt.Errorf("unmarshal Inbounds for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(outboundsJSON, &config.Outbounds); err != nil {
-			return nil, fmt.Errorf("unmarshal Outbounds for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(routeJSON, &config.Route); err != nil {
-			return nil, fmt.Errorf("unmarshal Route for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(experimentalJSON, &config.Experimental); err != nil {
-			return nil, fmt.Errorf("unmarshal Experimental for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(servicesJSON, &config.Services); err != nil {
-			return nil, fmt.Errorf("unmarshal Services for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(endpointsJSON, &config.Endpoints); err != nil {
-			return nil, fmt.Errorf("unmarshal Endpoints for %s: %w", config.ID, err)
-		}
-		if err := unmarshalFromJSON(certificateJSON, &config.Certificate); err != nil {
-			return nil, fmt.Errorf("unmarshal Certificate for %s: %w", config.ID, err)
+			return nil, fmt.Errorf("failed to scan xray config row: %w", err)
 		}
 		configs = append(configs, config)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating singbox config rows: %w", err)
+		return nil, fmt.Errorf("error iterating xray config rows: %w", err)
 	}
 	return configs, nil
 }
 
-// UpdateSingBoxConfig updates an existing SingBox configuration.
-func (s *SQLiteStore) UpdateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) error {
+// --- SingBox Methods ---
+
+// CreateSingBoxConfig creates a new SingBox configuration.
+func (s *SQLiteStore) CreateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) error {
+	if errs := validation.SingBoxConfigErrors(config); len(errs) > 0 {
+		return fmt.Errorf("cannot create singbox config: %w", errs[0])
+	}
 	if config.ID == "" {
-		return fmt.Errorf("cannot update singbox config: ID is missing")
+		config.ID = uuid.NewString()
 	}
-	config.UpdatedAt = time.Now().UTC()
+	now := time.Now().UTC()
+	config.CreatedAt = now
+	config.UpdatedAt = now
 
 	logJSON, err := marshalToJSON(config.Log)
 	if err != nil {
@@ -528,11 +618,11 @@ func (s *SQLiteStore) UpdateSingBoxConfig(ctx context.Context, config *models.Si
 	if err != nil {
 		return fmt.Errorf("marshal NTP: %w", err)
 	}
-	inboundsJSON, err := marshalToJSON(config.Inbounds)
+	inboundsJSON, err := s.marshalSealed(config.Inbounds)
 	if err != nil {
 		return fmt.Errorf("marshal Inbounds: %w", err)
 	}
-	outboundsJSON, err := marshalToJSON(config.Outbounds)
+	outboundsJSON, err := s.marshalSealed(config.Outbounds)
 	if err != nil {
 		return fmt.Errorf("marshal Outbounds: %w", err)
 	}
@@ -548,7 +638,7 @@ func (s *SQLiteStore) UpdateSingBoxConfig(ctx context.Context, config *models.Si
 	if err != nil {
 		return fmt.Errorf("marshal Services: %w", err)
 	}
-	endpointsJSON, err := marshalToJSON(config.Endpoints)
+	endpointsJSON, err := s.marshalSealed(config.Endpoints)
 	if err != nil {
 		return fmt.Errorf("marshal Endpoints: %w", err)
 	}
@@ -556,69 +646,330 @@ func (s *SQLiteStore) UpdateSingBoxConfig(ctx context.Context, config *models.Si
 	if err != nil {
 		return fmt.Errorf("marshal Certificate: %w", err)
 	}
+	suppressJSON, err := marshalToJSON(config.LintSuppress)
+	if err != nil {
+		return fmt.Errorf("marshal LintSuppress: %w", err)
+	}
+
+	if config.ConfigHash, err = models.CanonicalHashSingBox(config); err != nil {
+		return fmt.Errorf("hash singbox config: %w", err)
+	}
 
 	stmt := `
-    UPDATE singbox_configs SET
-        name = ?, description = ?, updated_at = ?,
-        log_config = ?, dns_config = ?, ntp_config = ?, inbounds = ?, outbounds = ?, route_config = ?,
-        experimental_config = ?, services_config = ?, endpoints_config = ?, certificate_config = ?
-    WHERE id = ?`
+    INSERT INTO singbox_configs (
+        id, name, description, created_at, updated_at,
+        log_config, dns_config, ntp_config, inbounds, outbounds, route_config,
+        experimental_config, services_config, endpoints_config, certificate_config,
+        environment, promoted_from, model_version, content_hash, lint_suppress
+    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := s.db.ExecContext(
-		ctx, stmt,
-		config.Name, config.Description, config.UpdatedAt,
-		logJSON, dnsJSON, ntpJSON, inboundsJSON, outboundsJSON, routeJSON,
-		experimentalJSON, servicesJSON, endpointsJSON, certificateJSON,
-		config.ID,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update singbox config: %w", err)
-	}
-	rowsAffected, err := result.RowsAffected()
+	return s.db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx, stmt,
+			config.ID, config.Name, config.Description, config.CreatedAt, config.UpdatedAt,
+			logJSON, dnsJSON, ntpJSON, inboundsJSON, outboundsJSON, routeJSON,
+			experimentalJSON, servicesJSON, endpointsJSON, certificateJSON,
+			config.Environment, config.PromotedFrom, config.ModelVersion, config.ConfigHash, suppressJSON,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert singbox config: %w", err)
+		}
+		return indexClients(ctx, tx, models.ConfigTypeSingBox, config.ID, models.SingBoxClientPlacements(config))
+	})
+}
+
+// GetSingBoxConfig retrieves a SingBox configuration by its ID.
+func (s *SQLiteStore) GetSingBoxConfig(ctx context.Context, id string) (*models.SingBoxConfig, error) {
+	stmt := `SELECT ` + singBoxColumns + ` FROM singbox_configs WHERE id = ?`
+
+	config, err := s.scanSingBoxConfig(s.db.QueryRowContext(ctx, stmt, id))
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected for singbox update: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("singbox config with id %s not found for update: %w", config.ID, sql.ErrNoRows)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("singbox config with id %s not found: %w", id, sql.ErrNoRows) // Wrap ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to scan singbox config: %w", err)
 	}
-	return nil
+	return config, nil
 }
 
-// DeleteSingBoxConfig deletes a SingBox configuration by its ID.
-func (s *SQLiteStore) DeleteSingBoxConfig(ctx context.Context, id string) error {
-	stmt := `DELETE FROM singbox_configs WHERE id = ?`
-	result, err := s.db.ExecContext(ctx, stmt, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete singbox config: %w", err)
+// GetMultipleXrayConfigs retrieves the Xray configurations with the given IDs
+// in a single query, keyed by ID. IDs that do not exist are absent from the
+// map rather than reported as errors.
+func (s *SQLiteStore) GetMultipleXrayConfigs(ctx context.Context, ids []string) (map[string]*models.XrayConfig, error) {
+	result := make(map[string]*models.XrayConfig, len(ids))
+	if len(ids) == 0 {
+		return result, nil
 	}
-	rowsAffected, err := result.RowsAffected()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs WHERE id IN (` + placeholders + `)`
+
+	configs, err := s.queryXrayConfigs(ctx, stmt, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected for singbox delete: %w", err)
+		return nil, err
 	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("singbox config with id %s not found for deletion: %w", id, sql.ErrNoRows)
+	for _, config := range configs {
+		result[config.ID] = config
 	}
-	return nil
+	return result, nil
 }
 
-// --- Xray Methods ---
+// ListXrayConfigsByHash returns the Xray configs whose canonical content hash
+// is hash, oldest first.
+func (s *SQLiteStore) ListXrayConfigsByHash(ctx context.Context, hash string) ([]models.ConfigRef, error) {
+	groups, err := s.queryDuplicateRefs(ctx, `SELECT content_hash, id, name, created_at FROM xray_configs
+    WHERE content_hash = ? ORDER BY created_at ASC`, hash)
+	if err != nil || len(groups) == 0 {
+		return []models.ConfigRef{}, err
+	}
+	return groups[0].Configs, nil
+}
 
-// CreateXrayConfig creates a new Xray configuration.
-func (s *SQLiteStore) CreateXrayConfig(ctx context.Context, config *models.XrayConfig) error {
+// ListXrayDuplicates groups the Xray configs that share a content hash with
+// at least one other config.
+func (s *SQLiteStore) ListXrayDuplicates(ctx context.Context) ([]models.DuplicateGroup, error) {
+	return s.queryDuplicateRefs(ctx, `SELECT content_hash, id, name, created_at FROM xray_configs
+    WHERE content_hash IN (
+        SELECT content_hash FROM xray_configs WHERE content_hash != '' GROUP BY content_hash HAVING COUNT(*) > 1
+    )
+    ORDER BY content_hash, created_at ASC`)
+}
 
-	if config.ID == "" {
-		config.ID = uuid.NewString()
+// queryDuplicateRefs runs a query selecting content_hash, id, name and
+// created_at, ordered by content_hash, and groups the rows by hash.
+func (s *SQLiteStore) queryDuplicateRefs(ctx context.Context, query string, args ...interface{}) ([]models.DuplicateGroup, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config hashes: %w", err)
 	}
-	now := time.Now().UTC()
-	config.CreatedAt = now
-	config.UpdatedAt = now
+	defer rows.Close()
 
-	var err error
-	logJSON, err := marshalToJSON(config.Log)
-	if err != nil {
-		return fmt.Errorf("marshal Log: %w", err)
+	groups := []models.DuplicateGroup{}
+	for rows.Next() {
+		var hash string
+		var ref models.ConfigRef
+		if err := rows.Scan(&hash, &ref.ID, &ref.Name, &ref.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan config hash row: %w", err)
+		}
+		if len(groups) == 0 || groups[len(groups)-1].ConfigHash != hash {
+			groups = append(groups, models.DuplicateGroup{ConfigHash: hash})
+		}
+		last := &groups[len(groups)-1]
+		last.Configs = append(last.Configs, ref)
 	}
-	apiJSON, err := marshalToJSON(config.API)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating config hash rows: %w", err)
+	}
+	return groups, nil
+}
+
+// GetXrayConfigByName retrieves an Xray configuration by its name.
+func (s *SQLiteStore) GetXrayConfigByName(ctx context.Context, name string) (*models.XrayConfig, error) {
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs WHERE name = ?`
+
+	config, err := s.scanXrayConfig(s.db.QueryRowContext(ctx, stmt, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("xray config with name %s not found: %w", name, sql.ErrNoRows)
+		}
+		return nil, fmt.Errorf("failed to scan xray config by name: %w", err)
+	}
+	return config, nil
+}
+
+// ListSingBoxConfigs retrieves a list of SingBox configurations with pagination.
+func (s *SQLiteStore) ListSingBoxConfigs(ctx context.Context, limit, offset int, sort store.Sort) ([]*models.SingBoxConfig, error) {
+	limit = s.pagination.Limit(limit)
+	if offset < 0 {
+		offset = 0
+	}
+	orderBy, err := orderByClause(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := `SELECT ` + singBoxColumns + ` FROM singbox_configs ` + orderBy + ` LIMIT ? OFFSET ?`
+	return s.querySingBoxConfigs(ctx, stmt, limit, offset)
+}
+
+// CountSingBoxConfigs returns the number of stored SingBox configurations.
+func (s *SQLiteStore) CountSingBoxConfigs(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM singbox_configs`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count singbox configs: %w", err)
+	}
+	return n, nil
+}
+
+// ListSingBoxConfigsUpdatedSince returns all SingBox configurations modified
+// strictly after since, oldest change first, so agents can sync incrementally.
+func (s *SQLiteStore) ListSingBoxConfigsUpdatedSince(ctx context.Context, since time.Time) ([]*models.SingBoxConfig, error) {
+	stmt := `SELECT ` + singBoxColumns + ` FROM singbox_configs WHERE updated_at > ? ORDER BY updated_at ASC`
+	return s.querySingBoxConfigs(ctx, stmt, since.UTC())
+}
+
+// UpdateSingBoxConfig updates an existing SingBox configuration.
+func (s *SQLiteStore) UpdateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) error {
+	if config.ID == "" {
+		return fmt.Errorf("cannot update singbox config: ID is missing")
+	}
+	if errs := validation.SingBoxConfigErrors(config); len(errs) > 0 {
+		return fmt.Errorf("cannot update singbox config: %w", errs[0])
+	}
+	config.UpdatedAt = time.Now().UTC()
+
+	logJSON, err := marshalToJSON(config.Log)
+	if err != nil {
+		return fmt.Errorf("marshal Log: %w", err)
+	}
+	dnsJSON, err := marshalToJSON(config.DNS)
+	if err != nil {
+		return fmt.Errorf("marshal DNS: %w", err)
+	}
+	ntpJSON, err := marshalToJSON(config.NTP)
+	if err != nil {
+		return fmt.Errorf("marshal NTP: %w", err)
+	}
+	inboundsJSON, err := s.marshalSealed(config.Inbounds)
+	if err != nil {
+		return fmt.Errorf("marshal Inbounds: %w", err)
+	}
+	outboundsJSON, err := s.marshalSealed(config.Outbounds)
+	if err != nil {
+		return fmt.Errorf("marshal Outbounds: %w", err)
+	}
+	routeJSON, err := marshalToJSON(config.Route)
+	if err != nil {
+		return fmt.Errorf("marshal Route: %w", err)
+	}
+	experimentalJSON, err := marshalToJSON(config.Experimental)
+	if err != nil {
+		return fmt.Errorf("marshal Experimental: %w", err)
+	}
+	servicesJSON, err := marshalToJSON(config.Services)
+	if err != nil {
+		return fmt.Errorf("marshal Services: %w", err)
+	}
+	endpointsJSON, err := s.marshalSealed(config.Endpoints)
+	if err != nil {
+		return fmt.Errorf("marshal Endpoints: %w", err)
+	}
+	certificateJSON, err := marshalToJSON(config.Certificate)
+	if err != nil {
+		return fmt.Errorf("marshal Certificate: %w", err)
+	}
+	suppressJSON, err := marshalToJSON(config.LintSuppress)
+	if err != nil {
+		return fmt.Errorf("marshal LintSuppress: %w", err)
+	}
+
+	if config.ConfigHash, err = models.CanonicalHashSingBox(config); err != nil {
+		return fmt.Errorf("hash singbox config: %w", err)
+	}
+
+	stmt := `
+    UPDATE singbox_configs SET
+        name = ?, description = ?, updated_at = ?,
+        log_config = ?, dns_config = ?, ntp_config = ?, inbounds = ?, outbounds = ?, route_config = ?,
+        experimental_config = ?, services_config = ?, endpoints_config = ?, certificate_config = ?,
+        environment = ?, promoted_from = ?, model_version = ?, content_hash = ?, lint_suppress = ?
+    WHERE id = ?`
+
+	return s.db.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx, stmt,
+			config.Name, config.Description, config.UpdatedAt,
+			logJSON, dnsJSON, ntpJSON, inboundsJSON, outboundsJSON, routeJSON,
+			experimentalJSON, servicesJSON, endpointsJSON, certificateJSON,
+			config.Environment, config.PromotedFrom, config.ModelVersion, config.ConfigHash, suppressJSON,
+			config.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update singbox config: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected for singbox update: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("singbox config with id %s not found for update: %w", config.ID, sql.ErrNoRows)
+		}
+		return indexClients(ctx, tx, models.ConfigTypeSingBox, config.ID, models.SingBoxClientPlacements(config))
+	})
+}
+
+// DeleteSingBoxConfig deletes a SingBox configuration by its ID.
+func (s *SQLiteStore) DeleteSingBoxConfig(ctx context.Context, id string) error {
+	stmt := `DELETE FROM singbox_configs WHERE id = ?`
+	return s.db.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, stmt, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete singbox config: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected for singbox delete: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("singbox config with id %s not found for deletion: %w", id, sql.ErrNoRows)
+		}
+		return indexClients(ctx, tx, models.ConfigTypeSingBox, id, nil)
+	})
+}
+
+// sortColumns maps store.SortFields to their column. Sort fields are only
+// ever put into SQL through this map.
+var sortColumns = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// orderByClause returns the ORDER BY clause for sort. The zero Sort orders by
+// most recently updated first; ties are broken by id so pages are stable.
+func orderByClause(sort store.Sort) (string, error) {
+	if sort.Field == "" {
+		return "ORDER BY updated_at DESC, id ASC", nil
+	}
+	column, ok := sortColumns[sort.Field]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", store.ErrInvalidSort, sort.Field)
+	}
+	direction := "ASC"
+	if sort.Descending {
+		direction = "DESC"
+	}
+	return "ORDER BY " + column + " " + direction + ", id ASC", nil
+}
+
+// --- Xray Methods ---
+
+// CreateXrayConfig creates a new Xray configuration. An empty ModelVersion
+// defaults to the XRAY_DEFAULT_VERSION environment variable.
+func (s *SQLiteStore) CreateXrayConfig(ctx context.Context, config *models.XrayConfig) error {
+	if errs := validation.XrayConfigErrors(config); len(errs) > 0 {
+		return fmt.Errorf("cannot create xray config: %w", errs[0])
+	}
+	if config.ID == "" {
+		config.ID = uuid.NewString()
+	}
+	if config.ModelVersion == "" {
+		config.ModelVersion = os.Getenv(version.EnvXrayDefaultVersion)
+	}
+	now := time.Now().UTC()
+	config.CreatedAt = now
+	config.UpdatedAt = now
+
+	var err error
+	logJSON, err := marshalToJSON(config.Log)
+	if err != nil {
+		return fmt.Errorf("marshal Log: %w", err)
+	}
+	apiJSON, err := marshalToJSON(config.API)
 	if err != nil {
 		return fmt.Errorf("marshal API: %w", err)
 	}
@@ -634,11 +985,11 @@ func (s *SQLiteStore) CreateXrayConfig(ctx context.Context, config *models.XrayC
 	if err != nil {
 		return fmt.Errorf("marshal Policy: %w", err)
 	}
-	inboundsJSON, err := marshalToJSON(config.Inbounds)
+	inboundsJSON, err := s.marshalSealed(config.Inbounds)
 	if err != nil {
 		return fmt.Errorf("marshal Inbounds: %w", err)
 	}
-	outboundsJSON, err := marshalToJSON(config.Outbounds)
+	outboundsJSON, err := s.marshalSealed(config.Outbounds)
 	if err != nil {
 		return fmt.Errorf("marshal Outbounds: %w", err)
 	}
@@ -670,248 +1021,313 @@ func (s *SQLiteStore) CreateXrayConfig(ctx context.Context, config *models.XrayC
 	if err != nil {
 		return fmt.Errorf("marshal BurstObservatory: %w", err)
 	}
+	servicesJSON, err := marshalToJSON(config.Services)
+	if err != nil {
+		return fmt.Errorf("marshal Services: %w", err)
+	}
+	managedJSON, err := marshalToJSON(config.ManagedSections)
+	if err != nil {
+		return fmt.Errorf("marshal ManagedSections: %w", err)
+	}
+	suppressJSON, err := marshalToJSON(config.LintSuppress)
+	if err != nil {
+		return fmt.Errorf("marshal LintSuppress: %w", err)
+	}
+
+	if config.ConfigHash, err = models.CanonicalHashXray(config); err != nil {
+		return fmt.Errorf("hash xray config: %w", err)
+	}
 
 	stmt := `
     INSERT INTO xray_configs (
         id, name, description, created_at, updated_at,
         log_config, api_config, dns_config, routing_config, policy_config,
         inbounds, outbounds, transport_config, stats_config, reverse_config,
-        fakedns_config, metrics_config, observatory_config, burst_observatory_config
-    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	_, err = s.db.ExecContext(
-		ctx, stmt,
-		config.ID, config.Name, config.Description, config.CreatedAt, config.UpdatedAt,
-		logJSON, apiJSON, dnsJSON, routingJSON, policyJSON,
-		inboundsJSON, outboundsJSON, transportJSON, statsJSON, reverseJSON,
-		fakednsJSON, metricsJSON, observatoryJSON, burstObservatoryJSON,
-	)
+        fakedns_config, metrics_config, observatory_config, burst_observatory_config,
+        environment, promoted_from, model_version, content_hash, services_config, managed_sections, lint_suppress
+    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	return s.db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx, stmt,
+			config.ID, config.Name, config.Description, config.CreatedAt, config.UpdatedAt,
+			logJSON, apiJSON, dnsJSON, routingJSON, policyJSON,
+			inboundsJSON, outboundsJSON, transportJSON, statsJSON, reverseJSON,
+			fakednsJSON, metricsJSON, observatoryJSON, burstObservatoryJSON,
+			config.Environment, config.PromotedFrom, config.ModelVersion, config.ConfigHash, servicesJSON, managedJSON, suppressJSON,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert xray config: %w", err)
+		}
+		return indexClients(ctx, tx, models.ConfigTypeXray, config.ID, models.XrayClientPlacements(config))
+	})
+}
+
+// GetXrayConfigPromotion returns the config promoted from sourceID into
+// environment, or a wrapped sql.ErrNoRows if there is none yet.
+func (s *SQLiteStore) GetXrayConfigPromotion(ctx context.Context, sourceID, environment string) (*models.XrayConfig, error) {
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs WHERE promoted_from = ? AND environment = ? ORDER BY created_at ASC LIMIT 1`
+
+	config, err := s.scanXrayConfig(s.db.QueryRowContext(ctx, stmt, sourceID, environment))
 	if err != nil {
-		return fmt.Errorf("failed to insert xray config: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no %s promotion of xray config %s found: %w", environment, sourceID, sql.ErrNoRows)
+		}
+		return nil, fmt.Errorf("failed to scan promoted xray config: %w", err)
 	}
-	return nil
+	return config, nil
 }
 
 // GetXrayConfig retrieves an Xray configuration by its ID.
 func (s *SQLiteStore) GetXrayConfig(ctx context.Context, id string) (*models.XrayConfig, error) {
-	stmt := `
-    SELECT id, name, description, created_at, updated_at,
-           log_config, api_config, dns_config, routing_config, policy_config,
-           inbounds, outbounds, transport_config, stats_config, reverse_config,
-           fakedns_config, metrics_config, observatory_config, burst_observatory_config
-    FROM xray_configs WHERE id = ?`
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs WHERE id = ?`
 
-	row := s.db.QueryRowContext(ctx, stmt, id)
-	config := &models.XrayConfig{}
-
-	var logJ, apiJ, dnsJ, routingJ, policyJ, inboundsJ, outboundsJ, transportJ, statsJ, reverseJ, fakednsJ, metricsJ, obsJ, burstObsJ sql.NullString
-
-	err := row.Scan(
-		&config.ID, &config.Name, &config.Description, &config.CreatedAt, &config.UpdatedAt,
-		&logJ, &apiJ, &dnsJ, &routingJ, &policyJ, &inboundsJ, &outboundsJ, &transportJ,
-		&statsJ, &reverseJ, &fakednsJ, &metricsJ, &obsJ, &burstObsJ,
-	)
+	config, err := s.scanXrayConfig(s.db.QueryRowContext(ctx, stmt, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("xray config with id %s not found: %w", id, sql.ErrNoRows)
 		}
 		return nil, fmt.Errorf("failed to scan xray config: %w", err)
 	}
+	return config, nil
+}
 
-	// Unmarshal JSON blobs
-	if err := unmarshalFromJSON(logJ, &config.Log); err != nil {
-		return nil, fmt.Errorf("unmarshal Log: %w", err)
-	}
-	if err := unmarshalFromJSON(apiJ, &config.API); err != nil {
-		return nil, fmt.Errorf("unmarshal API: %w", err)
-	}
-	if err := unmarshalFromJSON(dnsJ, &config.DNS); err != nil {
-		return nil, fmt.Errorf("unmarshal DNS: %w", err)
-	}
-	if err := unmarshalFromJSON(routingJ, &config.Routing); err != nil {
-		return nil, fmt.Errorf("unmarshal Routing: %w", err)
-	}
-	if err := unmarshalFromJSON(policyJ, &config.Policy); err != nil {
-		return nil, fmt.Errorf("unmarshal Policy: %w", err)
-	}
-	if err := unmarshalFromJSON(inboundsJ, &config.Inbounds); err != nil {
-		return nil, fmt.Errorf("unmarshal Inbounds: %w", err)
-	}
-	if err := unmarshalFromJSON(outboundsJ, &config.Outbounds); err != nil {
-		return nil, fmt.Errorf("unmarshal Outbounds: %w", err)
-	}
-	if err := unmarshalFromJSON(transportJ, &config.Transport); err != nil {
-		return nil, fmt.Errorf("unmarshal Transport: %w", err)
-	}
-	if err := unmarshalFromJSON(statsJ, &config.Stats); err != nil {
-		return nil, fmt.Errorf("unmarshal Stats: %w", err)
-	}
-	if err := unmarshalFromJSON(reverseJ, &config.Reverse); err != nil {
-		return nil, fmt.Errorf("unmarshal Reverse: %w", err)
-	}
-	if err := unmarshalFromJSON(fakednsJ, &config.FakeDNS); err != nil {
-		return nil, fmt.Errorf("unmarshal FakeDNS: %w", err)
+// ListXrayConfigs retrieves a list of Xray configurations with pagination.
+func (s *SQLiteStore) ListXrayConfigs(ctx context.Context, limit, offset int, sort store.Sort) ([]*models.XrayConfig, error) {
+	limit = s.pagination.Limit(limit)
+	if offset < 0 {
+		offset = 0
 	}
-	if err := unmarshalFromJSON(metricsJ, &config.Metrics); err != nil {
-		return nil, fmt.Errorf("unmarshal Metrics: %w", err)
+	orderBy, err := orderByClause(sort)
+	if err != nil {
+		return nil, err
 	}
-	if err := unmarshalFromJSON(obsJ, &config.Observatory); err != nil {
-		return nil, fmt.Errorf("unmarshal Observatory: %w", err)
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs ` + orderBy + ` LIMIT ? OFFSET ?`
+	return s.queryXrayConfigs(ctx, stmt, limit, offset)
+}
+
+// ListXrayConfigsByModelVersion is ListXrayConfigs restricted to configs
+// authored for modelVersion.
+func (s *SQLiteStore) ListXrayConfigsByModelVersion(ctx context.Context, modelVersion string, limit, offset int, sort store.Sort) ([]*models.XrayConfig, error) {
+	limit = s.pagination.Limit(limit)
+	if offset < 0 {
+		offset = 0
 	}
-	if err := unmarshalFromJSON(burstObsJ, &config.BurstObservatory); err != nil {
-		return nil, fmt.Errorf("unmarshal BurstObservatory: %w", err)
+	orderBy, err := orderByClause(sort)
+	if err != nil {
+		return nil, err
 	}
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs WHERE model_version = ? ` + orderBy + ` LIMIT ? OFFSET ?`
+	return s.queryXrayConfigs(ctx, stmt, modelVersion, limit, offset)
+}
 
-	return config, nil
+// xraySectionColumns maps the section names accepted by
+// ListXrayConfigsWithSection to their JSON blob column.
+var xraySectionColumns = map[string]string{
+	"log":               "log_config",
+	"api":               "api_config",
+	"dns":               "dns_config",
+	"routing":           "routing_config",
+	"policy":            "policy_config",
+	"inbounds":          "inbounds",
+	"outbounds":         "outbounds",
+	"transport":         "transport_config",
+	"stats":             "stats_config",
+	"reverse":           "reverse_config",
+	"fakedns":           "fakedns_config",
+	"metrics":           "metrics_config",
+	"observatory":       "observatory_config",
+	"burst_observatory": "burst_observatory_config",
+	"services":          "services_config",
 }
 
-// ListXrayConfigs retrieves a list of Xray configurations with pagination.
-func (s *SQLiteStore) ListXrayConfigs(ctx context.Context, limit, offset int) ([]*models.XrayConfig, error) {
-	if limit <= 0 {
-		limit = 10
-	}
+// ListXrayConfigsWithSection retrieves the Xray configurations in which the
+// given top-level section is present and not empty, most recently updated
+// first. Section names are the JSON keys, e.g. "dns" or "metrics".
+func (s *SQLiteStore) ListXrayConfigsWithSection(ctx context.Context, section string, limit, offset int, sort store.Sort) ([]*models.XrayConfig, error) {
+	column, ok := xraySectionColumns[section]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", store.ErrUnknownSection, section)
+	}
+	limit = s.pagination.Limit(limit)
 	if offset < 0 {
 		offset = 0
 	}
-	stmt := `
-    SELECT id, name, description, created_at, updated_at,
-           log_config, api_config, dns_config, routing_config, policy_config,
-           inbounds, outbounds, transport_config, stats_config, reverse_config,
-           fakedns_config, metrics_config, observatory_config, burst_observatory_config
-    FROM xray_configs ORDER BY updated_at DESC LIMIT ? OFFSET ?`
+	orderBy, err := orderByClause(sort)
+	if err != nil {
+		return nil, err
+	}
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs
+    WHERE ` + column + ` IS NOT NULL AND ` + column + ` NOT IN ('', 'null', '{}', '[]')
+    ` + orderBy + ` LIMIT ? OFFSET ?`
+	return s.queryXrayConfigs(ctx, stmt, limit, offset)
+}
 
-	rows, err := s.db.QueryContext(ctx, stmt, limit, offset)
+// CountXrayConfigs returns the number of stored Xray configurations.
+func (s *SQLiteStore) CountXrayConfigs(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM xray_configs`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count xray configs: %w", err)
+	}
+	return n, nil
+}
+
+// XrayConfigExists reports whether an Xray configuration with id exists. It
+// reads no config columns, so it is cheap enough for existence checks.
+func (s *SQLiteStore) XrayConfigExists(ctx context.Context, id string) (bool, error) {
+	var one int
+	err := s.db.QueryRowContext(ctx, `SELECT 1 FROM xray_configs WHERE id = ? LIMIT 1`, id).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to query xray configs: %w", err)
+		return false, fmt.Errorf("failed to check xray config %s: %w", id, err)
 	}
-	defer rows.Close()
+	return true, nil
+}
 
-	var configs []*models.XrayConfig
-	for rows.Next() {
-		config := &models.XrayConfig{}
-		var logJ, apiJ, dnsJ, routingJ, policyJ, inboundsJ, outboundsJ, transportJ, statsJ, reverseJ, fakednsJ, metricsJ, obsJ, burstObsJ sql.NullString
-		err := rows.Scan(
-			&config.ID, &config.Name, &config.Description, &config.CreatedAt, &config.UpdatedAt,
-			&logJ, &apiJ, &dnsJ, &routingJ, &policyJ, &inboundsJ, &outboundsJ, &transportJ,
-			&statsJ, &reverseJ, &fakednsJ, &metricsJ, &obsJ, &burstObsJ,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan xray config row: %w", err)
-		}
+// ListXrayConfigsUpdatedSince returns all Xray configurations modified
+// strictly after since, oldest change first, so agents can sync incrementally.
+func (s *SQLiteStore) ListXrayConfigsUpdatedSince(ctx context.Context, since time.Time) ([]*models.XrayConfig, error) {
+	stmt := `SELECT ` + xrayColumns + ` FROM xray_configs WHERE updated_at > ? ORDER BY updated_at ASC`
+	return s.queryXrayConfigs(ctx, stmt, since.UTC())
+}
 
-		if errU := unmarshalFromJSON(logJ, &config.Log); errU != nil {
-			return nil, fmt.Errorf("unmarshal Log for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(apiJ, &config.API); errU != nil {
-			return nil, fmt.Errorf("unmarshal API for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(dnsJ, &config.DNS); errU != nil {
-			return nil, fmt.Errorf("unmarshal DNS for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(routingJ, &config.Routing); errU != nil {
-			return nil, fmt.Errorf("unmarshal Routing for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(policyJ, &config.Policy); errU != nil {
-			return nil, fmt.Errorf("unmarshal Policy for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(inboundsJ, &config.Inbounds); errU != nil {
-			return nil, fmt.Errorf("unmarshal Inbounds for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(outboundsJ, &config.Outbounds); errU != nil {
-			return nil, fmt.Errorf("unmarshal Outbounds for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(transportJ, &config.Transport); errU != nil {
-			return nil, fmt.Errorf("unmarshal Transport for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(statsJ, &config.Stats); errU != nil {
-			return nil, fmt.Errorf("unmarshal Stats for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(reverseJ, &config.Reverse); errU != nil {
-			return nil, fmt.Errorf("unmarshal Reverse for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(fakednsJ, &config.FakeDNS); errU != nil {
-			return nil, fmt.Errorf("unmarshal FakeDNS for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(metricsJ, &config.Metrics); errU != nil {
-			return nil, fmt.Errorf("unmarshal Metrics for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(obsJ, &config.Observatory); errU != nil {
-			return nil, fmt.Errorf("unmarshal Observatory for %s: %w", config.ID, errU)
-		}
-		if errU := unmarshalFromJSON(burstObsJ, &config.BurstObservatory); errU != nil {
-			return nil, fmt.Errorf("unmarshal BurstObservatory for %s: %w", config.ID, errU)
+// RenameXrayConfig changes only the name of an Xray configuration in a single
+// statement. A name used by another config yields store.ErrConflict.
+func (s *SQLiteStore) RenameXrayConfig(ctx context.Context, id, newName string) error {
+	if newName == "" {
+		return fmt.Errorf("cannot rename xray config %s: name is empty", id)
+	}
+	stmt := `UPDATE xray_configs SET name = ?, updated_at = ? WHERE id = ?`
+	result, err := s.db.ExecContext(ctx, stmt, newName, time.Now().UTC(), id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("xray config name %q is already taken: %w", newName, store.ErrConflict)
 		}
-		configs = append(configs, config)
+		return fmt.Errorf("failed to rename xray config: %w", err)
 	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating xray config rows: %w", err)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for xray rename: %w", err)
 	}
-	return configs, nil
+	if rowsAffected == 0 {
+		return fmt.Errorf("xray config with id %s not found for rename: %w", id, sql.ErrNoRows)
+	}
+	return nil
 }
 
 // UpdateXrayConfig updates an existing Xray configuration.
 func (s *SQLiteStore) UpdateXrayConfig(ctx context.Context, config *models.XrayConfig) error {
+	update, err := s.prepareXrayUpdate(ctx, config)
+	if err != nil {
+		return err
+	}
+	return s.db.withTx(ctx, update)
+}
+
+// UpdateXrayConfigs updates several Xray configurations in one transaction:
+// if any update fails, none is applied.
+func (s *SQLiteStore) UpdateXrayConfigs(ctx context.Context, configs ...*models.XrayConfig) error {
+	updates := make([]func(tx *sql.Tx) error, len(configs))
+	for i, config := range configs {
+		var err error
+		if updates[i], err = s.prepareXrayUpdate(ctx, config); err != nil {
+			return err
+		}
+	}
+	return s.db.withTx(ctx, func(tx *sql.Tx) error {
+		for _, update := range updates {
+			if err := update(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// prepareXrayUpdate marshals config and returns the statements updating it,
+// to be run inside a transaction.
+func (s *SQLiteStore) prepareXrayUpdate(ctx context.Context, config *models.XrayConfig) (func(tx *sql.Tx) error, error) {
 	if config.ID == "" {
-		return fmt.Errorf("cannot update xray config: ID is missing")
+		return nil, fmt.Errorf("cannot update xray config: ID is missing")
+	}
+	if errs := validation.XrayConfigErrors(config); len(errs) > 0 {
+		return nil, fmt.Errorf("cannot update xray config: %w", errs[0])
 	}
 	config.UpdatedAt = time.Now().UTC()
 
-	var err error
 	logJSON, err := marshalToJSON(config.Log)
 	if err != nil {
-		return fmt.Errorf("marshal Log: %w", err)
+		return nil, fmt.Errorf("marshal Log: %w", err)
 	}
 	apiJSON, err := marshalToJSON(config.API)
 	if err != nil {
-		return fmt.Errorf("marshal API: %w", err)
+		return nil, fmt.Errorf("marshal API: %w", err)
 	}
 	dnsJSON, err := marshalToJSON(config.DNS)
 	if err != nil {
-		return fmt.Errorf("marshal DNS: %w", err)
+		return nil, fmt.Errorf("marshal DNS: %w", err)
 	}
 	routingJSON, err := marshalToJSON(config.Routing)
 	if err != nil {
-		return fmt.Errorf("marshal Routing: %w", err)
+		return nil, fmt.Errorf("marshal Routing: %w", err)
 	}
 	policyJSON, err := marshalToJSON(config.Policy)
 	if err != nil {
-		return fmt.Errorf("marshal Policy: %w", err)
+		return nil, fmt.Errorf("marshal Policy: %w", err)
 	}
-	inboundsJSON, err := marshalToJSON(config.Inbounds)
+	inboundsJSON, err := s.marshalSealed(config.Inbounds)
 	if err != nil {
-		return fmt.Errorf("marshal Inbounds: %w", err)
+		return nil, fmt.Errorf("marshal Inbounds: %w", err)
 	}
-	outboundsJSON, err := marshalToJSON(config.Outbounds)
+	outboundsJSON, err := s.marshalSealed(config.Outbounds)
 	if err != nil {
-		return fmt.Errorf("marshal Outbounds: %w", err)
+		return nil, fmt.Errorf("marshal Outbounds: %w", err)
 	}
 	transportJSON, err := marshalToJSON(config.Transport)
 	if err != nil {
-		return fmt.Errorf("marshal Transport: %w", err)
+		return nil, fmt.Errorf("marshal Transport: %w", err)
 	}
 	statsJSON, err := marshalToJSON(config.Stats)
 	if err != nil {
-		return fmt.Errorf("marshal Stats: %w", err)
+		return nil, fmt.Errorf("marshal Stats: %w", err)
 	}
 	reverseJSON, err := marshalToJSON(config.Reverse)
 	if err != nil {
-		return fmt.Errorf("marshal Reverse: %w", err)
+		return nil, fmt.Errorf("marshal Reverse: %w", err)
 	}
 	fakednsJSON, err := marshalToJSON(config.FakeDNS)
 	if err != nil {
-		return fmt.Errorf("marshal FakeDNS: %w", err)
+		return nil, fmt.Errorf("marshal FakeDNS: %w", err)
 	}
 	metricsJSON, err := marshalToJSON(config.Metrics)
 	if err != nil {
-		return fmt.Errorf("marshal Metrics: %w", err)
+		return nil, fmt.Errorf("marshal Metrics: %w", err)
 	}
 	observatoryJSON, err := marshalToJSON(config.Observatory)
 	if err != nil {
-		return fmt.Errorf("marshal Observatory: %w", err)
+		return nil, fmt.Errorf("marshal Observatory: %w", err)
 	}
 	burstObservatoryJSON, err := marshalToJSON(config.BurstObservatory)
 	if err != nil {
-		return fmt.Errorf("marshal BurstObservatory: %w", err)
+		return nil, fmt.Errorf("marshal BurstObservatory: %w", err)
+	}
+	servicesJSON, err := marshalToJSON(config.Services)
+	if err != nil {
+		return nil, fmt.Errorf("marshal Services: %w", err)
+	}
+	managedJSON, err := marshalToJSON(config.ManagedSections)
+	if err != nil {
+		return nil, fmt.Errorf("marshal ManagedSections: %w", err)
+	}
+	suppressJSON, err := marshalToJSON(config.LintSuppress)
+	if err != nil {
+		return nil, fmt.Errorf("marshal LintSuppress: %w", err)
+	}
+
+	if config.ConfigHash, err = models.CanonicalHashXray(config); err != nil {
+		return nil, fmt.Errorf("hash xray config: %w", err)
 	}
 
 	stmt := `
@@ -919,45 +1335,51 @@ func (s *SQLiteStore) UpdateXrayConfig(ctx context.Context, config *models.XrayC
         name = ?, description = ?, updated_at = ?,
         log_config = ?, api_config = ?, dns_config = ?, routing_config = ?, policy_config = ?,
         inbounds = ?, outbounds = ?, transport_config = ?, stats_config = ?, reverse_config = ?,
-        fakedns_config = ?, metrics_config = ?, observatory_config = ?, burst_observatory_config = ?
+        fakedns_config = ?, metrics_config = ?, observatory_config = ?, burst_observatory_config = ?,
+        environment = ?, promoted_from = ?, model_version = ?, content_hash = ?, services_config = ?, managed_sections = ?, lint_suppress = ?
     WHERE id = ?`
 
-	result, err := s.db.ExecContext(
-		ctx, stmt,
-		config.Name, config.Description, config.UpdatedAt,
-		logJSON, apiJSON, dnsJSON, routingJSON, policyJSON,
-		inboundsJSON, outboundsJSON, transportJSON, statsJSON, reverseJSON,
-		fakednsJSON, metricsJSON, observatoryJSON, burstObservatoryJSON,
-		config.ID,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update xray config: %w", err)
-	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected for xray update: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("xray config with id %s not found for update: %w", config.ID, sql.ErrNoRows)
-	}
-	return nil
+	return func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(
+			ctx, stmt,
+			config.Name, config.Description, config.UpdatedAt,
+			logJSON, apiJSON, dnsJSON, routingJSON, policyJSON,
+			inboundsJSON, outboundsJSON, transportJSON, statsJSON, reverseJSON,
+			fakednsJSON, metricsJSON, observatoryJSON, burstObservatoryJSON,
+			config.Environment, config.PromotedFrom, config.ModelVersion, config.ConfigHash, servicesJSON, managedJSON, suppressJSON,
+			config.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update xray config: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected for xray update: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("xray config with id %s not found for update: %w", config.ID, sql.ErrNoRows)
+		}
+		return indexClients(ctx, tx, models.ConfigTypeXray, config.ID, models.XrayClientPlacements(config))
+	}, nil
 }
 
 // DeleteXrayConfig deletes an Xray configuration by its ID.
 func (s *SQLiteStore) DeleteXrayConfig(ctx context.Context, id string) error {
 	stmt := `DELETE FROM xray_configs WHERE id = ?`
-	result, err := s.db.ExecContext(ctx, stmt, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete xray config: %w", err)
-	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected for xray delete: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("xray config with id %s not found for deletion: %w", id, sql.ErrNoRows)
-	}
-	return nil
+	return s.db.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, stmt, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete xray config: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected for xray delete: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("xray config with id %s not found for deletion: %w", id, sql.ErrNoRows)
+		}
+		return indexClients(ctx, tx, models.ConfigTypeXray, id, nil)
+	})
 }
 
 // Close closes the database connection.
@@ -3,7 +3,9 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +16,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/validation"
+	"github.com/tools4net/ezfw/backend/internal/version"
 )
 
 func setupTestDB(t *testing.T) (*SQLiteStore, func()) {
@@ -131,6 +136,135 @@ func TestUpdateSingBoxConfig_NotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "not found for update")
 }
 
+func TestSingBoxConfig_DuplicateTagsRejected(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := &models.SingBoxConfig{
+		Name:      "tags",
+		Inbounds:  []*models.SingBoxInbound{{Type: "mixed", Tag: "mixed-in"}},
+		Outbounds: []*models.SingBoxOutbound{{Type: "direct", Tag: "mixed-in"}},
+	}
+	err := store.CreateSingBoxConfig(ctx, config)
+	var validationErr validation.ValidationError
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "outbounds[0].tag", validationErr.Field)
+	assert.Equal(t, "mixed-in", validationErr.Value)
+	assert.Empty(t, config.ID, "a rejected config is not assigned an ID")
+
+	config.Outbounds[0].Tag = "direct"
+	require.NoError(t, store.CreateSingBoxConfig(ctx, config))
+
+	config.Inbounds = append(config.Inbounds, &models.SingBoxInbound{Type: "tun", Tag: "mixed-in"})
+	err = store.UpdateSingBoxConfig(ctx, config)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "inbounds[1].tag", validationErr.Field)
+
+	stored, err := store.GetSingBoxConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Inbounds, 1)
+}
+
+func TestSingBoxConfig_UnknownTypeRejected(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := &models.SingBoxConfig{
+		Name:     "types",
+		Inbounds: []*models.SingBoxInbound{{Type: "mixedd", Tag: "mixed-in"}},
+	}
+	err := store.CreateSingBoxConfig(ctx, config)
+	var validationErr validation.ValidationError
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "inbounds[0].type", validationErr.Field)
+	assert.Equal(t, "mixedd", validationErr.Value)
+
+	config.Inbounds[0].Type = "mixed"
+	require.NoError(t, store.CreateSingBoxConfig(ctx, config))
+
+	config.Outbounds = []*models.SingBoxOutbound{{Type: "drect", Tag: "direct"}}
+	err = store.UpdateSingBoxConfig(ctx, config)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "outbounds[0].type", validationErr.Field)
+}
+
+func TestSingBoxConfig_InvalidRouteRuleRejected(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	rule := &models.SingBoxRouteRule{Outbound: StringPtr("direct")}
+	for i := 0; i <= validation.MaxRuleNestingDepth; i++ {
+		rule = &models.SingBoxRouteRule{Type: StringPtr("logical"), Mode: StringPtr("and"), Rules: []*models.SingBoxRouteRule{rule}, Outbound: StringPtr("direct")}
+	}
+	config := &models.SingBoxConfig{Name: "deep", Route: &models.SingBoxRouteConfig{Rules: []*models.SingBoxRouteRule{rule}}}
+	err := store.CreateSingBoxConfig(ctx, config)
+	var validationErr validation.ValidationError
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Contains(t, validationErr.Message, "nested at most")
+
+	config.Route = &models.SingBoxRouteConfig{Rules: []*models.SingBoxRouteRule{{Outbound: StringPtr("direct")}}}
+	require.NoError(t, store.CreateSingBoxConfig(ctx, config))
+
+	config.Route.Rules = append(config.Route.Rules, rule)
+	err = store.UpdateSingBoxConfig(ctx, config)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Contains(t, validationErr.Field, "route.rules[1]")
+}
+
+func TestSingBoxConfig_InvalidPortRejected(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := &models.SingBoxConfig{
+		Name:     "ports",
+		Inbounds: []*models.SingBoxInbound{{Type: "mixed", Tag: "mixed-in", ListenPort: IntPtr(70000)}},
+	}
+	err := store.CreateSingBoxConfig(ctx, config)
+	var validationErr validation.ValidationError
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "inbounds[0].listen_port", validationErr.Field)
+
+	config.Inbounds[0].ListenPort = IntPtr(1080)
+	require.NoError(t, store.CreateSingBoxConfig(ctx, config))
+
+	config.Inbounds[0].ListenPort = IntPtr(0)
+	err = store.UpdateSingBoxConfig(ctx, config)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "0", validationErr.Value)
+}
+
+func TestSingBoxConfig_InvalidWireGuardEndpointRejected(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	publicKey := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	config := &models.SingBoxConfig{
+		Name: "wg",
+		Endpoints: []map[string]interface{}{{
+			"type": "wireguard", "tag": "wg", "address": []interface{}{"10.0.0.2/32"},
+			"peers": []interface{}{map[string]interface{}{"public_key": "not-a-key", "allowed_ips": []interface{}{"0.0.0.0/0"}}},
+		}},
+	}
+	err := store.CreateSingBoxConfig(ctx, config)
+	var validationErr validation.ValidationError
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "endpoints[0].peers[0].public_key", validationErr.Field)
+
+	peer := config.Endpoints[0]["peers"].([]interface{})[0].(map[string]interface{})
+	peer["public_key"] = publicKey
+	require.NoError(t, store.CreateSingBoxConfig(ctx, config))
+
+	peer["allowed_ips"] = []interface{}{"10.0.0.0/33"}
+	err = store.UpdateSingBoxConfig(ctx, config)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "endpoints[0].peers[0].allowed_ips[0]", validationErr.Field)
+}
+
 func TestDeleteSingBoxConfig(t *testing.T) {
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -158,6 +292,10 @@ func TestDeleteSingBoxConfig_NotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "not found for deletion")
 }
 
+// defaultOrder requests a List method's default order. Tests name the store
+// variable "store", which shadows the package.
+var defaultOrder = store.Sort{}
+
 func TestListSingBoxConfigs(t *testing.T) {
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -176,7 +314,7 @@ func TestListSingBoxConfigs(t *testing.T) {
 	require.NoError(t, store.CreateSingBoxConfig(ctx, config3))
 
 	// Test listing all (limit > count)
-	configs, err := store.ListSingBoxConfigs(ctx, 10, 0)
+	configs, err := store.ListSingBoxConfigs(ctx, 10, 0, defaultOrder)
 	require.NoError(t, err)
 	require.Len(t, configs, 3)
 	// Check order (default is by UpdatedAt DESC)
@@ -187,13 +325,13 @@ func TestListSingBoxConfigs(t *testing.T) {
 	assert.Equal(t, "warn", *configs[0].Log.Level)
 
 	// Test limit
-	configs, err = store.ListSingBoxConfigs(ctx, 1, 0)
+	configs, err = store.ListSingBoxConfigs(ctx, 1, 0, defaultOrder)
 	require.NoError(t, err)
 	require.Len(t, configs, 1)
 	assert.Equal(t, config3.ID, configs[0].ID)
 
 	// Test offset
-	configs, err = store.ListSingBoxConfigs(ctx, 1, 1)
+	configs, err = store.ListSingBoxConfigs(ctx, 1, 1, defaultOrder)
 	require.NoError(t, err)
 	require.Len(t, configs, 1)
 	assert.Equal(t, config2.ID, configs[0].ID)
@@ -201,11 +339,174 @@ func TestListSingBoxConfigs(t *testing.T) {
 	// Test empty list
 	storeNoConf, cleanupNoConf := setupTestDB(t)
 	defer cleanupNoConf()
-	emptyConfigs, err := storeNoConf.ListSingBoxConfigs(ctx, 10, 0)
+	emptyConfigs, err := storeNoConf.ListSingBoxConfigs(ctx, 10, 0, defaultOrder)
 	require.NoError(t, err)
 	assert.Len(t, emptyConfigs, 0)
 }
 
+func TestListConfigs_PaginationLimits(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, st.CreateSingBoxConfig(ctx, &models.SingBoxConfig{Name: fmt.Sprintf("sb-%d", i)}))
+		require.NoError(t, st.CreateXrayConfig(ctx, &models.XrayConfig{Name: fmt.Sprintf("xray-%d", i)}))
+	}
+	st.SetPagination(store.Pagination{DefaultLimit: 2, MaxLimit: 3})
+
+	singBoxes, err := st.ListSingBoxConfigs(ctx, 1000, 0, defaultOrder)
+	require.NoError(t, err)
+	assert.Len(t, singBoxes, 3, "limit must be clamped to MaxLimit")
+	xrays, err := st.ListXrayConfigs(ctx, 1000, 0, defaultOrder)
+	require.NoError(t, err)
+	assert.Len(t, xrays, 3, "limit must be clamped to MaxLimit")
+
+	singBoxes, err = st.ListSingBoxConfigs(ctx, 0, 0, defaultOrder)
+	require.NoError(t, err)
+	assert.Len(t, singBoxes, 2, "zero limit must use DefaultLimit")
+	xrays, err = st.ListXrayConfigs(ctx, -1, 0, defaultOrder)
+	require.NoError(t, err)
+	assert.Len(t, xrays, 2, "negative limit must use DefaultLimit")
+}
+
+func TestListConfigs_Sort(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, name := range []string{"charlie", "alpha", "bravo"} {
+		require.NoError(t, st.CreateXrayConfig(ctx, &models.XrayConfig{Name: name}))
+		require.NoError(t, st.CreateSingBoxConfig(ctx, &models.SingBoxConfig{Name: name}))
+	}
+
+	byName, err := store.ParseSort("name")
+	require.NoError(t, err)
+	xrays, err := st.ListXrayConfigs(ctx, 10, 0, byName)
+	require.NoError(t, err)
+	require.Len(t, xrays, 3)
+	assert.Equal(t, []string{"alpha", "bravo", "charlie"}, []string{xrays[0].Name, xrays[1].Name, xrays[2].Name})
+
+	byNameDesc, err := store.ParseSort("-name")
+	require.NoError(t, err)
+	singBoxes, err := st.ListSingBoxConfigs(ctx, 2, 0, byNameDesc)
+	require.NoError(t, err)
+	require.Len(t, singBoxes, 2)
+	assert.Equal(t, "charlie", singBoxes[0].Name)
+	assert.Equal(t, "bravo", singBoxes[1].Name)
+
+	_, err = st.ListXrayConfigs(ctx, 10, 0, store.Sort{Field: "name; DROP TABLE xray_configs"})
+	assert.ErrorIs(t, err, store.ErrInvalidSort)
+}
+
+func TestCreateXrayConfig_ModelVersion(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	t.Setenv(version.EnvXrayDefaultVersion, "1.8.0")
+
+	defaulted := &models.XrayConfig{Name: "defaulted"}
+	require.NoError(t, st.CreateXrayConfig(ctx, defaulted))
+	assert.Equal(t, "1.8.0", defaulted.ModelVersion)
+	explicit := &models.XrayConfig{Name: "explicit", ModelVersion: "1.8.4"}
+	require.NoError(t, st.CreateXrayConfig(ctx, explicit))
+	assert.Equal(t, "1.8.4", explicit.ModelVersion)
+
+	got, err := st.GetXrayConfig(ctx, defaulted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "1.8.0", got.ModelVersion)
+
+	configs, err := st.ListXrayConfigsByModelVersion(ctx, "1.8.4", 10, 0, defaultOrder)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, explicit.ID, configs[0].ID)
+
+	configs, err = st.ListXrayConfigsByModelVersion(ctx, "1.7.0", 10, 0, defaultOrder)
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+}
+
+func TestListXrayConfigsWithSection(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	withDNS := &models.XrayConfig{Name: "with-dns", DNS: &models.DNSObject{Servers: []interface{}{"1.1.1.1"}}}
+	withoutDNS := &models.XrayConfig{Name: "without-dns", Log: &models.LogObject{Loglevel: StringPtr("info")}}
+	noInbounds := &models.XrayConfig{Name: "no-inbounds", Inbounds: []models.InboundObject{}}
+	for _, cfg := range []*models.XrayConfig{withDNS, withoutDNS, noInbounds} {
+		require.NoError(t, st.CreateXrayConfig(ctx, cfg))
+	}
+
+	configs, err := st.ListXrayConfigsWithSection(ctx, "dns", 10, 0, defaultOrder)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, withDNS.ID, configs[0].ID)
+
+	configs, err = st.ListXrayConfigsWithSection(ctx, "inbounds", 10, 0, defaultOrder)
+	require.NoError(t, err)
+	assert.Empty(t, configs, "an empty array does not count as configured")
+
+	_, err = st.ListXrayConfigsWithSection(ctx, "dns_config; DROP TABLE xray_configs", 10, 0, defaultOrder)
+	assert.ErrorIs(t, err, store.ErrUnknownSection)
+}
+
+func TestGetMultipleXrayConfigs(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	a := &models.XrayConfig{Name: "a"}
+	b := &models.XrayConfig{Name: "b"}
+	require.NoError(t, st.CreateXrayConfig(ctx, a))
+	require.NoError(t, st.CreateXrayConfig(ctx, b))
+
+	// Empty IDs
+	got, err := st.GetMultipleXrayConfigs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// Partial hits
+	got, err = st.GetMultipleXrayConfigs(ctx, []string{a.ID, uuid.NewString()})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "a", got[a.ID].Name)
+	assert.Equal(t, a.ConfigHash, got[a.ID].ConfigHash)
+
+	// All hits
+	got, err = st.GetMultipleXrayConfigs(ctx, []string{a.ID, b.ID})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "b", got[b.ID].Name)
+}
+
+func TestRenameXrayConfig(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	one := &models.XrayConfig{Name: "one", Log: &models.LogObject{Loglevel: StringPtr("warning")}}
+	two := &models.XrayConfig{Name: "two"}
+	require.NoError(t, st.CreateXrayConfig(ctx, one))
+	require.NoError(t, st.CreateXrayConfig(ctx, two))
+
+	require.NoError(t, st.RenameXrayConfig(ctx, one.ID, "uno"))
+	got, err := st.GetXrayConfig(ctx, one.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "uno", got.Name)
+	assert.Equal(t, "warning", *got.Log.Loglevel, "content is untouched")
+	assert.True(t, got.UpdatedAt.After(one.UpdatedAt))
+
+	err = st.RenameXrayConfig(ctx, two.ID, "uno")
+	assert.ErrorIs(t, err, store.ErrConflict)
+	got, err = st.GetXrayConfig(ctx, two.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "two", got.Name)
+
+	err = st.RenameXrayConfig(ctx, uuid.NewString(), "three")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
 // Helper function to create a pointer to a string.
 // () etc. can be used directly in tests.
 // This is just for local use if models package isn't directly modifiable for test helpers.
@@ -307,6 +608,36 @@ func TestSingBoxConfig_JSONMarshalling(t *testing.T) {
 	}
 }
 
+func TestSingBoxConfig_MultiplexRoundTrip(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	outboundMux := &models.SingBoxMultiplexConfig{
+		Enabled:    BoolPtr(true),
+		Protocol:   StringPtr("h2mux"),
+		MaxStreams: IntPtr(16),
+		Padding:    BoolPtr(true),
+		Brutal:     &models.SingBoxBrutalConfig{Enabled: BoolPtr(true), UpMbps: IntPtr(100), DownMbps: IntPtr(500)},
+	}
+	inboundMux := &models.SingBoxMultiplexConfig{
+		Enabled: BoolPtr(true),
+		Brutal:  &models.SingBoxBrutalConfig{Enabled: BoolPtr(true), UpMbps: IntPtr(1000), DownMbps: IntPtr(1000)},
+	}
+	config := &models.SingBoxConfig{
+		Name:      "mux",
+		Inbounds:  []*models.SingBoxInbound{{Type: "vless", Tag: "vless-in", ListenPort: IntPtr(443), Multiplex: inboundMux}},
+		Outbounds: []*models.SingBoxOutbound{{Type: "vless", Tag: "vless-out", Multiplex: outboundMux}},
+	}
+	require.NoError(t, st.CreateSingBoxConfig(ctx, config))
+
+	got, err := st.GetSingBoxConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Equal(t, outboundMux, got.Outbounds[0].Multiplex)
+	assert.Equal(t, inboundMux, got.Inbounds[0].Multiplex)
+	assert.Equal(t, config.ConfigHash, got.ConfigHash)
+}
+
 // --- Xray Tests ---
 
 func TestCreateXrayConfig(t *testing.T) {
@@ -344,6 +675,75 @@ func TestCreateXrayConfig(t *testing.T) {
 	portVal, ok := retrieved.Inbounds[0].Port.(float64)
 	require.True(t, ok, "Port should be unmarshalled as float64 from JSON number")
 	assert.Equal(t, float64(1088), portVal)
+
+	// The content hash must survive the JSON round trip through the database
+	require.NotEmpty(t, config.ConfigHash)
+	assert.Equal(t, config.ConfigHash, retrieved.ConfigHash)
+}
+
+func TestXrayConfig_ServicesRoundTrip(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := &models.XrayConfig{
+		Name:     "browser-dialer",
+		Services: &models.XrayServices{BrowserDialer: &models.BrowserDialerObject{Address: "ws://127.0.0.1:12345"}},
+	}
+	require.NoError(t, st.CreateXrayConfig(ctx, config))
+
+	got, err := st.GetXrayConfig(ctx, config.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Services)
+	require.NotNil(t, got.Services.BrowserDialer)
+	assert.Equal(t, "ws://127.0.0.1:12345", got.Services.BrowserDialer.Address)
+	assert.Equal(t, config.ConfigHash, got.ConfigHash)
+
+	got.Services = nil
+	require.NoError(t, st.UpdateXrayConfig(ctx, got))
+	got, err = st.GetXrayConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.Services)
+}
+
+func TestConfigs_LintSuppressRoundTrip(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	xray := &models.XrayConfig{Name: "quiet"}
+	require.NoError(t, store.CreateXrayConfig(ctx, xray))
+	hash := xray.ConfigHash
+	xray.LintSuppress = []string{"XRAY-LOG-MISSING"}
+	require.NoError(t, store.UpdateXrayConfig(ctx, xray))
+	gotXray, err := store.GetXrayConfig(ctx, xray.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"XRAY-LOG-MISSING"}, gotXray.LintSuppress)
+	assert.Equal(t, hash, gotXray.ConfigHash, "suppressions are panel metadata")
+
+	sb := &models.SingBoxConfig{Name: "quiet", LintSuppress: []string{"SINGBOX-LOG-MISSING"}}
+	require.NoError(t, store.CreateSingBoxConfig(ctx, sb))
+	gotSB, err := store.GetSingBoxConfig(ctx, sb.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SINGBOX-LOG-MISSING"}, gotSB.LintSuppress)
+}
+
+func TestXrayConfig_ManagedSectionsRoundTrip(t *testing.T) {
+	st, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := &models.XrayConfig{Name: "managed"}
+	require.NoError(t, st.CreateXrayConfig(ctx, config))
+	plainHash := config.ConfigHash
+
+	config.ManagedSections = []models.ManagedSection{{Path: "inbounds.api", Owner: "xray-api"}}
+	require.NoError(t, st.UpdateXrayConfig(ctx, config))
+	assert.Equal(t, plainHash, config.ConfigHash, "managed sections are panel metadata")
+
+	got, err := st.GetXrayConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Equal(t, config.ManagedSections, got.ManagedSections)
 }
 
 func TestCreateXrayConfig_NameConflict(t *testing.T) {
@@ -370,6 +770,23 @@ func TestGetXrayConfig_NotFound(t *testing.T) {
 	assert.True(t, errors.Is(err, sql.ErrNoRows) || strings.Contains(err.Error(), "not found"))
 }
 
+func TestXrayConfigExists(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := &models.XrayConfig{Name: "exists"}
+	require.NoError(t, store.CreateXrayConfig(ctx, config))
+
+	exists, err := store.XrayConfigExists(ctx, config.ID)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = store.XrayConfigExists(ctx, uuid.NewString())
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestUpdateXrayConfig(t *testing.T) {
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -440,6 +857,95 @@ func TestUpdateXrayConfig_NameConflict(t *testing.T) {
 	assert.Contains(t, err.Error(), "UNIQUE constraint failed: xray_configs.name")
 }
 
+func TestUpdateXrayConfigs_Atomic(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	first := &models.XrayConfig{Name: "Batch One"}
+	second := &models.XrayConfig{Name: "Batch Two"}
+	require.NoError(t, store.CreateXrayConfig(ctx, first))
+	require.NoError(t, store.CreateXrayConfig(ctx, second))
+
+	first.Description = "updated"
+	second.Description = "updated"
+	require.NoError(t, store.UpdateXrayConfigs(ctx, first, second))
+	got, err := store.GetXrayConfig(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", got.Description)
+
+	// A failing second update rolls back the first
+	first.Description = "rolled back"
+	missing := &models.XrayConfig{ID: uuid.NewString(), Name: "Missing"}
+	err = store.UpdateXrayConfigs(ctx, first, missing)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	got, err = store.GetXrayConfig(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", got.Description)
+}
+
+func TestXrayConfig_DuplicateTagsRejected(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	config := &models.XrayConfig{
+		Name:     "tags",
+		Inbounds: []models.InboundObject{{Tag: "in", Protocol: "vless", Port: 443}, {Tag: "in", Protocol: "socks", Port: 1080}},
+	}
+	err := store.CreateXrayConfig(ctx, config)
+	var validationErr validation.ValidationError
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "inbounds[1].tag", validationErr.Field)
+	assert.Empty(t, config.ID, "a rejected config is not assigned an ID")
+
+	config.Inbounds[1].Tag = "socks-in"
+	config.Outbounds = []models.OutboundObject{{Tag: StringPtr("in"), Protocol: StringPtr("freedom")}}
+	require.NoError(t, store.CreateXrayConfig(ctx, config), "inbounds and outbounds are separate namespaces")
+
+	config.Outbounds = append(config.Outbounds, models.OutboundObject{Tag: StringPtr("in"), Protocol: StringPtr("blackhole")})
+	err = store.UpdateXrayConfig(ctx, config)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Equal(t, "outbounds[1].tag", validationErr.Field)
+	err = store.UpdateXrayConfigs(ctx, config)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+
+	stored, err := store.GetXrayConfig(ctx, config.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Outbounds, 1)
+}
+
+func TestConfigs_DetourCycleRejected(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	xray := &models.XrayConfig{
+		Name:      "loop",
+		Outbounds: []models.OutboundObject{{Tag: StringPtr("self"), ProxySettings: &models.ProxySettings{Tag: StringPtr("self")}}},
+	}
+	err := store.CreateXrayConfig(ctx, xray)
+	var validationErr validation.ValidationError
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Contains(t, validationErr.Message, "detour cycle")
+
+	xray.Outbounds[0].ProxySettings = nil
+	require.NoError(t, store.CreateXrayConfig(ctx, xray))
+	xray.Outbounds[0].ProxySettings = &models.ProxySettings{Tag: StringPtr("self")}
+	err = store.UpdateXrayConfig(ctx, xray)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+
+	singBox := &models.SingBoxConfig{
+		Name: "loop",
+		Outbounds: []*models.SingBoxOutbound{
+			{Type: "selector", Tag: "select", Settings: map[string]interface{}{"outbounds": []interface{}{"select"}}},
+		},
+	}
+	err = store.CreateSingBoxConfig(ctx, singBox)
+	require.True(t, errors.As(err, &validationErr), "got %v", err)
+	assert.Contains(t, validationErr.Message, "detour cycle")
+}
+
 
 func TestDeleteXrayConfig(t *testing.T) {
 	store, cleanup := setupTestDB(t)
@@ -470,7 +976,7 @@ func TestListXrayConfigs(t *testing.T) {
 	x2 := &models.XrayConfig{Name: "Xray Cfg 2", Log: &models.LogObject{Loglevel: StringPtr("debug")}}
 	require.NoError(t, store.CreateXrayConfig(ctx, x2))
 
-	configs, err := store.ListXrayConfigs(ctx, 5, 0)
+	configs, err := store.ListXrayConfigs(ctx, 5, 0, defaultOrder)
 	require.NoError(t, err)
 	require.Len(t, configs, 2)
 	assert.Equal(t, x2.ID, configs[0].ID) // Ordered by UpdatedAt DESC
@@ -485,7 +991,7 @@ func TestListXrayConfigs(t *testing.T) {
 	// Test empty list
 	storeNoConf, cleanupNoConf := setupTestDB(t)
 	defer cleanupNoConf()
-	emptyConfigs, err := storeNoConf.ListXrayConfigs(ctx, 10, 0)
+	emptyConfigs, err := storeNoConf.ListXrayConfigs(ctx, 10, 0, defaultOrder)
 	require.NoError(t, err)
 	assert.Len(t, emptyConfigs, 0)
 
@@ -595,6 +1101,45 @@ func TestGetXrayConfigByName(t *testing.T) {
 }
 
 
+func TestListConfigsUpdatedSince(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	oldSB := &models.SingBoxConfig{Name: "Old SingBox"}
+	require.NoError(t, store.CreateSingBoxConfig(ctx, oldSB))
+	oldX := &models.XrayConfig{Name: "Old Xray"}
+	require.NoError(t, store.CreateXrayConfig(ctx, oldX))
+
+	time.Sleep(5 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	newSB := &models.SingBoxConfig{Name: "New SingBox"}
+	require.NoError(t, store.CreateSingBoxConfig(ctx, newSB))
+	newX := &models.XrayConfig{Name: "New Xray"}
+	require.NoError(t, store.CreateXrayConfig(ctx, newX))
+	time.Sleep(5 * time.Millisecond)
+	oldSB.Log = &models.SingBoxLogConfig{Level: StringPtr("debug")}
+	require.NoError(t, store.UpdateSingBoxConfig(ctx, oldSB))
+
+	sbChanged, err := store.ListSingBoxConfigsUpdatedSince(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, sbChanged, 2)
+	assert.Equal(t, newSB.ID, sbChanged[0].ID, "oldest change first")
+	assert.Equal(t, oldSB.ID, sbChanged[1].ID)
+	assert.Equal(t, oldSB.ConfigHash, sbChanged[1].ConfigHash)
+
+	xChanged, err := store.ListXrayConfigsUpdatedSince(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, xChanged, 1)
+	assert.Equal(t, newX.ID, xChanged[0].ID)
+
+	none, err := store.ListXrayConfigsUpdatedSince(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
 // It's good practice to also add pointer helpers to the models package itself if possible,
 // or a shared utility package.
 // For example, in models/utils.go:
@@ -602,3 +1147,76 @@ func TestGetXrayConfigByName(t *testing.T) {
 // func (i int) *int { return &i }
 // func BoolPtr(b bool) *bool { return &b }
 // Then they can be used as ("value")
+
+func TestNewSQLiteStore_AddsMissingColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	oldDB, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	// xray_configs as created before the environment/promoted_from columns
+	_, err = oldDB.Exec(`CREATE TABLE xray_configs (
+		id TEXT PRIMARY KEY, name TEXT UNIQUE, description TEXT, created_at DATETIME, updated_at DATETIME,
+		log_config TEXT, api_config TEXT, dns_config TEXT, routing_config TEXT, policy_config TEXT,
+		inbounds TEXT, outbounds TEXT, transport_config TEXT, stats_config TEXT, reverse_config TEXT,
+		fakedns_config TEXT, metrics_config TEXT, observatory_config TEXT, burst_observatory_config TEXT)`)
+	require.NoError(t, err)
+	_, err = oldDB.Exec(`INSERT INTO xray_configs (id, name, description, created_at, updated_at) VALUES ('old-1', 'legacy', '', ?, ?)`, time.Now().UTC(), time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, oldDB.Close())
+
+	store, err := NewSQLiteStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	legacy, err := store.GetXrayConfig(ctx, "old-1")
+	require.NoError(t, err)
+	assert.Equal(t, "", legacy.Environment)
+	var storedHash string
+	require.NoError(t, store.db.QueryRowContext(ctx, `SELECT content_hash FROM xray_configs WHERE id = 'old-1'`).Scan(&storedHash))
+	assert.Equal(t, legacy.ConfigHash, storedHash, "existing rows must be backfilled with their content hash")
+
+	fresh := &models.XrayConfig{Name: "fresh", Environment: "staging"}
+	require.NoError(t, store.CreateXrayConfig(ctx, fresh))
+	got, err := store.GetXrayConfig(ctx, fresh.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "staging", got.Environment)
+}
+
+func TestListXrayDuplicates(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	level := StringPtr("warning")
+	first := &models.XrayConfig{Name: "first", Log: &models.LogObject{Loglevel: level}}
+	second := &models.XrayConfig{Name: "second", Description: "same content", Log: &models.LogObject{Loglevel: level}}
+	unique := &models.XrayConfig{Name: "unique", Log: &models.LogObject{Loglevel: StringPtr("debug")}}
+	for _, cfg := range []*models.XrayConfig{first, second, unique} {
+		require.NoError(t, store.CreateXrayConfig(ctx, cfg))
+	}
+	assert.Equal(t, first.ConfigHash, second.ConfigHash)
+
+	groups, err := store.ListXrayDuplicates(ctx)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, first.ConfigHash, groups[0].ConfigHash)
+	require.Len(t, groups[0].Configs, 2)
+	assert.Equal(t, first.ID, groups[0].Configs[0].ID, "oldest config comes first")
+	assert.Equal(t, second.Name, groups[0].Configs[1].Name)
+
+	refs, err := store.ListXrayConfigsByHash(ctx, unique.ConfigHash)
+	require.NoError(t, err)
+	require.Len(t, refs, 1)
+	assert.Equal(t, unique.ID, refs[0].ID)
+
+	// Editing a copy so it differs breaks up the group
+	second.Log.Loglevel = StringPtr("error")
+	require.NoError(t, store.UpdateXrayConfig(ctx, second))
+	groups, err = store.ListXrayDuplicates(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	refs, err = store.ListXrayConfigsByHash(ctx, "no-such-hash")
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+}
@@ -0,0 +1,22 @@
+// Package sqlitetest provides the SQLite store fixture shared by tests
+// outside package sqlite.
+package sqlitetest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite"
+)
+
+// New returns a store backed by a fresh database in tb's temporary
+// directory. The store is closed when the test ends.
+func New(tb testing.TB) *sqlite.SQLiteStore {
+	tb.Helper()
+	st, err := sqlite.NewSQLiteStore(filepath.Join(tb.TempDir(), "test.db"))
+	if err != nil {
+		tb.Fatalf("open test store: %v", err)
+	}
+	tb.Cleanup(func() { st.Close() })
+	return st
+}
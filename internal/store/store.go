@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/tools4net/ezfw/backend/internal/models"
 )
@@ -11,16 +12,71 @@ type Store interface {
 	// SingBox Configuration methods
 	CreateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) error
 	GetSingBoxConfig(ctx context.Context, id string) (*models.SingBoxConfig, error)
-	ListSingBoxConfigs(ctx context.Context, limit, offset int) ([]*models.SingBoxConfig, error)
+	ListSingBoxConfigs(ctx context.Context, limit, offset int, sort Sort) ([]*models.SingBoxConfig, error)
 	UpdateSingBoxConfig(ctx context.Context, config *models.SingBoxConfig) error
 	DeleteSingBoxConfig(ctx context.Context, id string) error
-	// CountSingBoxConfigs(ctx context.Context) (int, error) // Optional: for pagination metadata
+	// ListSingBoxConfigsUpdatedSince returns configs modified after since, oldest first.
+	ListSingBoxConfigsUpdatedSince(ctx context.Context, since time.Time) ([]*models.SingBoxConfig, error)
+	CountSingBoxConfigs(ctx context.Context) (int, error)
 
 	// Xray Configuration methods
 	CreateXrayConfig(ctx context.Context, config *models.XrayConfig) error
 	GetXrayConfig(ctx context.Context, id string) (*models.XrayConfig, error)
-	ListXrayConfigs(ctx context.Context, limit, offset int) ([]*models.XrayConfig, error)
+	GetXrayConfigByName(ctx context.Context, name string) (*models.XrayConfig, error)
+	// XrayConfigExists reports whether a config with id exists without loading it.
+	XrayConfigExists(ctx context.Context, id string) (bool, error)
+	// GetMultipleXrayConfigs fetches configs by ID in one query; missing IDs are omitted.
+	GetMultipleXrayConfigs(ctx context.Context, ids []string) (map[string]*models.XrayConfig, error)
+	ListXrayConfigs(ctx context.Context, limit, offset int, sort Sort) ([]*models.XrayConfig, error)
+	// ListXrayConfigsByModelVersion lists configs authored for modelVersion.
+	ListXrayConfigsByModelVersion(ctx context.Context, modelVersion string, limit, offset int, sort Sort) ([]*models.XrayConfig, error)
+	// ListXrayConfigsWithSection lists configs where section (e.g. "dns") is set;
+	// an unknown section yields ErrUnknownSection.
+	ListXrayConfigsWithSection(ctx context.Context, section string, limit, offset int, sort Sort) ([]*models.XrayConfig, error)
 	UpdateXrayConfig(ctx context.Context, config *models.XrayConfig) error
+	// UpdateXrayConfigs updates all configs atomically: if any update fails
+	// none is applied.
+	UpdateXrayConfigs(ctx context.Context, configs ...*models.XrayConfig) error
+	// RenameXrayConfig changes only the name; a taken name yields ErrConflict.
+	RenameXrayConfig(ctx context.Context, id, newName string) error
 	DeleteXrayConfig(ctx context.Context, id string) error
-	// CountXrayConfigs(ctx context.Context) (int, error) // Optional: for pagination metadata
+	// ListXrayConfigsUpdatedSince returns configs modified after since, oldest first.
+	ListXrayConfigsUpdatedSince(ctx context.Context, since time.Time) ([]*models.XrayConfig, error)
+	// ListXrayConfigsByHash lists configs with the given content hash, oldest first.
+	ListXrayConfigsByHash(ctx context.Context, hash string) ([]models.ConfigRef, error)
+	// ListXrayDuplicates groups configs sharing a content hash.
+	ListXrayDuplicates(ctx context.Context) ([]models.DuplicateGroup, error)
+	// GetXrayConfigPromotion returns the config promoted from sourceID into environment.
+	GetXrayConfigPromotion(ctx context.Context, sourceID, environment string) (*models.XrayConfig, error)
+	CountXrayConfigs(ctx context.Context) (int, error)
+
+	// SingBox rule-set methods
+	CreateRuleSet(ctx context.Context, ruleSet *models.SingBoxRuleSet) error
+	GetRuleSet(ctx context.Context, id string) (*models.SingBoxRuleSet, error)
+	ListRuleSets(ctx context.Context) ([]*models.SingBoxRuleSet, error)
+	UpdateRuleSet(ctx context.Context, ruleSet *models.SingBoxRuleSet) error
+	DeleteRuleSet(ctx context.Context, id string) error
+
+	// Client index methods
+	ListClientPlacements(ctx context.Context, email string) ([]models.ClientPlacement, error)
+	RebuildClientIndex(ctx context.Context) (int, error)
+
+	// RotateDataKeys re-encrypts sensitive values with the current master key
+	// and returns the number of rows rewritten.
+	RotateDataKeys(ctx context.Context) (int, error)
+
+	// Search finds configs matching query, grouped by resource type.
+	Search(ctx context.Context, query string, types []string, limitPerType int) (*models.SearchResults, error)
+
+	// Idempotency key methods
+	GetIdempotencyRecord(ctx context.Context, keyHash string) (*models.IdempotencyRecord, error)
+	SaveIdempotencyRecord(ctx context.Context, record *models.IdempotencyRecord) error
+	ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) (bool, error)
+	ReleaseIdempotencyKey(ctx context.Context, keyHash string) error
+	DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error)
+
+	// Background job run history
+	CreateJobRun(ctx context.Context, run *models.JobRun) error
+	ListJobRuns(ctx context.Context, job string, limit int) ([]*models.JobRun, error)
+	DeleteJobRunsBefore(ctx context.Context, cutoff time.Time) (int64, error)
 }
@@ -0,0 +1,68 @@
+package store_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/store"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite"
+	"github.com/tools4net/ezfw/backend/internal/store/sqlite/sqlitetest"
+)
+
+var _ store.Store = (*sqlite.SQLiteStore)(nil)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// zeroArgs builds arguments for method m: a background context for contexts,
+// a pointer to a zero value for pointers and the zero value otherwise.
+func zeroArgs(m reflect.Method) []reflect.Value {
+	args := make([]reflect.Value, m.Type.NumIn())
+	for i := range args {
+		in := m.Type.In(i)
+		switch {
+		case in == contextType:
+			args[i] = reflect.ValueOf(context.Background())
+		case in.Kind() == reflect.Ptr:
+			args[i] = reflect.New(in.Elem())
+		default:
+			args[i] = reflect.Zero(in)
+		}
+	}
+	return args
+}
+
+// TestStoreInterfaceConsistency calls every store.Store method on each
+// implementation with zero-value arguments. Errors are expected; panics are
+// not.
+func TestStoreInterfaceConsistency(t *testing.T) {
+	implementations := map[string]func(t *testing.T) store.Store{
+		"sqlite": func(t *testing.T) store.Store {
+			return sqlitetest.New(t)
+		},
+		"instrumented": func(t *testing.T) store.Store {
+			return store.NewInstrumented(sqlitetest.New(t), 0)
+		},
+	}
+
+	iface := reflect.TypeOf((*store.Store)(nil)).Elem()
+	for name, open := range implementations {
+		t.Run(name, func(t *testing.T) {
+			st := reflect.ValueOf(open(t))
+			for i := 0; i < iface.NumMethod(); i++ {
+				m := iface.Method(i)
+				t.Run(m.Name, func(t *testing.T) {
+					require.NotPanics(t, func() {
+						method := st.MethodByName(m.Name)
+						if m.Type.IsVariadic() {
+							method.CallSlice(zeroArgs(m))
+						} else {
+							method.Call(zeroArgs(m))
+						}
+					})
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package template instantiates config templates, filling in variables the
+// operator does not supply.
+package template
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Variable types that are generated rather than supplied.
+const (
+	VariableUUID             = "uuid"
+	VariableX25519PrivateKey = "x25519_private_key"
+	VariableWGPrivateKey     = "wg_private_key"
+)
+
+// PublicKeySuffix is appended to a key variable's name to hold the matching
+// public key, e.g. "reality_key" and "reality_key_public".
+const PublicKeySuffix = "_public"
+
+var (
+	// ErrUnknownVariableType is returned for a variable type with no
+	// generator.
+	ErrUnknownVariableType = errors.New("unknown template variable type")
+	// ErrInvalidKey is returned for a supplied key variable that is not a
+	// base64 encoded 32-byte X25519 private key.
+	ErrInvalidKey = errors.New("invalid x25519 private key")
+)
+
+// Variable declares one template variable in the template's variables
+// metadata.
+type Variable struct {
+	Name string `json:"name" example:"vless_uuid"`
+	Type string `json:"type" example:"uuid"` // "uuid", "x25519_private_key" or "wg_private_key"
+}
+
+// GenerateVariables returns a value for every variable in vars, keyed by
+// name. Key variables also get their public key under name+PublicKeySuffix.
+// Names already present in supplied are taken from there instead of being
+// generated; for a supplied private key the public key is derived from it,
+// in the same encoding, unless that is supplied too.
+func GenerateVariables(vars []Variable, supplied map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(vars))
+	for k, v := range supplied {
+		values[k] = v
+	}
+	for _, v := range vars {
+		if private, ok := supplied[v.Name]; ok {
+			if v.Type != VariableX25519PrivateKey && v.Type != VariableWGPrivateKey {
+				continue
+			}
+			public, err := deriveX25519Public(private)
+			if err != nil {
+				return nil, fmt.Errorf("%w: variable %s: %v", ErrInvalidKey, v.Name, err)
+			}
+			if _, ok := supplied[v.Name+PublicKeySuffix]; !ok {
+				values[v.Name+PublicKeySuffix] = public
+			}
+			continue
+		}
+		switch v.Type {
+		case VariableUUID:
+			values[v.Name] = uuid.NewString()
+		case VariableX25519PrivateKey, VariableWGPrivateKey:
+			private, public, err := generateX25519()
+			if err != nil {
+				return nil, fmt.Errorf("generate %s: %w", v.Name, err)
+			}
+			values[v.Name] = private
+			values[v.Name+PublicKeySuffix] = public
+		default:
+			return nil, fmt.Errorf("%w: %q (variable %s)", ErrUnknownVariableType, v.Type, v.Name)
+		}
+	}
+	return values, nil
+}
+
+// generateX25519 returns a new X25519 key pair, each key standard base64
+// encoded (44 characters) as WireGuard expects. REALITY configs take the
+// unpadded URL-safe form of the same bytes. Scalar clamping happens when the
+// key is used, so the raw random bytes are a valid WireGuard private key.
+func generateX25519() (private, public string, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(key.Bytes()),
+		base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// deriveX25519Public returns the public key of a private key encoded as
+// generateX25519 does, or in the unpadded URL-safe form REALITY uses. The
+// public key is returned in the private key's encoding.
+func deriveX25519Public(private string) (string, error) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(private, "-_") || len(private)%4 != 0 {
+		enc = base64.RawURLEncoding
+	}
+	raw, err := enc.DecodeString(private)
+	if err != nil {
+		return "", fmt.Errorf("not base64: %v", err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return "", err
+	}
+	return enc.EncodeToString(key.PublicKey().Bytes()), nil
+}
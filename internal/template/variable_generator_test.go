@@ -0,0 +1,63 @@
+package template
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateVariables(t *testing.T) {
+	vars := []Variable{
+		{Name: "vless_uuid", Type: VariableUUID},
+		{Name: "reality_key", Type: VariableX25519PrivateKey},
+		{Name: "wg_key", Type: VariableWGPrivateKey},
+		{Name: "fixed_uuid", Type: VariableUUID},
+	}
+	values, err := GenerateVariables(vars, map[string]string{"fixed_uuid": "supplied"})
+	require.NoError(t, err)
+
+	_, err = uuid.Parse(values["vless_uuid"])
+	assert.NoError(t, err, "generated UUID must parse")
+	assert.Equal(t, "supplied", values["fixed_uuid"], "supplied values win")
+
+	for _, name := range []string{"reality_key", "wg_key"} {
+		private := values[name]
+		assert.Len(t, private, 44)
+		raw, err := base64.StdEncoding.DecodeString(private)
+		require.NoError(t, err)
+		key, err := ecdh.X25519().NewPrivateKey(raw)
+		require.NoError(t, err)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), values[name+PublicKeySuffix])
+	}
+	assert.NotEqual(t, values["reality_key"], values["wg_key"])
+}
+
+func TestGenerateVariables_UnknownType(t *testing.T) {
+	_, err := GenerateVariables([]Variable{{Name: "x", Type: "rsa_key"}}, nil)
+	assert.ErrorIs(t, err, ErrUnknownVariableType)
+}
+
+func TestGenerateVariables_SuppliedKey(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	vars := []Variable{{Name: "wg_key", Type: VariableWGPrivateKey}, {Name: "reality_key", Type: VariableX25519PrivateKey}}
+
+	values, err := GenerateVariables(vars, map[string]string{
+		"wg_key":      base64.StdEncoding.EncodeToString(key.Bytes()),
+		"reality_key": base64.RawURLEncoding.EncodeToString(key.Bytes()),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), values["wg_key"+PublicKeySuffix])
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), values["reality_key"+PublicKeySuffix],
+		"the public key uses the private key's encoding")
+
+	for _, bad := range []string{"not-a-key!", base64.StdEncoding.EncodeToString([]byte("too short"))} {
+		_, err = GenerateVariables(vars[:1], map[string]string{"wg_key": bad})
+		assert.ErrorIs(t, err, ErrInvalidKey, bad)
+	}
+}
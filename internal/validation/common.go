@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// EnvValidateHostnames, when set to "true", makes ValidateHostname also
+// require that the hostname resolves.
+const EnvValidateHostnames = "VALIDATE_HOSTNAMES"
+
+// hostnameLabel matches one RFC 1123 label.
+var hostnameLabel = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// ValidatePort returns a ValidationError for fieldName unless port is a
+// usable TCP/UDP port number (1-65535).
+func ValidatePort(port int, fieldName string) error {
+	if port < 1 || port > 65535 {
+		return ValidationError{
+			Field:   fieldName,
+			Message: "port must be between 1 and 65535",
+			Value:   strconv.Itoa(port),
+		}
+	}
+	return nil
+}
+
+// ValidateIPAddress returns a ValidationError unless ip is a literal IPv4 or
+// IPv6 address.
+func ValidateIPAddress(ip string) error {
+	if ip == "" {
+		return ValidationError{Field: "ip_address", Message: "IP address is required"}
+	}
+	if net.ParseIP(ip) == nil {
+		return ValidationError{Field: "ip_address", Message: "not a valid IPv4 or IPv6 address", Value: ip}
+	}
+	return nil
+}
+
+// ValidateHostname returns a ValidationError unless hostname is a
+// syntactically valid DNS name. A trailing dot is allowed. When
+// VALIDATE_HOSTNAMES=true the name must also resolve.
+func ValidateHostname(hostname string) error {
+	if hostname == "" {
+		return ValidationError{Field: "hostname", Message: "hostname is required"}
+	}
+	name := strings.TrimSuffix(hostname, ".")
+	if len(name) == 0 || len(name) > 253 {
+		return ValidationError{Field: "hostname", Message: "hostname must be 1 to 253 characters", Value: hostname}
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return ValidationError{Field: "hostname", Message: "not a valid hostname", Value: hostname}
+		}
+	}
+	if os.Getenv(EnvValidateHostnames) == "true" {
+		if _, err := net.LookupHost(name); err != nil {
+			return ValidationError{Field: "hostname", Message: "hostname does not resolve", Value: hostname}
+		}
+	}
+	return nil
+}
+
+// SingBoxInboundPortErrors runs ValidatePort on the listen_port of every
+// SingBox inbound that sets one.
+func SingBoxInboundPortErrors(config *models.SingBoxConfig) []ValidationError {
+	var errs []ValidationError
+	for i, in := range config.Inbounds {
+		if in == nil || in.ListenPort == nil {
+			continue
+		}
+		if err := ValidatePort(*in.ListenPort, fmt.Sprintf("inbounds[%d].listen_port", i)); err != nil {
+			errs = append(errs, err.(ValidationError))
+		}
+	}
+	return errs
+}
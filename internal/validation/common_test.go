@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestValidatePort(t *testing.T) {
+	tests := []struct {
+		port  int
+		valid bool
+	}{
+		{-1, false}, {0, false}, {1, true}, {80, true}, {65535, true}, {65536, false},
+	}
+	for _, tc := range tests {
+		err := ValidatePort(tc.port, "port")
+		if tc.valid {
+			assert.NoError(t, err, "port %d", tc.port)
+			continue
+		}
+		var verr ValidationError
+		require.ErrorAs(t, err, &verr, "port %d", tc.port)
+		assert.Equal(t, "port", verr.Field)
+	}
+}
+
+func TestSingBoxInboundPortErrors(t *testing.T) {
+	port := func(p int) *int { return &p }
+	config := &models.SingBoxConfig{Inbounds: []*models.SingBoxInbound{
+		{Tag: "ok", ListenPort: port(443)},
+		{Tag: "unset"},
+		{Tag: "bad", ListenPort: port(70000)},
+	}}
+
+	errs := SingBoxInboundPortErrors(config)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "inbounds[2].listen_port", errs[0].Field)
+	assert.Equal(t, "70000", errs[0].Value)
+}
+
+func TestValidateIPAddress(t *testing.T) {
+	for _, ip := range []string{"192.0.2.10", "0.0.0.0", "2001:db8::1", "::1"} {
+		assert.NoError(t, ValidateIPAddress(ip), ip)
+	}
+	for _, ip := range []string{"", "not-an-ip", "256.1.1.1", "192.0.2.10/24", "example.com"} {
+		var verr ValidationError
+		require.ErrorAs(t, ValidateIPAddress(ip), &verr, ip)
+		assert.Equal(t, "ip_address", verr.Field)
+	}
+}
+
+func TestValidateHostname_Offline(t *testing.T) {
+	t.Setenv(EnvValidateHostnames, "")
+	for _, h := range []string{"localhost", "node-1.example.com", "example.com.", "xn--bcher-kva.example"} {
+		assert.NoError(t, ValidateHostname(h), h)
+	}
+	for _, h := range []string{"", ".", "-node.example.com", "node-.example.com", "a..b", "under_score.example", strings.Repeat("a", 64) + ".com"} {
+		var verr ValidationError
+		require.ErrorAs(t, ValidateHostname(h), &verr, h)
+		assert.Equal(t, "hostname", verr.Field)
+	}
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/tools4net/ezfw/backend/internal/graph"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// XrayDetourCycles reports outbounds chained through themselves via
+// proxySettings, sockopt.dialerProxy or balancers. Xray would loop forever
+// dialing such an outbound.
+func XrayDetourCycles(config *models.XrayConfig) []ValidationError {
+	return cycleErrors(graph.BuildXray(config))
+}
+
+// SingBoxDetourCycles is the SingBox counterpart of XrayDetourCycles,
+// covering detours and selector or urltest groups.
+func SingBoxDetourCycles(config *models.SingBoxConfig) []ValidationError {
+	return cycleErrors(graph.BuildSingBox(config))
+}
+
+// cycleErrors reports each cycle of g at the node it starts from.
+func cycleErrors(g *graph.Graph) []ValidationError {
+	nodes := make(map[string]graph.Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+	var errs []ValidationError
+	for _, cycle := range g.Cycles {
+		start := nodes[cycle[0]]
+		tags := make([]string, len(cycle))
+		for i, id := range cycle {
+			tags[i] = nodes[id].Tag
+		}
+		errs = append(errs, ValidationError{
+			Field:   start.Path,
+			Message: "detour cycle: " + strings.Join(tags, " -> "),
+			Value:   start.Tag,
+		})
+	}
+	return errs
+}
@@ -0,0 +1,27 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestXrayDetourCycles(t *testing.T) {
+	config := &models.XrayConfig{
+		Outbounds: []models.OutboundObject{
+			{Tag: strPtr("direct")},
+			{Tag: strPtr("hop-a"), ProxySettings: &models.ProxySettings{Tag: strPtr("hop-b")}},
+			{Tag: strPtr("hop-b"), ProxySettings: &models.ProxySettings{Tag: strPtr("hop-a")}},
+		},
+	}
+
+	errs := XrayDetourCycles(config)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "outbounds[1]", errs[0].Field)
+	assert.Equal(t, "detour cycle: hop-a -> hop-b -> hop-a", errs[0].Message)
+
+	config.Outbounds[2].ProxySettings = nil
+	assert.Empty(t, XrayDetourCycles(config))
+}
@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// Opt-in checks for the public-facing address of a node. Both are off by
+// default so development setups can use local addresses.
+const (
+	// EnvRejectPrivateIPs, when "true", makes ValidateNodeIPAddress reject
+	// private (RFC 1918 and IPv6 ULA) and loopback addresses.
+	EnvRejectPrivateIPs = "REJECT_PRIVATE_IPS"
+	// EnvRejectReservedHostnames, when "true", makes ValidateNodeHostname
+	// reject names that always point at the local machine.
+	EnvRejectReservedHostnames = "REJECT_RESERVED_HOSTNAMES"
+)
+
+// reservedHostnames always resolve to the machine asking.
+var reservedHostnames = []string{"localhost", "127.0.0.1", "::1"}
+
+// ValidateNodeIPAddress is ValidateIPAddress for the public address of a
+// node, additionally rejecting private and loopback addresses when
+// REJECT_PRIVATE_IPS=true.
+func ValidateNodeIPAddress(ip string) error {
+	if err := ValidateIPAddress(ip); err != nil {
+		return err
+	}
+	if os.Getenv(EnvRejectPrivateIPs) != "true" {
+		return nil
+	}
+	parsed := net.ParseIP(ip)
+	if parsed.IsLoopback() {
+		return ValidationError{Field: "ip_address", Message: "loopback addresses cannot be used for public-facing nodes", Value: ip}
+	}
+	if parsed.IsPrivate() {
+		return ValidationError{Field: "ip_address", Message: "private addresses cannot be used for public-facing nodes", Value: ip}
+	}
+	return nil
+}
+
+// ValidateNodeHostname is ValidateHostname for the public hostname of a
+// node, additionally rejecting localhost, 127.0.0.1 and ::1 when
+// REJECT_RESERVED_HOSTNAMES=true.
+func ValidateNodeHostname(hostname string) error {
+	if os.Getenv(EnvRejectReservedHostnames) == "true" {
+		name := strings.ToLower(strings.TrimSuffix(hostname, "."))
+		for _, reserved := range reservedHostnames {
+			if name == reserved {
+				return ValidationError{Field: "hostname", Message: "reserved hostname cannot be used for public-facing nodes", Value: hostname}
+			}
+		}
+	}
+	return ValidateHostname(hostname)
+}
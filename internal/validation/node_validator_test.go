@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateNodeIPAddress(t *testing.T) {
+	private := []string{"10.1.2.3", "172.16.0.1", "172.31.255.254", "192.168.1.1", "127.0.0.1", "::1", "fd00::1"}
+	public := []string{"192.0.2.10", "172.32.0.1", "8.8.8.8", "2001:db8::1"}
+
+	t.Setenv(EnvRejectPrivateIPs, "")
+	for _, ip := range append(private, public...) {
+		assert.NoError(t, ValidateNodeIPAddress(ip), ip)
+	}
+
+	t.Setenv(EnvRejectPrivateIPs, "true")
+	for _, ip := range private {
+		var verr ValidationError
+		require.ErrorAs(t, ValidateNodeIPAddress(ip), &verr, ip)
+		assert.Equal(t, "ip_address", verr.Field)
+		assert.Equal(t, ip, verr.Value)
+	}
+	for _, ip := range public {
+		assert.NoError(t, ValidateNodeIPAddress(ip), ip)
+	}
+	assert.Error(t, ValidateNodeIPAddress("not-an-ip"), "syntax is still checked")
+}
+
+func TestValidateNodeHostname(t *testing.T) {
+	t.Setenv(EnvValidateHostnames, "")
+	reserved := []string{"localhost", "LOCALHOST", "localhost.", "127.0.0.1"}
+
+	t.Setenv(EnvRejectReservedHostnames, "")
+	for _, h := range append(reserved, "node-1.example.com") {
+		assert.NoError(t, ValidateNodeHostname(h), h)
+	}
+
+	t.Setenv(EnvRejectReservedHostnames, "true")
+	for _, h := range append(reserved, "::1") {
+		var verr ValidationError
+		require.ErrorAs(t, ValidateNodeHostname(h), &verr, h)
+		assert.Equal(t, "hostname", verr.Field)
+		assert.Contains(t, verr.Message, "reserved", h)
+	}
+	assert.NoError(t, ValidateNodeHostname("node-1.example.com"))
+	assert.NoError(t, ValidateNodeHostname("localhost.example.com"))
+}
@@ -0,0 +1,112 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// RuleSetReference is a single rule_set tag used by a route or DNS rule.
+type RuleSetReference struct {
+	Field string // e.g. "route.rules[1].rule_set[0]"
+	Tag   string
+}
+
+// ValidateSingBoxRuleSet checks a managed rule-set before it is stored.
+func ValidateSingBoxRuleSet(rs *models.SingBoxRuleSet) []ValidationError {
+	var errs []ValidationError
+	if rs.Tag == "" {
+		errs = append(errs, ValidationError{Field: "tag", Message: "tag is required"})
+	}
+	if rs.Format != models.RuleSetFormatSource && rs.Format != models.RuleSetFormatBinary {
+		errs = append(errs, ValidationError{Field: "format", Message: "format must be \"source\" or \"binary\"", Value: rs.Format})
+	}
+	switch rs.Type {
+	case models.RuleSetTypeRemote:
+		u, err := url.Parse(rs.URL)
+		if rs.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, ValidationError{Field: "url", Message: "remote rule-sets need an http(s) URL", Value: rs.URL})
+		}
+	case models.RuleSetTypeLocal:
+		if rs.Path == "" {
+			errs = append(errs, ValidationError{Field: "path", Message: "local rule-sets need a path"})
+		}
+	default:
+		errs = append(errs, ValidationError{Field: "type", Message: "type must be \"remote\" or \"local\"", Value: rs.Type})
+	}
+	return errs
+}
+
+// SingBoxRuleSetReferences lists every rule_set tag used by the route and DNS
+// rules of config, including rules nested in logical rules.
+func SingBoxRuleSetReferences(config *models.SingBoxConfig) []RuleSetReference {
+	var refs []RuleSetReference
+	if config.Route != nil {
+		refs = collectRouteRuleSetRefs(refs, "route.rules", config.Route.Rules)
+	}
+	if config.DNS != nil {
+		refs = collectDNSRuleSetRefs(refs, "dns.rules", config.DNS.Rules)
+	}
+	return refs
+}
+
+func collectRouteRuleSetRefs(refs []RuleSetReference, prefix string, rules []*models.SingBoxRouteRule) []RuleSetReference {
+	for i, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		path := fmt.Sprintf("%s[%d]", prefix, i)
+		for j, tag := range rule.RuleSet {
+			refs = append(refs, RuleSetReference{Field: fmt.Sprintf("%s.rule_set[%d]", path, j), Tag: tag})
+		}
+		refs = collectRouteRuleSetRefs(refs, path+".rules", rule.Rules)
+	}
+	return refs
+}
+
+func collectDNSRuleSetRefs(refs []RuleSetReference, prefix string, rules []*models.SingBoxDNSRule) []RuleSetReference {
+	for i, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		path := fmt.Sprintf("%s[%d]", prefix, i)
+		for j, tag := range rule.RuleSet {
+			refs = append(refs, RuleSetReference{Field: fmt.Sprintf("%s.rule_set[%d]", path, j), Tag: tag})
+		}
+		refs = collectDNSRuleSetRefs(refs, path+".rules", rule.Rules)
+	}
+	return refs
+}
+
+// InlineRuleSetTags returns the tags of rule-sets defined directly in
+// config.route.rule_set.
+func InlineRuleSetTags(config *models.SingBoxConfig) map[string]bool {
+	tags := make(map[string]bool)
+	if config.Route == nil {
+		return tags
+	}
+	for _, rs := range config.Route.RuleSet {
+		if tag, ok := rs["tag"].(string); ok && tag != "" {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+// SingBoxRuleSetReferenceErrors reports every rule_set reference that is
+// neither defined inline in the config nor one of the managed rule-sets.
+func SingBoxRuleSetReferenceErrors(config *models.SingBoxConfig, managed []*models.SingBoxRuleSet) []ValidationError {
+	known := InlineRuleSetTags(config)
+	for _, rs := range managed {
+		known[rs.Tag] = true
+	}
+
+	var errs []ValidationError
+	for _, ref := range SingBoxRuleSetReferences(config) {
+		if !known[ref.Tag] {
+			errs = append(errs, ValidationError{Field: ref.Field, Message: "unknown rule-set tag", Value: ref.Tag})
+		}
+	}
+	return errs
+}
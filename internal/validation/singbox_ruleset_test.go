@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestSingBoxRuleSetReferenceErrors(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Route: &models.SingBoxRouteConfig{
+			Rules: []*models.SingBoxRouteRule{
+				{RuleSet: []string{"inline", "managed"}},
+				{Mode: strPtr("and"), Rules: []*models.SingBoxRouteRule{{RuleSet: []string{"nope"}}}},
+			},
+			RuleSet: []map[string]interface{}{{"tag": "inline", "type": "local"}},
+		},
+		DNS: &models.SingBoxDNSConfig{Rules: []*models.SingBoxDNSRule{{RuleSet: []string{"managed", "missing"}}}},
+	}
+	managed := []*models.SingBoxRuleSet{{Tag: "managed"}}
+
+	errs := SingBoxRuleSetReferenceErrors(config, managed)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "route.rules[1].rules[0].rule_set[0]", errs[0].Field)
+	assert.Equal(t, "nope", errs[0].Value)
+	assert.Equal(t, "dns.rules[0].rule_set[1]", errs[1].Field)
+	assert.Equal(t, "missing", errs[1].Value)
+}
+
+func TestValidateSingBoxRuleSet(t *testing.T) {
+	valid := &models.SingBoxRuleSet{Tag: "cn", Type: models.RuleSetTypeRemote, Format: models.RuleSetFormatBinary, URL: "https://example.com/cn.srs"}
+	assert.Empty(t, ValidateSingBoxRuleSet(valid))
+
+	errs := ValidateSingBoxRuleSet(&models.SingBoxRuleSet{Type: models.RuleSetTypeRemote, Format: "yaml", URL: "ftp://example.com/x"})
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	assert.ElementsMatch(t, []string{"tag", "format", "url"}, fields)
+
+	errs = ValidateSingBoxRuleSet(&models.SingBoxRuleSet{Tag: "local", Type: models.RuleSetTypeLocal, Format: models.RuleSetFormatSource})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "path", errs[0].Field)
+}
@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// SingBoxInboundTypes lists the inbound types sing-box accepts. Append to it
+// when a new sing-box release adds one.
+var SingBoxInboundTypes = []string{
+	"direct", "mixed", "socks", "http", "shadowsocks", "vmess", "trojan", "naive",
+	"hysteria", "shadowtls", "tuic", "hysteria2", "vless", "anytls", "tun", "redirect", "tproxy",
+}
+
+// SingBoxOutboundTypes lists the outbound types sing-box accepts. Append to
+// it when a new sing-box release adds one.
+var SingBoxOutboundTypes = []string{
+	"direct", "block", "socks", "http", "shadowsocks", "vmess", "trojan", "wireguard",
+	"hysteria", "shadowtls", "vless", "tuic", "hysteria2", "anytls", "tor", "ssh", "dns",
+	"selector", "urltest",
+}
+
+// SingBoxUnknownTypes reports inbounds and outbounds whose type is not in
+// SingBoxInboundTypes or SingBoxOutboundTypes. sing-box refuses to start
+// with such a config, typically because of a typo like "mixedd".
+func SingBoxUnknownTypes(config *models.SingBoxConfig) []ValidationError {
+	var errs []ValidationError
+	for i, in := range config.Inbounds {
+		if in != nil && !contains(SingBoxInboundTypes, in.Type) {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("inbounds[%d].type", i),
+				Message: fmt.Sprintf("unknown inbound type on tag %q", in.Tag),
+				Value:   in.Type,
+			})
+		}
+	}
+	for i, out := range config.Outbounds {
+		if out != nil && !contains(SingBoxOutboundTypes, out.Type) {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("outbounds[%d].type", i),
+				Message: fmt.Sprintf("unknown outbound type on tag %q", out.Tag),
+				Value:   out.Type,
+			})
+		}
+	}
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
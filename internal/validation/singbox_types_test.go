@@ -0,0 +1,38 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestSingBoxUnknownTypes(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Inbounds:  []*models.SingBoxInbound{{Type: "mixed", Tag: "mixed-in"}, {Type: "mixedd", Tag: "typo-in"}},
+		Outbounds: []*models.SingBoxOutbound{{Type: "direct", Tag: "direct"}, {Type: "", Tag: "untyped"}},
+	}
+
+	errs := SingBoxUnknownTypes(config)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "inbounds[1].type", errs[0].Field)
+	assert.Equal(t, "mixedd", errs[0].Value)
+	assert.Contains(t, errs[0].Message, `"typo-in"`)
+	assert.Equal(t, "outbounds[1].type", errs[1].Field)
+	assert.Contains(t, errs[1].Message, `"untyped"`)
+
+	config.Inbounds = config.Inbounds[:1]
+	config.Outbounds = config.Outbounds[:1]
+	assert.Empty(t, SingBoxUnknownTypes(config))
+}
+
+func TestSingBoxUnknownTypes_Extensible(t *testing.T) {
+	saved := SingBoxInboundTypes
+	t.Cleanup(func() { SingBoxInboundTypes = saved })
+
+	config := &models.SingBoxConfig{Inbounds: []*models.SingBoxInbound{{Type: "future", Tag: "in"}}}
+	assert.Len(t, SingBoxUnknownTypes(config), 1)
+	SingBoxInboundTypes = append(append([]string(nil), saved...), "future")
+	assert.Empty(t, SingBoxUnknownTypes(config))
+}
@@ -0,0 +1,111 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+	"github.com/tools4net/ezfw/backend/internal/version"
+)
+
+// SingBoxTypedDNSVersion is the sing-box release that deprecated the legacy
+// address based DNS server format in favour of typed servers.
+const SingBoxTypedDNSVersion = "1.12.0"
+
+// SingBoxDNSLegacyWarnings reports DNS servers that still use the legacy
+// address/address_resolver/address_strategy/strategy fields. The result is
+// only non-empty when targetVersion is SingBoxTypedDNSVersion or newer; the
+// entries are advisory and should not block saving the config.
+func SingBoxDNSLegacyWarnings(dns *models.SingBoxDNSConfig, targetVersion string) []ValidationError {
+	if dns == nil || targetVersion == "" || !version.AtLeast(targetVersion, SingBoxTypedDNSVersion) {
+		return nil
+	}
+
+	var warnings []ValidationError
+	for i, server := range dns.Servers {
+		if server == nil {
+			continue
+		}
+		legacy := map[string]*string{
+			"address":          server.Address,
+			"address_resolver": server.AddressResolver,
+			"address_strategy": server.AddressStrategy,
+			"strategy":         server.Strategy,
+		}
+		for _, name := range []string{"address", "address_resolver", "address_strategy", "strategy"} {
+			if v := legacy[name]; v != nil {
+				warnings = append(warnings, ValidationError{
+					Field:   fmt.Sprintf("dns.servers[%d].%s", i, name),
+					Message: fmt.Sprintf("legacy DNS server field is deprecated since sing-box %s", SingBoxTypedDNSVersion),
+					Value:   *v,
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// MaxRuleNestingDepth is the deepest logical rule nesting accepted. Deeper
+// trees are rejected outright to bound validation and generation work.
+const MaxRuleNestingDepth = 5
+
+// ValidateSingBoxRouteRule checks a route rule and its nested rules. depth is
+// 0 for entries of route.rules. Top-level rules must name an outbound or a
+// balancer; rules nested in a logical rule inherit the parent's action.
+// Logical rules need both mode ("and"/"or") and at least one nested rule.
+// Field paths are relative to rule, e.g. "rules[1].mode".
+func ValidateSingBoxRouteRule(rule *models.SingBoxRouteRule, depth int) []ValidationError {
+	if rule == nil {
+		return []ValidationError{{Message: "rule must not be null"}}
+	}
+	if depth > MaxRuleNestingDepth {
+		return []ValidationError{{Message: fmt.Sprintf("logical rules may be nested at most %d levels deep", MaxRuleNestingDepth)}}
+	}
+
+	var errs []ValidationError
+	logical := (rule.Type != nil && *rule.Type == "logical") || rule.Mode != nil || len(rule.Rules) > 0
+	if depth == 0 && rule.Outbound == nil && rule.Balancer == nil {
+		errs = append(errs, ValidationError{Field: "outbound", Message: "rule must set outbound"})
+	}
+	if !logical {
+		return errs
+	}
+
+	if rule.Mode == nil {
+		errs = append(errs, ValidationError{Field: "mode", Message: "logical rule must set mode"})
+	} else if *rule.Mode != "and" && *rule.Mode != "or" {
+		errs = append(errs, ValidationError{Field: "mode", Message: "mode must be \"and\" or \"or\"", Value: *rule.Mode})
+	}
+	if len(rule.Rules) == 0 {
+		errs = append(errs, ValidationError{Field: "rules", Message: "logical rule must contain at least one rule"})
+	}
+	for i, nested := range rule.Rules {
+		for _, e := range ValidateSingBoxRouteRule(nested, depth+1) {
+			e.Field = joinField(fmt.Sprintf("rules[%d]", i), e.Field)
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// ValidateSingBoxRouteRules runs ValidateSingBoxRouteRule on every entry of
+// route.rules, with fields prefixed by "route.rules[i]".
+func ValidateSingBoxRouteRules(route *models.SingBoxRouteConfig) []ValidationError {
+	if route == nil {
+		return nil
+	}
+	var errs []ValidationError
+	for i, rule := range route.Rules {
+		for _, e := range ValidateSingBoxRouteRule(rule, 0) {
+			e.Field = joinField(fmt.Sprintf("route.rules[%d]", i), e.Field)
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+func joinField(prefix, field string) string {
+	if field == "" {
+		return prefix
+	}
+	return prefix + "." + field
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestSingBoxDNSLegacyWarnings(t *testing.T) {
+	dns := &models.SingBoxDNSConfig{Servers: []*models.SingBoxDNSServer{
+		{Tag: strPtr("legacy"), Address: strPtr("tls://1.1.1.1"), AddressResolver: strPtr("local")},
+		{Tag: strPtr("typed"), Type: strPtr("udp"), Server: strPtr("8.8.8.8")},
+	}}
+
+	warnings := SingBoxDNSLegacyWarnings(dns, "1.12.0")
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "dns.servers[0].address", warnings[0].Field)
+	assert.Equal(t, "tls://1.1.1.1", warnings[0].Value)
+	assert.Equal(t, "dns.servers[0].address_resolver", warnings[1].Field)
+
+	assert.Empty(t, SingBoxDNSLegacyWarnings(dns, "1.11.4"), "legacy fields are fine before 1.12")
+	assert.Empty(t, SingBoxDNSLegacyWarnings(dns, ""))
+}
+
+func TestValidateSingBoxRouteRule(t *testing.T) {
+	assert.Empty(t, ValidateSingBoxRouteRule(&models.SingBoxRouteRule{Outbound: strPtr("direct"), Domain: []string{"a.com"}}, 0))
+
+	errs := ValidateSingBoxRouteRule(&models.SingBoxRouteRule{Domain: []string{"a.com"}}, 0)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "outbound", errs[0].Field)
+
+	logical := &models.SingBoxRouteRule{
+		Type:     strPtr("logical"),
+		Outbound: strPtr("proxy"),
+		Rules: []*models.SingBoxRouteRule{
+			{Domain: []string{"a.com"}}, // Nested rules need no outbound
+			{Rules: []*models.SingBoxRouteRule{{Domain: []string{"b.com"}}}},
+		},
+	}
+	errs = ValidateSingBoxRouteRule(logical, 0)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "mode", errs[0].Field)
+	assert.Equal(t, "rules[1].mode", errs[1].Field)
+}
+
+func TestValidateSingBoxRouteRules_MaxDepth(t *testing.T) {
+	rule := &models.SingBoxRouteRule{Domain: []string{"deep.example"}}
+	for i := 0; i <= MaxRuleNestingDepth; i++ {
+		rule = &models.SingBoxRouteRule{Mode: strPtr("and"), Rules: []*models.SingBoxRouteRule{rule}}
+	}
+	rule.Outbound = strPtr("direct")
+
+	errs := ValidateSingBoxRouteRules(&models.SingBoxRouteConfig{Rules: []*models.SingBoxRouteRule{rule}})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "route.rules[0]"+strings.Repeat(".rules[0]", MaxRuleNestingDepth+1), errs[0].Field)
+	assert.Contains(t, errs[0].Message, "nested")
+}
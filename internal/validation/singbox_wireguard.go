@@ -0,0 +1,87 @@
+package validation
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/netip"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// wireGuardKeySize is the length of a decoded WireGuard key.
+const wireGuardKeySize = 32
+
+// SingBoxWireGuardEndpointErrors checks every wireguard endpoint: its address
+// entries must be CIDR prefixes, and every peer needs a base64 public_key of
+// 32 bytes and CIDR allowed_ips.
+// Documentation: https://sing-box.sagernet.org/configuration/endpoint/wireguard/
+func SingBoxWireGuardEndpointErrors(config *models.SingBoxConfig) []ValidationError {
+	var errs []ValidationError
+	for i, endpoint := range config.Endpoints {
+		if endpoint["type"] != "wireguard" {
+			continue
+		}
+		base := fmt.Sprintf("endpoints[%d]", i)
+		if _, ok := endpoint["address"]; !ok {
+			errs = append(errs, ValidationError{Field: base + ".address", Message: "wireguard endpoint needs at least one address"})
+		}
+		errs = append(errs, cidrListErrors(endpoint["address"], base+".address")...)
+
+		peers, _ := endpoint["peers"].([]interface{})
+		if len(peers) == 0 {
+			errs = append(errs, ValidationError{Field: base + ".peers", Message: "wireguard endpoint needs at least one peer"})
+		}
+		for j, p := range peers {
+			field := fmt.Sprintf("%s.peers[%d]", base, j)
+			peer, ok := p.(map[string]interface{})
+			if !ok {
+				errs = append(errs, ValidationError{Field: field, Message: "peer must be an object"})
+				continue
+			}
+			if err := validateWireGuardKey(peer["public_key"], field+".public_key"); err != nil {
+				errs = append(errs, *err)
+			}
+			errs = append(errs, cidrListErrors(peer["allowed_ips"], field+".allowed_ips")...)
+		}
+	}
+	return errs
+}
+
+func validateWireGuardKey(v interface{}, field string) *ValidationError {
+	key, _ := v.(string)
+	if key == "" {
+		return &ValidationError{Field: field, Message: "key is required"}
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != wireGuardKeySize {
+		return &ValidationError{Field: field, Message: "key must be 32 bytes of base64", Value: key}
+	}
+	return nil
+}
+
+// cidrListErrors checks a sing-box listable field (a string or a list of
+// strings) whose entries must all be CIDR prefixes.
+func cidrListErrors(v interface{}, field string) []ValidationError {
+	var entries []interface{}
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		entries = v
+	default:
+		entries = []interface{}{v}
+	}
+
+	var errs []ValidationError
+	for i, entry := range entries {
+		s, ok := entry.(string)
+		if _, err := netip.ParsePrefix(s); !ok || err != nil {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("%s[%d]", field, i),
+				Message: "must be a CIDR prefix such as 10.0.0.2/32",
+				Value:   fmt.Sprint(entry),
+			})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+const testPublicKey = "Z1XXLsKYkYxuiYjJIkRvtIKFepCYHTgON+GwPq7SOV4="
+
+func wireGuardConfig(t *testing.T, endpoint string) *models.SingBoxConfig {
+	t.Helper()
+	var config models.SingBoxConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"endpoints": [{"type": "direct"}, `+endpoint+`]}`), &config))
+	return &config
+}
+
+func TestSingBoxWireGuardEndpointErrors_Valid(t *testing.T) {
+	config := wireGuardConfig(t, `{
+		"type": "wireguard", "tag": "wg",
+		"address": ["10.0.0.2/32", "fd00::2/128"],
+		"peers": [{"address": "vpn.example.com", "port": 51820, "public_key": "`+testPublicKey+`", "allowed_ips": ["0.0.0.0/0", "::/0"]}]
+	}`)
+	assert.Empty(t, SingBoxWireGuardEndpointErrors(config))
+
+	// A single string is accepted wherever a list is
+	config = wireGuardConfig(t, `{
+		"type": "wireguard", "address": "10.0.0.2/32",
+		"peers": [{"public_key": "`+testPublicKey+`", "allowed_ips": "0.0.0.0/0"}]
+	}`)
+	assert.Empty(t, SingBoxWireGuardEndpointErrors(config))
+}
+
+func TestSingBoxWireGuardEndpointErrors_Invalid(t *testing.T) {
+	config := wireGuardConfig(t, `{
+		"type": "wireguard",
+		"address": ["10.0.0.2"],
+		"peers": [
+			{"public_key": "not-a-key", "allowed_ips": ["0.0.0.0/0"]},
+			{"public_key": "`+testPublicKey+`", "allowed_ips": ["0.0.0.0/0", "10.0.0.0/33"]},
+			{"allowed_ips": []}
+		]
+	}`)
+
+	errs := SingBoxWireGuardEndpointErrors(config)
+	fields := make([]string, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	assert.ElementsMatch(t, []string{
+		"endpoints[1].address[0]",
+		"endpoints[1].peers[0].public_key",
+		"endpoints[1].peers[1].allowed_ips[1]",
+		"endpoints[1].peers[2].public_key",
+	}, fields)
+
+	config = wireGuardConfig(t, `{"type": "wireguard"}`)
+	assert.Len(t, SingBoxWireGuardEndpointErrors(config), 2, "missing address and peers")
+}
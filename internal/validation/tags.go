@@ -0,0 +1,89 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// XrayDuplicateTags reports inbound tags and outbound tags that are used more
+// than once. Xray refuses to start with such a config. Inbounds and outbounds
+// are separate namespaces, so an inbound may share a tag with an outbound.
+func XrayDuplicateTags(config *models.XrayConfig) []ValidationError {
+	inbounds := make([]string, len(config.Inbounds))
+	for i, in := range config.Inbounds {
+		inbounds[i] = in.Tag
+	}
+	outbounds := make([]string, len(config.Outbounds))
+	for i, out := range config.Outbounds {
+		if out.Tag != nil {
+			outbounds[i] = *out.Tag
+		}
+	}
+	return append(checkDuplicateTags("inbounds", inbounds), checkDuplicateTags("outbounds", outbounds)...)
+}
+
+// SingBoxDuplicateTags is the SingBox counterpart of XrayDuplicateTags.
+// Unlike Xray, sing-box keeps one namespace for all tags, so an outbound
+// reusing an inbound tag is reported as well.
+func SingBoxDuplicateTags(config *models.SingBoxConfig) []ValidationError {
+	var inbounds, outbounds []string
+	for _, in := range config.Inbounds {
+		if in != nil {
+			inbounds = append(inbounds, in.Tag)
+		}
+	}
+	for _, out := range config.Outbounds {
+		if out != nil {
+			outbounds = append(outbounds, out.Tag)
+		}
+	}
+	errs := append(checkDuplicateTags("inbounds", inbounds), checkDuplicateTags("outbounds", outbounds)...)
+
+	inboundIndex := make(map[string]int, len(inbounds))
+	for i, tag := range inbounds {
+		if _, seen := inboundIndex[tag]; !seen && tag != "" {
+			inboundIndex[tag] = i
+		}
+	}
+	reported := make(map[string]bool)
+	for i, tag := range outbounds {
+		j, clash := inboundIndex[tag]
+		if !clash || reported[tag] {
+			continue
+		}
+		errs = append(errs, ValidationError{
+			Field:   fmt.Sprintf("outbounds[%d].tag", i),
+			Message: fmt.Sprintf("duplicate tag, already used by inbounds[%d]", j),
+			Value:   tag,
+		})
+		reported[tag] = true
+	}
+	return errs
+}
+
+// checkDuplicateTags reports every repeated non-empty tag once, at the index
+// of its second occurrence. Untagged entries are ignored.
+func checkDuplicateTags(section string, tags []string) []ValidationError {
+	var errs []ValidationError
+	first := make(map[string]int, len(tags))
+	for i, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		prev, seen := first[tag]
+		if !seen {
+			first[tag] = i
+			continue
+		}
+		if prev >= 0 {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("%s[%d].tag", section, i),
+				Message: fmt.Sprintf("duplicate tag, already used by %s[%d]", section, prev),
+				Value:   tag,
+			})
+			first[tag] = -1 // Report each tag only once
+		}
+	}
+	return errs
+}
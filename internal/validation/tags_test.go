@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+func TestXrayDuplicateTags(t *testing.T) {
+	config := &models.XrayConfig{
+		Inbounds: []models.InboundObject{
+			{Tag: "vless-in"}, {Tag: ""}, {Tag: "vless-in"}, {Tag: ""}, {Tag: "vless-in"},
+		},
+		Outbounds: []models.OutboundObject{{Tag: strPtr("vless-in")}, {Tag: strPtr("direct")}},
+	}
+
+	errs := XrayDuplicateTags(config)
+	require.Len(t, errs, 1, "each tag is reported once; untagged and cross-section reuse is fine")
+	assert.Equal(t, "inbounds[2].tag", errs[0].Field)
+	assert.Equal(t, "vless-in", errs[0].Value)
+	assert.Contains(t, errs[0].Message, "inbounds[0]")
+}
+
+func TestSingBoxDuplicateTags(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Inbounds:  []*models.SingBoxInbound{{Tag: "mixed-in"}},
+		Outbounds: []*models.SingBoxOutbound{{Tag: "direct"}, {Tag: "proxy"}, {Tag: "direct"}},
+	}
+
+	errs := SingBoxDuplicateTags(config)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "outbounds[2].tag", errs[0].Field)
+
+	config.Outbounds = config.Outbounds[:2]
+	assert.Empty(t, SingBoxDuplicateTags(config))
+}
+
+func TestSingBoxDuplicateTags_Inbounds(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Inbounds: []*models.SingBoxInbound{{Tag: "mixed-in"}, {Tag: "tun-in"}, {Tag: "mixed-in"}},
+	}
+
+	errs := SingBoxDuplicateTags(config)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "inbounds[2].tag", errs[0].Field)
+	assert.Equal(t, "mixed-in", errs[0].Value)
+}
+
+func TestSingBoxDuplicateTags_AcrossInboundsAndOutbounds(t *testing.T) {
+	config := &models.SingBoxConfig{
+		Inbounds:  []*models.SingBoxInbound{{Tag: "mixed-in"}, {Tag: ""}},
+		Outbounds: []*models.SingBoxOutbound{{Tag: "direct"}, {Tag: "mixed-in"}, {Tag: "mixed-in"}, {Tag: ""}},
+	}
+
+	errs := SingBoxDuplicateTags(config)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "outbounds[2].tag", errs[0].Field, "repeat within outbounds")
+	assert.Equal(t, "outbounds[1].tag", errs[1].Field, "clash with an inbound is reported once")
+	assert.Contains(t, errs[1].Message, "inbounds[0]")
+}
@@ -0,0 +1,41 @@
+// Package validation checks stored Xray and SingBox configurations for
+// problems the JSON schema alone cannot express.
+package validation
+
+import (
+	"fmt"
+
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// ValidationError describes a single problem found in a configuration.
+// Field is a JSON-path style reference such as "inbounds[2].tag".
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"error"`
+	Value   string `json:"value,omitempty"`
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	if e.Value != "" {
+		return fmt.Sprintf("%s: %s (%q)", e.Field, e.Message, e.Value)
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// XrayConfigErrors runs the checks every stored Xray config must pass. The
+// store refuses to save a config that fails any of them.
+func XrayConfigErrors(config *models.XrayConfig) []ValidationError {
+	return append(XrayDuplicateTags(config), XrayDetourCycles(config)...)
+}
+
+// SingBoxConfigErrors is the SingBox counterpart of XrayConfigErrors.
+func SingBoxConfigErrors(config *models.SingBoxConfig) []ValidationError {
+	errs := SingBoxDuplicateTags(config)
+	errs = append(errs, SingBoxDetourCycles(config)...)
+	errs = append(errs, SingBoxUnknownTypes(config)...)
+	errs = append(errs, ValidateSingBoxRouteRules(config.Route)...)
+	errs = append(errs, SingBoxInboundPortErrors(config)...)
+	return append(errs, SingBoxWireGuardEndpointErrors(config)...)
+}
@@ -0,0 +1,74 @@
+// Package version compares the dotted release versions used by Xray and
+// sing-box (e.g. "1.8.4", "v1.12.0", "1.12.0-beta.3").
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Environment variables naming the Xray versions the panel works with.
+// EnvXrayDefaultVersion is recorded on new configs that do not specify a
+// model version; configs authored for a version older than
+// EnvXrayCurrentVersion are flagged when rendered.
+const (
+	EnvXrayDefaultVersion = "XRAY_DEFAULT_VERSION"
+	EnvXrayCurrentVersion = "XRAY_CURRENT_VERSION"
+)
+
+// Compare returns -1, 0 or +1 depending on whether a is older than, equal to
+// or newer than b. A leading "v" is ignored, missing components count as zero
+// and a pre-release ("-beta.1") sorts before the matching release. Components
+// that are not numbers compare as zero.
+func Compare(a, b string) int {
+	coreA, preA := split(a)
+	coreB, preB := split(b)
+
+	for i := 0; i < len(coreA) || i < len(coreB); i++ {
+		var x, y int
+		if i < len(coreA) {
+			x = coreA[i]
+		}
+		if i < len(coreB) {
+			y = coreB[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	case preA < preB:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// AtLeast reports whether v is the same as or newer than min.
+func AtLeast(v, min string) bool {
+	return Compare(v, min) >= 0
+}
+
+func split(v string) ([]int, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	var pre string
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(p)
+		parts = append(parts, n)
+	}
+	return parts, pre
+}
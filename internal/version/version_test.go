@@ -0,0 +1,27 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.12.0", "1.12.0", 0},
+		{"v1.12", "1.12.0", 0},
+		{"1.11.4", "1.12.0", -1},
+		{"1.12.10", "1.12.9", 1},
+		{"1.12.0-beta.3", "1.12.0", -1},
+		{"1.12.0-beta.3", "1.12.0-beta.2", 1},
+		{"25.3.6", "1.8.24", 1},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, Compare(tc.a, tc.b), "%s vs %s", tc.a, tc.b)
+	}
+	assert.True(t, AtLeast("1.12.1", "1.12.0"))
+	assert.False(t, AtLeast("1.9.0", "1.12.0"))
+}
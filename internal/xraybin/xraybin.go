@@ -0,0 +1,148 @@
+// Package xraybin runs a locally installed xray binary against generated
+// configurations for authoritative validation.
+package xraybin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/tools4net/ezfw/backend/internal/generator"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// EnvBinary names the environment variable holding the xray binary path.
+// EnvBinaryPath is accepted as an alias when EnvBinary is unset.
+const (
+	EnvBinary     = "XRAY_BINARY"
+	EnvBinaryPath = "XRAY_BINARY_PATH"
+)
+
+// DefaultTimeout bounds a single `xray -test` run.
+const DefaultTimeout = 15 * time.Second
+
+// ErrNotConfigured is returned by NewTesterFromEnv when neither EnvBinary nor
+// EnvBinaryPath is set. API handlers map it to 501 Not Implemented.
+var ErrNotConfigured = errors.New(EnvBinary + " is not set")
+
+// TestResult is the outcome of running `xray -test` on a config.
+type TestResult struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+}
+
+// Tester runs `<Binary> -test -config <file>`.
+type Tester struct {
+	Binary  string
+	Timeout time.Duration
+}
+
+// NewTesterFromEnv returns a Tester for the binary named by EnvBinary or
+// EnvBinaryPath.
+func NewTesterFromEnv() (*Tester, error) {
+	binary := os.Getenv(EnvBinary)
+	if binary == "" {
+		binary = os.Getenv(EnvBinaryPath)
+	}
+	if binary == "" {
+		return nil, ErrNotConfigured
+	}
+	return &Tester{Binary: binary, Timeout: DefaultTimeout}, nil
+}
+
+// Test renders config to a temporary file and runs the binary on it. A
+// non-zero exit code is reported in the result, not as an error; errors are
+// reserved for failures to render the config or start the binary.
+func (t *Tester) Test(ctx context.Context, config *models.XrayConfig) (*TestResult, error) {
+	doc, err := generator.RenderXray(config)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "xray-test-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("create temp config: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(doc); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write temp config: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("write temp config: %w", err)
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.Binary, "-test", "-config", f.Name())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	result := &TestResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, nil
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case runErr == nil:
+	case errors.As(runErr, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return nil, fmt.Errorf("run %s: %w", t.Binary, runErr)
+	}
+	return result, nil
+}
+
+// ValidateTimeout bounds a Validate run.
+const ValidateTimeout = 10 * time.Second
+
+// Validation is the summarised verdict of the xray binary on a config.
+// Valid is nil when no binary is configured, with Reason explaining why.
+type Validation struct {
+	Valid  *bool  `json:"valid"`
+	Error  string `json:"error,omitempty"`  // xray output when the config is rejected
+	Reason string `json:"reason,omitempty"` // why no verdict could be given
+}
+
+// Validate runs config through the tester's binary with ValidateTimeout and
+// condenses the result. A nil tester yields a nil verdict rather than an
+// error so callers can always respond with the Validation as-is.
+func Validate(ctx context.Context, t *Tester, config *models.XrayConfig) (*Validation, error) {
+	if t == nil {
+		return &Validation{Reason: "xray binary not configured"}, nil
+	}
+	bounded := *t
+	if bounded.Timeout <= 0 || bounded.Timeout > ValidateTimeout {
+		bounded.Timeout = ValidateTimeout
+	}
+	result, err := bounded.Test(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := result.ExitCode == 0 && !result.TimedOut
+	v := &Validation{Valid: &valid}
+	switch {
+	case result.TimedOut:
+		v.Error = fmt.Sprintf("xray did not finish within %s", bounded.Timeout)
+	case !valid:
+		v.Error = strings.TrimSpace(result.Stdout + "\n" + result.Stderr)
+	}
+	return v, nil
+}
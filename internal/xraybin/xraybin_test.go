@@ -0,0 +1,85 @@
+package xraybin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tools4net/ezfw/backend/internal/models"
+)
+
+// stubBinary writes an executable shell script standing in for xray.
+func stubBinary(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "xray")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+	return path
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestTester_PassesConfigFile(t *testing.T) {
+	// Echo the arguments and the config file content back on stdout
+	tester := &Tester{Binary: stubBinary(t, "echo \"$1 $2\"\ncat \"$3\"\necho oops >&2\nexit 23\n")}
+	config := &models.XrayConfig{Name: "panel-only", Log: &models.LogObject{Loglevel: strPtr("debug")}}
+
+	result, err := tester.Test(context.Background(), config)
+	require.NoError(t, err)
+	assert.Equal(t, 23, result.ExitCode)
+	assert.Contains(t, result.Stdout, "-test -config")
+	assert.Contains(t, result.Stdout, `"loglevel": "debug"`)
+	assert.NotContains(t, result.Stdout, "panel-only", "panel metadata must not reach the binary")
+	assert.Equal(t, "oops\n", result.Stderr)
+}
+
+func TestTester_Timeout(t *testing.T) {
+	tester := &Tester{Binary: stubBinary(t, "exec sleep 5\n"), Timeout: 50 * time.Millisecond}
+
+	result, err := tester.Test(context.Background(), &models.XrayConfig{})
+	require.NoError(t, err)
+	assert.True(t, result.TimedOut)
+}
+
+func TestNewTesterFromEnv(t *testing.T) {
+	t.Setenv(EnvBinary, "")
+	t.Setenv(EnvBinaryPath, "")
+	_, err := NewTesterFromEnv()
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	t.Setenv(EnvBinaryPath, "/opt/xray")
+	tester, err := NewTesterFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/xray", tester.Binary)
+
+	t.Setenv(EnvBinary, "/usr/local/bin/xray")
+	tester, err = NewTesterFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/local/bin/xray", tester.Binary)
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+
+	v, err := Validate(ctx, nil, &models.XrayConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, v.Valid)
+	assert.Equal(t, "xray binary not configured", v.Reason)
+
+	ok := &Tester{Binary: stubBinary(t, "echo 'Configuration OK.'\n")}
+	v, err = Validate(ctx, ok, &models.XrayConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, v.Valid)
+	assert.True(t, *v.Valid)
+	assert.Empty(t, v.Error)
+
+	bad := &Tester{Binary: stubBinary(t, "echo 'Failed to start: unknown protocol' >&2\nexit 23\n")}
+	v, err = Validate(ctx, bad, &models.XrayConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, v.Valid)
+	assert.False(t, *v.Valid)
+	assert.Equal(t, "Failed to start: unknown protocol", v.Error)
+}